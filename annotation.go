@@ -0,0 +1,173 @@
+package sadl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AnnotationTargets are the kinds of definitions an annotation declaration may restrict its use to.
+var AnnotationTargets = []string{"model", "type", "field", "element", "const", "operation", "http", "auth", "service", "resource", "example"}
+
+// BuiltinAnnotations are the annotations that the parser and the generators in this repo produce or consume. These are
+// always allowed.
+var BuiltinAnnotations = map[string]bool{
+	"x_include":            true,
+	"x_tags":               true,
+	"x_paginated":          true,
+	"x_server":             true,
+	"x_license_name":       true,
+	"x_license_url":        true,
+	"x_openapi_version":    true,
+	"x_deprecated":         true,
+	"x_deprecated_message": true,
+	"x_deprecated_since":   true,
+	"x_enumValue":          true,
+	"x_unique":             true,
+	"x_integer":            true,
+	"x_sensitive":          true,
+	"x_error":              true,
+	"x_httpError":          true,
+	"x_timestampFormat":    true,
+	"x_wire_name":          true,
+}
+
+func (model *Model) FindAnnotationDef(name string) *AnnotationDef {
+	for _, ad := range model.AnnotationDefs {
+		if ad.Name == name {
+			return ad
+		}
+	}
+	return nil
+}
+
+// ValidateAnnotations checks every x_* annotation in the model against the annotation declarations. If the model
+// declares no annotations, annotations are not checked. All problems found are returned, as Diagnostics.
+func (model *Model) ValidateAnnotations() error {
+	if len(model.AnnotationDefs) == 0 {
+		return nil
+	}
+	var ds Diagnostics
+	for _, ad := range model.AnnotationDefs {
+		if model.FindType(ad.Type) == nil {
+			ds.add(spanError(ad.Span, fmt.Errorf("Annotation %s: no such type '%s'", ad.Name, ad.Type)))
+		}
+		for _, target := range ad.Targets {
+			if !containsOption(AnnotationTargets, target) {
+				ds.add(spanError(ad.Span, fmt.Errorf("Annotation %s: bad target %q, expected one of %v", ad.Name, target, AnnotationTargets)))
+			}
+		}
+	}
+	if len(ds) > 0 {
+		return ds
+	}
+	model.validateAnnotationUses(&ds, "model", model.Name, nil, model.Annotations)
+	for _, cd := range model.Constants {
+		model.validateAnnotationUses(&ds, "const", cd.Name, cd.Span, cd.Annotations)
+	}
+	for _, td := range model.Types {
+		model.validateAnnotationUses(&ds, "type", td.Name, td.Span, td.Annotations)
+		model.validateTypeSpecAnnotationUses(&ds, td.Name, &td.TypeSpec)
+	}
+	for _, op := range model.Operations {
+		model.validateAnnotationUses(&ds, "operation", op.Name, op.Span, op.Annotations)
+		for _, in := range op.Inputs {
+			model.validateAnnotationUses(&ds, "field", op.Name+"."+in.Name, in.Span, in.Annotations)
+		}
+		for _, out := range op.Outputs {
+			model.validateAnnotationUses(&ds, "field", op.Name+"."+out.Name, op.Span, out.Annotations)
+		}
+	}
+	for _, hd := range model.Http {
+		model.validateAnnotationUses(&ds, "http", hd.Name, hd.Span, hd.Annotations)
+		for _, in := range hd.Inputs {
+			model.validateAnnotationUses(&ds, "field", hd.Name+"."+in.Name, in.Span, in.Annotations)
+		}
+		if hd.Expected != nil {
+			model.validateAnnotationUses(&ds, "http", hd.Name, hd.Expected.Span, hd.Expected.Annotations)
+			for _, out := range hd.Expected.Outputs {
+				model.validateAnnotationUses(&ds, "field", hd.Name+"."+out.Name, out.Span, out.Annotations)
+			}
+		}
+		for _, exc := range hd.Exceptions {
+			model.validateAnnotationUses(&ds, "http", hd.Name, exc.Span, exc.Annotations)
+			for _, out := range exc.Outputs {
+				model.validateAnnotationUses(&ds, "field", hd.Name+"."+out.Name, out.Span, out.Annotations)
+			}
+		}
+	}
+	for _, ad := range model.Auth {
+		model.validateAnnotationUses(&ds, "auth", ad.Name, ad.Span, ad.Annotations)
+	}
+	for _, sd := range model.Services {
+		model.validateAnnotationUses(&ds, "service", sd.Name, sd.Span, sd.Annotations)
+	}
+	for _, rd := range model.Resources {
+		model.validateAnnotationUses(&ds, "resource", rd.Name, rd.Span, rd.Annotations)
+	}
+	for _, exc := range model.Exceptions {
+		model.validateAnnotationUses(&ds, "http", model.Name, exc.Span, exc.Annotations)
+		for _, out := range exc.Outputs {
+			model.validateAnnotationUses(&ds, "field", model.Name+"."+out.Name, out.Span, out.Annotations)
+		}
+	}
+	for _, ex := range model.Examples {
+		model.validateAnnotationUses(&ds, "example", ex.Target, ex.Span, ex.Annotations)
+	}
+	return ds.err()
+}
+
+func (model *Model) validateTypeSpecAnnotationUses(ds *Diagnostics, context string, ts *TypeSpec) {
+	for _, fd := range ts.Fields {
+		model.validateAnnotationUses(ds, "field", context+"."+fd.Name, fd.Span, fd.Annotations)
+		model.validateTypeSpecAnnotationUses(ds, context+"."+fd.Name, &fd.TypeSpec)
+	}
+	for _, vd := range ts.Variants {
+		model.validateAnnotationUses(ds, "field", context+"."+vd.Name, vd.Span, vd.Annotations)
+	}
+	for _, el := range ts.Elements {
+		model.validateAnnotationUses(ds, "element", context+"."+el.Symbol, el.Span, el.Annotations)
+	}
+}
+
+func (model *Model) validateAnnotationUses(ds *Diagnostics, target string, context string, span *Span, annos map[string]interface{}) {
+	names := make([]string, 0, len(annos))
+	for name := range annos {
+		names = append(names, name)
+	}
+	sort.Strings(names) //report in a stable order
+	for _, name := range names {
+		if !strings.HasPrefix(name, "x_") {
+			continue //an extension option, checked by its extension
+		}
+		val := annos[name]
+		ad := model.FindAnnotationDef(name)
+		if ad == nil {
+			if BuiltinAnnotations[name] {
+				continue
+			}
+			ds.add(spanError(span, fmt.Errorf("%s: undeclared annotation: %s", context, name)))
+			continue
+		}
+		if len(ad.Targets) > 0 && !containsOption(ad.Targets, target) {
+			ds.add(spanError(span, fmt.Errorf("%s: annotation %s is not allowed on target %q, only on %v", context, name, target, ad.Targets)))
+			continue
+		}
+		if val == "" && model.BaseType(ad.Type) == "Bool" {
+			//a bare annotation, i.e. (x_flag), is shorthand for x_flag=true
+			val = true
+		}
+		err := model.ValidateAgainstTypeSpec(context+" "+name, &ad.TypeSpec, val)
+		if err != nil {
+			ds.add(spanError(span, fmt.Errorf("%s: bad value for annotation %s: %v", context, name, err)))
+		}
+	}
+}
+
+// attributes the error to the span, if known
+func spanError(span *Span, err error) error {
+	if span == nil {
+		return err
+	}
+	return &Diagnostic{Severity: SeverityError, Span: span, Message: err.Error()}
+}
@@ -0,0 +1,725 @@
+package sadl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder constructs a model in code, as an alternative to parsing SADL source, i.e.
+//
+//	model, err := sadl.NewBuilder("crudl").
+//		Type("ItemId", "String", sadl.Pattern("^[a-z]+$")).
+//		Struct("Item").Field("id", "ItemId", sadl.Required()).Field("tags", "Array<String>").
+//		Http("GET", "/items/{id}").Input("id", "ItemId").Expect(200).Output("body", "Item").
+//		Build()
+//
+// Each call checks what it is given. The problems found are reported by Build, together with any found by validating
+// the whole model, just as for parsed source.
+type Builder struct {
+	schema      *Schema
+	defined     map[string]bool
+	diagnostics Diagnostics
+}
+
+// StructBuilder adds fields to the Struct type most recently started. The Builder methods start the next definition.
+type StructBuilder struct {
+	*Builder
+	td *TypeDef
+}
+
+// HttpBuilder adds inputs, outputs, and exceptions to the http action most recently started. The Builder methods start
+// the next definition.
+type HttpBuilder struct {
+	*Builder
+	hd *HttpDef
+}
+
+// An Option sets one of the options of a definition, as written in parentheses in SADL source.
+type Option struct {
+	name string
+	set  func(opts *builderOptions)
+}
+
+type builderOptions struct {
+	Options
+	comment string
+}
+
+func NewBuilder(name string) *Builder {
+	b := &Builder{
+		schema: &Schema{
+			Sadl:  Version,
+			Name:  name,
+			Types: make([]*TypeDef, 0),
+		},
+		defined: make(map[string]bool),
+	}
+	b.checkName("model", name)
+	return b
+}
+
+func (b *Builder) Namespace(ns string) *Builder {
+	b.schema.Namespace = ns
+	return b
+}
+
+func (b *Builder) Version(version string) *Builder {
+	b.schema.Version = version
+	return b
+}
+
+func (b *Builder) Base(base string) *Builder {
+	if base != "" && !strings.HasPrefix(base, "/") {
+		b.fail(fmt.Errorf("Bad base path value: %s", base))
+	}
+	b.schema.Base = base
+	return b
+}
+
+// Auth adds an authentication scheme of the service, one of "apiKey", "bearer", "basic", "oauth2", or "mutualTLS". An
+// http action requires any one of the schemes, unless it names others with the AuthSchemes option.
+func (b *Builder) Auth(name string, scheme string, opts ...Option) *Builder {
+	b.checkName("auth", name)
+	for _, ad := range b.schema.Auth {
+		if ad.Name == name {
+			b.fail(fmt.Errorf("Duplicate auth scheme: %s", name))
+			return b
+		}
+	}
+	var acceptable []string
+	switch scheme {
+	case "apiKey":
+		acceptable = []string{"header", "query", "cookie"}
+	case "bearer":
+		acceptable = []string{"format"}
+	case "oauth2":
+		acceptable = []string{"authorizationUrl", "tokenUrl", "scopes"}
+	}
+	o := b.options("auth "+name, acceptable, opts)
+	b.schema.Auth = append(b.schema.Auth, &AuthDef{
+		Name:             name,
+		Scheme:           scheme,
+		Header:           o.Header,
+		Query:            o.Query,
+		Cookie:           o.Cookie,
+		Format:           o.Format,
+		AuthorizationUrl: o.AuthorizationUrl,
+		TokenUrl:         o.TokenUrl,
+		Scopes:           o.Scopes,
+		Comment:          o.comment,
+		Annotations:      o.Annotations,
+	})
+	return b
+}
+
+// Service adds a service with its own version and base path. The http actions of the service name it with the Service
+// option, which they may omit if it is the only one.
+func (b *Builder) Service(name string, opts ...Option) *Builder {
+	b.checkName("service", name)
+	for _, sd := range b.schema.Services {
+		if sd.Name == name {
+			b.fail(fmt.Errorf("Duplicate service: %s", name))
+			return b
+		}
+	}
+	o := b.options("service "+name, []string{"version", "base"}, opts)
+	if o.Base != "" && !strings.HasPrefix(o.Base, "/") {
+		b.fail(fmt.Errorf("Bad base path value: %s", o.Base))
+	}
+	b.schema.Services = append(b.schema.Services, &ServiceDef{
+		Name:        name,
+		Version:     o.Version,
+		Base:        o.Base,
+		Comment:     o.comment,
+		Annotations: o.Annotations,
+	})
+	return b
+}
+
+// Resource adds a resource, with the Identifiers option and a Lifecycle option for each http action it binds.
+func (b *Builder) Resource(name string, opts ...Option) *Builder {
+	b.checkName("resource", name)
+	for _, rd := range b.schema.Resources {
+		if rd.Name == name {
+			b.fail(fmt.Errorf("Duplicate resource: %s", name))
+			return b
+		}
+	}
+	o := b.options("resource "+name, append([]string{"identifiers"}, ResourceLifecycle...), opts)
+	b.schema.Resources = append(b.schema.Resources, &ResourceDef{
+		Name:        name,
+		Identifiers: o.Identifiers,
+		Create:      o.Create,
+		Read:        o.Read,
+		Update:      o.Update,
+		Delete:      o.Delete,
+		List:        o.List,
+		Comment:     o.comment,
+		Annotations: o.Annotations,
+	})
+	return b
+}
+
+// Except adds an exceptional response common to all http actions, unless an action has its own with that status or
+// type. A status of 0 makes it the default, i.e. the error envelope of any other error response.
+func (b *Builder) Except(status int32, typ string, opts ...Option) *Builder {
+	for _, exc := range b.schema.Exceptions {
+		if exc.Type == typ || exc.Status == status {
+			b.fail(fmt.Errorf("Duplicate common exception: %d %s", status, typ))
+			return b
+		}
+	}
+	o := b.options("except "+typ, nil, opts)
+	b.schema.Exceptions = append(b.schema.Exceptions, &HttpExceptionSpec{Type: typ, Status: status, Comment: o.comment, Annotations: o.Annotations})
+	return b
+}
+
+// Type defines a type based on another, which may be written with parameters, i.e. "Array<Item>" or
+// "Map<String,Int32>". Use Struct, Enum, and Union for those types.
+func (b *Builder) Type(name string, typ string, opts ...Option) *Builder {
+	ts, ok := b.typeRef(name, typ)
+	if !ok {
+		return b
+	}
+	switch ts.Type {
+	case "Struct", "Enum", "Union":
+		b.fail(fmt.Errorf("Type %s: use the %s method to define %s types", name, ts.Type, ts.Type))
+		return b
+	}
+	td := &TypeDef{Name: name, TypeSpec: *ts}
+	o := b.options("type "+name, typeSpecOptions(ts.Type), opts)
+	td.Comment = o.comment
+	td.Annotations = o.Annotations
+	td.Pattern = o.Pattern
+	td.Values = o.Values
+	td.MinSize = o.MinSize
+	td.MaxSize = o.MaxSize
+	td.Min = o.Min
+	td.Max = o.Max
+	td.Reference = o.Reference
+	if o.Unit != "" {
+		td.Unit = o.Unit
+	}
+	b.addType(td)
+	return b
+}
+
+func (b *Builder) Struct(name string, opts ...Option) *StructBuilder {
+	td := &TypeDef{Name: name, TypeSpec: TypeSpec{Type: "Struct", Fields: make([]*StructFieldDef, 0)}}
+	o := b.options("type "+name, nil, opts)
+	td.Comment = o.comment
+	td.Annotations = o.Annotations
+	b.addType(td)
+	return &StructBuilder{Builder: b, td: td}
+}
+
+func (sb *StructBuilder) Field(name string, typ string, opts ...Option) *StructBuilder {
+	context := sb.td.Name + "." + name
+	sb.checkName("field", name)
+	for _, fd := range sb.td.Fields {
+		if fd.Name == name {
+			sb.fail(fmt.Errorf("Duplicate field: %s", context))
+			return sb
+		}
+	}
+	ts, ok := sb.typeRef(context, typ)
+	if !ok {
+		return sb
+	}
+	fd := &StructFieldDef{Name: name, TypeSpec: *ts}
+	sb.setField(fd, context, append(fieldConstraintOptions(ts.Type), "required", "nullable", "default", "json"), opts)
+	sb.td.Fields = append(sb.td.Fields, fd)
+	return sb
+}
+
+func (b *Builder) Enum(name string, symbols ...string) *Builder {
+	td := &TypeDef{Name: name, TypeSpec: TypeSpec{Type: "Enum"}}
+	for _, sym := range symbols {
+		b.checkName("enum element of "+name, sym)
+		td.Elements = append(td.Elements, &EnumElementDef{Symbol: sym})
+	}
+	b.addType(td)
+	return b
+}
+
+// Union defines a Union type with a variant for each of the given types, named the same as the type.
+func (b *Builder) Union(name string, variants ...string) *Builder {
+	td := &TypeDef{Name: name, TypeSpec: TypeSpec{Type: "Union"}}
+	for _, v := range variants {
+		b.checkName("variant of "+name, v)
+		vd := &UnionVariantDef{Name: v}
+		vd.Type = v
+		td.Variants = append(td.Variants, vd)
+	}
+	b.addType(td)
+	return b
+}
+
+func (b *Builder) Constant(name string, typ string, value interface{}, opts ...Option) *Builder {
+	b.checkName("constant", name)
+	if b.defined[name] {
+		b.fail(fmt.Errorf("Duplicate name: %s", name))
+		return b
+	}
+	b.defined[name] = true
+	o := b.options("const "+name, nil, opts)
+	b.schema.Constants = append(b.schema.Constants, &ConstantDef{
+		Name:        name,
+		Type:        typ,
+		Value:       builderLiteral(value),
+		Comment:     o.comment,
+		Annotations: o.Annotations,
+	})
+	return b
+}
+
+// Example adds an example of the target type. Maps and slices in the value are converted to the generic form that the
+// parser produces.
+func (b *Builder) Example(target string, value interface{}, opts ...Option) *Builder {
+	o := b.options("example for "+target, []string{"name"}, opts)
+	b.schema.Examples = append(b.schema.Examples, &ExampleDef{
+		Target:      target,
+		Name:        o.Name,
+		Example:     builderLiteral(value),
+		Comment:     o.comment,
+		Annotations: o.Annotations,
+	})
+	return b
+}
+
+// Http defines an http action. Its name is derived from the method and path unless the Operation option is given.
+func (b *Builder) Http(method string, path string, opts ...Option) *HttpBuilder {
+	hd := &HttpDef{Method: strings.ToUpper(method), Path: path}
+	switch hd.Method {
+	case "POST", "GET", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS":
+	default:
+		b.fail(fmt.Errorf("HTTP 'method' invalid: %s", method))
+	}
+	o := b.options("http "+method+" "+path, []string{"operation", "resource", "service", "auth", "scopes", "paginated"}, opts)
+	hd.Name = o.Action
+	hd.Resource = o.Resource
+	hd.Service = o.Service
+	hd.Auth = o.Auth
+	hd.Scopes = o.Scopes
+	hd.Anonymous = o.Auth != nil && len(o.Auth) == 0
+	hd.Paginated = o.Paginated
+	hd.Comment = o.comment
+	hd.Annotations = o.Annotations
+	b.schema.Http = append(b.schema.Http, hd)
+	return &HttpBuilder{Builder: b, hd: hd}
+}
+
+// Input adds an input parameter, which is taken from the path or query if the path template has a variable of that
+// name, from a header or a cookie if the Header or Cookie option is given, and otherwise from the body.
+func (hb *HttpBuilder) Input(name string, typ string, opts ...Option) *HttpBuilder {
+	context := hb.hd.Method + " " + hb.hd.Path + " input " + name
+	hb.checkName("input", name)
+	ts, ok := hb.typeRef(context, typ)
+	if !ok {
+		return hb
+	}
+	spec := &HttpParamSpec{StructFieldDef: StructFieldDef{Name: name, TypeSpec: *ts}}
+	o := hb.setField(&spec.StructFieldDef, context, append([]string{"header", "cookie", "csv", "mediaType", "default", "required"}, fieldConstraintOptions(ts.Type)...), opts)
+	spec.Csv = o.Csv
+	spec.MediaType = o.MediaType
+	switch paramType, paramName := parameterSource(hb.hd.Path, name, &o.Options); paramType {
+	case "path":
+		spec.Path = true
+	case "greedy":
+		spec.Path = true
+		spec.Greedy = true
+	case "query":
+		spec.Query = paramName
+	case "header":
+		spec.Header = paramName
+	case "cookie":
+		spec.Cookie = paramName
+	}
+	hb.hd.Inputs = append(hb.hd.Inputs, spec)
+	return hb
+}
+
+// Expect sets the status of the normal response, to which Output then adds.
+func (hb *HttpBuilder) Expect(status int32, opts ...Option) *HttpBuilder {
+	if hb.hd.Expected != nil {
+		hb.fail(fmt.Errorf("Only a single 'expect' directive is allowed per HTTP action: %s %s", hb.hd.Method, hb.hd.Path))
+		return hb
+	}
+	o := hb.options(fmt.Sprintf("expect %d", status), nil, opts)
+	hb.hd.Expected = &HttpExpectedSpec{Status: status, Comment: o.comment, Annotations: o.Annotations}
+	return hb
+}
+
+// Output adds an output of the normal response, which is taken from a header or a cookie if the Header or Cookie
+// option is given, and otherwise is the body.
+func (hb *HttpBuilder) Output(name string, typ string, opts ...Option) *HttpBuilder {
+	context := hb.hd.Method + " " + hb.hd.Path + " output " + name
+	if hb.hd.Expected == nil {
+		hb.fail(fmt.Errorf("%s: Expect must be called before Output", context))
+		return hb
+	}
+	hb.checkName("output", name)
+	ts, ok := hb.typeRef(context, typ)
+	if !ok {
+		return hb
+	}
+	spec := &HttpParamSpec{StructFieldDef: StructFieldDef{Name: name, TypeSpec: *ts}}
+	o := hb.setField(&spec.StructFieldDef, context, append([]string{"header", "cookie", "mediaType", "default", "required"}, fieldConstraintOptions(ts.Type)...), opts)
+	spec.Header = o.Header
+	spec.Cookie = o.Cookie
+	spec.MediaType = o.MediaType
+	hb.hd.Expected.Outputs = append(hb.hd.Expected.Outputs, spec)
+	return hb
+}
+
+// Except adds an exceptional response, with the given type as its body.
+func (hb *HttpBuilder) Except(status int32, typ string, opts ...Option) *HttpBuilder {
+	for _, exc := range hb.hd.Exceptions {
+		if exc.Type == typ {
+			hb.fail(fmt.Errorf("Duplicate HTTP action exception type: %s", typ))
+			return hb
+		}
+	}
+	o := hb.options("except "+typ, nil, opts)
+	hb.hd.Exceptions = append(hb.hd.Exceptions, &HttpExceptionSpec{Type: typ, Status: status, Comment: o.comment, Annotations: o.Annotations})
+	return hb
+}
+
+// ExceptOutput adds an output to the most recent exceptional response, which is taken from a header or a cookie if the
+// Header or Cookie option is given, and otherwise is the body, of the type given to Except.
+func (hb *HttpBuilder) ExceptOutput(name string, typ string, opts ...Option) *HttpBuilder {
+	context := hb.hd.Method + " " + hb.hd.Path + " exception output " + name
+	if len(hb.hd.Exceptions) == 0 {
+		hb.fail(fmt.Errorf("%s: Except must be called before ExceptOutput", context))
+		return hb
+	}
+	exc := hb.hd.Exceptions[len(hb.hd.Exceptions)-1]
+	hb.checkName("output", name)
+	ts, ok := hb.typeRef(context, typ)
+	if !ok {
+		return hb
+	}
+	spec := &HttpParamSpec{StructFieldDef: StructFieldDef{Name: name, TypeSpec: *ts}}
+	o := hb.setField(&spec.StructFieldDef, context, append([]string{"header", "cookie", "mediaType", "default", "required"}, fieldConstraintOptions(ts.Type)...), opts)
+	spec.Header = o.Header
+	spec.Cookie = o.Cookie
+	spec.MediaType = o.MediaType
+	exc.Outputs = append(exc.Outputs, spec)
+	return hb
+}
+
+// Build returns the model, or the problems found in it.
+func (b *Builder) Build() (*Model, error) {
+	if len(b.diagnostics) > 0 {
+		return nil, b.diagnostics
+	}
+	for _, hd := range b.schema.Http {
+		err := ensureActionName(hd)
+		if err != nil {
+			return nil, err
+		}
+		ensureRequiredParams(hd)
+	}
+	return validateSchema(b.schema, "", nil)
+}
+
+func (b *Builder) fail(err error) {
+	b.diagnostics.add(err)
+}
+
+func (b *Builder) checkName(kind string, name string) {
+	if !IsSymbol(name) {
+		b.fail(fmt.Errorf("Bad %s name: %q", kind, name))
+	}
+}
+
+func (b *Builder) addType(td *TypeDef) {
+	b.checkName("type", td.Name)
+	if b.defined[td.Name] {
+		b.fail(fmt.Errorf("Duplicate type: %s", td.Name))
+		return
+	}
+	b.defined[td.Name] = true
+	b.schema.Types = append(b.schema.Types, td)
+}
+
+// typeRef returns the type spec for a type written as in SADL source, i.e. "Item", "Array<Item>", or
+// "Map<String,Item>".
+func (b *Builder) typeRef(context string, typ string) (*TypeSpec, bool) {
+	ts := &TypeSpec{Type: typ}
+	var params []string
+	if i := strings.Index(typ, "<"); i > 0 && strings.HasSuffix(typ, ">") {
+		ts.Type = typ[:i]
+		for _, param := range strings.Split(typ[i+1:len(typ)-1], ",") {
+			params = append(params, strings.TrimSpace(param))
+		}
+	}
+	if !IsSymbol(ts.Type) {
+		b.fail(fmt.Errorf("%s: bad type %q", context, typ))
+		return nil, false
+	}
+	for _, param := range params {
+		if !IsSymbol(param) {
+			b.fail(fmt.Errorf("%s: bad type parameter in %q", context, typ))
+			return nil, false
+		}
+	}
+	expected := 0
+	switch ts.Type {
+	case "Array", "List":
+		ts.Type = "Array"
+		expected = 1
+		if len(params) == 0 {
+			ts.Items = "Any"
+			expected = 0
+		} else {
+			ts.Items = params[0]
+		}
+	case "Map":
+		expected = 2
+		if len(params) == 0 {
+			ts.Keys, ts.Items = "String", "Any"
+			expected = 0
+		} else if len(params) == 2 {
+			ts.Keys, ts.Items = params[0], params[1]
+		}
+	case "UnitValue":
+		expected = 2
+		if len(params) == 2 {
+			ts.Value, ts.Unit = params[0], params[1]
+		}
+	}
+	if len(params) != expected {
+		b.fail(fmt.Errorf("%s: wrong number of type parameters in %q", context, typ))
+		return nil, false
+	}
+	return ts, true
+}
+
+// options applies the options, checking that those other than annotations and comments are acceptable.
+func (b *Builder) options(context string, acceptable []string, opts []Option) *builderOptions {
+	o := &builderOptions{}
+	for _, opt := range opts {
+		if opt.name != "comment" && !strings.HasPrefix(opt.name, "x_") && !containsOption(acceptable, opt.name) {
+			b.fail(fmt.Errorf("Unrecognized option for %s: %s", context, opt.name))
+			continue
+		}
+		opt.set(o)
+	}
+	return o
+}
+
+func (b *Builder) setField(fd *StructFieldDef, context string, acceptable []string, opts []Option) *builderOptions {
+	o := b.options(context, acceptable, opts)
+	fd.Comment = o.comment
+	fd.Annotations = o.Annotations
+	fd.Required = o.Required
+	fd.Nullable = o.Nullable
+	fd.Default = o.Default
+	setConstraints(&fd.TypeSpec, &o.Options)
+	return o
+}
+
+// builderLiteral converts Go numbers to Decimals, and slices and maps to their generic forms, as the parser
+// produces them.
+func builderLiteral(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int, int8, int16, int32, int64, float32, float64:
+		return DecimalValue(nil, val)
+	case []string:
+		items := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			items = append(items, item)
+		}
+		return items
+	case []interface{}:
+		items := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			items = append(items, builderLiteral(item))
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = builderLiteral(item)
+		}
+		return m
+	}
+	return v
+}
+
+func Required() Option {
+	return Option{"required", func(o *builderOptions) { o.Required = true }}
+}
+
+func Nullable() Option {
+	return Option{"nullable", func(o *builderOptions) { o.Nullable = true }}
+}
+
+func Default(value interface{}) Option {
+	return Option{"default", func(o *builderOptions) { o.Default = builderLiteral(value) }}
+}
+
+func Pattern(pattern string) Option {
+	return Option{"pattern", func(o *builderOptions) { o.Pattern = pattern }}
+}
+
+func Values(values ...string) Option {
+	return Option{"values", func(o *builderOptions) { o.Values = values }}
+}
+
+func MinSize(n int64) Option {
+	return Option{"minsize", func(o *builderOptions) { o.MinSize = &n }}
+}
+
+func MaxSize(n int64) Option {
+	return Option{"maxsize", func(o *builderOptions) { o.MaxSize = &n }}
+}
+
+func Min(n interface{}) Option {
+	return Option{"min", func(o *builderOptions) { o.Min = DecimalValue(nil, n) }}
+}
+
+func Max(n interface{}) Option {
+	return Option{"max", func(o *builderOptions) { o.Max = DecimalValue(nil, n) }}
+}
+
+func Reference(typeName string) Option {
+	return Option{"reference", func(o *builderOptions) { o.Reference = typeName }}
+}
+
+// Unit names the String or Enum type of the units of a UnitValue.
+func Unit(typeName string) Option {
+	return Option{"unit", func(o *builderOptions) { o.Unit = typeName }}
+}
+
+func Header(name string) Option {
+	return Option{"header", func(o *builderOptions) { o.Header = name }}
+}
+
+// Cookie takes an http input from the cookie of that name, or sets it with an output.
+func Cookie(name string) Option {
+	return Option{"cookie", func(o *builderOptions) { o.Cookie = name }}
+}
+
+// Csv makes an Array query parameter a single comma-separated value, rather than repeated for each item.
+func Csv() Option {
+	return Option{"csv", func(o *builderOptions) { o.Csv = true }}
+}
+
+// MediaType gives the body of an http request or response a media type other than JSON, i.e. "image/png" for Bytes, or
+// "application/x-www-form-urlencoded" or "multipart/form-data" for a Struct sent as a form.
+func MediaType(mediaType string) Option {
+	return Option{"mediaType", func(o *builderOptions) { o.MediaType = mediaType }}
+}
+
+// Query takes the key of an apiKey auth scheme from the query parameter of that name.
+func Query(name string) Option {
+	return Option{"query", func(o *builderOptions) { o.Query = name }}
+}
+
+// Format describes the token of a bearer auth scheme, i.e. "JWT".
+func Format(format string) Option {
+	return Option{"format", func(o *builderOptions) { o.Format = format }}
+}
+
+func AuthorizationUrl(url string) Option {
+	return Option{"authorizationUrl", func(o *builderOptions) { o.AuthorizationUrl = url }}
+}
+
+func TokenUrl(url string) Option {
+	return Option{"tokenUrl", func(o *builderOptions) { o.TokenUrl = url }}
+}
+
+// Scopes are those an oauth2 auth scheme offers, or those an http action requires.
+func Scopes(scopes ...string) Option {
+	return Option{"scopes", func(o *builderOptions) { o.Scopes = scopes }}
+}
+
+// AuthSchemes names the auth schemes of an http action, any one of which it requires, rather than those of the service.
+func AuthSchemes(names ...string) Option {
+	return Option{"auth", func(o *builderOptions) { o.Auth = names }}
+}
+
+// Anonymous makes an http action require no authentication at all.
+func Anonymous() Option {
+	return Option{"auth", func(o *builderOptions) { o.Auth = []string{} }}
+}
+
+func Operation(name string) Option {
+	return Option{"operation", func(o *builderOptions) { o.Action = name }}
+}
+
+func Resource(name string) Option {
+	return Option{"resource", func(o *builderOptions) { o.Resource = name }}
+}
+
+// Service names the service of an http action.
+func Service(name string) Option {
+	return Option{"service", func(o *builderOptions) { o.Service = name }}
+}
+
+func ServiceVersion(version string) Option {
+	return Option{"version", func(o *builderOptions) { o.Version = version }}
+}
+
+// BasePath is the path of a service that precedes the paths of its http actions.
+func BasePath(base string) Option {
+	return Option{"base", func(o *builderOptions) { o.Base = base }}
+}
+
+// Identifiers are the path variables that identify an instance of a resource.
+func Identifiers(names ...string) Option {
+	return Option{"identifiers", func(o *builderOptions) { o.Identifiers = names }}
+}
+
+// Lifecycle binds an http action of a resource to one of its lifecycle operations: "create", "read", "update",
+// "delete", or "list".
+func Lifecycle(op string, action string) Option {
+	return Option{op, func(o *builderOptions) {
+		switch op {
+		case "create":
+			o.Create = action
+		case "read":
+			o.Read = action
+		case "update":
+			o.Update = action
+		case "delete":
+			o.Delete = action
+		case "list":
+			o.List = action
+		}
+	}}
+}
+
+// Paginated makes an http action return its results in pages. The inputToken and pageSize name its inputs, the
+// outputToken and items are dotted paths into its outputs, i.e. "items.next". The pageSize and items may be empty.
+func Paginated(inputToken, outputToken, pageSize, items string) Option {
+	return Option{"paginated", func(o *builderOptions) {
+		o.Paginated = &HttpPaginatedSpec{InputToken: inputToken, OutputToken: outputToken, PageSize: pageSize, Items: items}
+	}}
+}
+
+// Name names an example.
+func Name(name string) Option {
+	return Option{"name", func(o *builderOptions) { o.Name = name }}
+}
+
+// Json sets the key of a field in JSON, when it is not the name of the field.
+func Json(wireName string) Option {
+	return Option{"json", Annotation("x_wire_name", wireName).set}
+}
+
+// Annotation sets an x_* annotation.
+func Annotation(name string, value interface{}) Option {
+	return Option{name, func(o *builderOptions) {
+		if o.Annotations == nil {
+			o.Annotations = make(map[string]interface{})
+		}
+		o.Annotations[name] = builderLiteral(value)
+	}}
+}
+
+func Comment(text string) Option {
+	return Option{"comment", func(o *builderOptions) { o.comment = text }}
+}
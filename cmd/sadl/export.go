@@ -26,6 +26,9 @@ func SetupCommandLineArgs(generator string) {
 func ExportFiles(model *sadl.Model, generator, dir string, conf *sadl.Data) error {
 	switch generator {
 	case "json", "sadl-ast":
+		if !conf.GetBool("spans") {
+			model.ClearSpans()
+		}
 		fmt.Println(sadl.Pretty(model))
 		return nil
 	case "sadl":
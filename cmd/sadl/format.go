@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/boynton/sadl"
+)
+
+// formatFiles implements 'sadl fmt', returning the exit status. With -d, the status is 1 if any file is not already
+// formatted, so it can be used in a pre-commit hook.
+func formatFiles(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	pWrite := flags.Bool("w", false, "Write the result to the source file instead of stdout")
+	pDiff := flags.Bool("d", false, "Display a diff of the changes instead of the result")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: sadl fmt [-w] [-d] file ...\n\nWith no files, formats stdin to stdout.\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			var formatted string
+			formatted, err = sadl.FormatSadl(string(src))
+			fmt.Print(formatted)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "*** %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "*** %v\n", err)
+			status = 2
+			continue
+		}
+		formatted, err := sadl.FormatSadl(string(src))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "*** %s: %v\n", path, err)
+			status = 2
+			continue
+		}
+		changed := formatted != string(src)
+		if *pDiff && changed {
+			fmt.Print(unifiedDiff(path, string(src), formatted))
+			if status == 0 {
+				status = 1
+			}
+		}
+		if *pWrite && changed {
+			info, err := os.Stat(path)
+			if err == nil {
+				err = ioutil.WriteFile(path, []byte(formatted), info.Mode())
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "*** %v\n", err)
+				status = 2
+			}
+		}
+		if !*pDiff && !*pWrite {
+			fmt.Print(formatted)
+		}
+	}
+	return status
+}
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte //' ', '-', or '+'
+	text string
+}
+
+// unifiedDiff returns the difference between the two texts in unified diff format.
+func unifiedDiff(path string, before string, after string) string {
+	lines := diffLines(splitLines(before), splitLines(after))
+	//the line numbers in each text at which each diff line starts
+	aLine := make([]int, len(lines)+1)
+	bLine := make([]int, len(lines)+1)
+	for i, l := range lines {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if l.op != '+' {
+			aLine[i+1]++
+		}
+		if l.op != '-' {
+			bLine[i+1]++
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s.orig\n+++ %s\n", path, path)
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		//extend the hunk through changes separated by no more than twice the context
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		stop := end + diffContext
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+		aStart, bStart := aLine[start]+1, bLine[start]+1
+		aLen, bLen := aLine[stop]-aLine[start], bLine[stop]-bLine[start]
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, l := range lines[start:stop] {
+			sb.WriteString(string(l.op) + l.text + "\n")
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a minimal edit script from the longest common subsequence of the lines.
+func diffLines(a []string, b []string) []diffLine {
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		} else {
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
@@ -112,7 +112,18 @@ func ImportFiles(paths []string, conf *sadl.Data, extensions ...sadl.Extension)
 	if chosenType == "" {
 		return nil, fmt.Errorf("Cannot determine file type for input file(s))\n")
 	}
-	return importFiles(importPaths, chosenType, conf, extensions)
+	model, err := importFiles(importPaths, chosenType, conf, extensions)
+	if err != nil {
+		return nil, err
+	}
+	//the smithy importer selects the service itself, as it converts the model
+	if service := conf.GetString("service"); service != "" && chosenType != "smithy" {
+		err = model.SelectService(service)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return model, nil
 }
 
 func importFiles(paths []string, ftype string, conf *sadl.Data, extensions []sadl.Extension) (*sadl.Model, error) {
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/boynton/sadl"
+	"github.com/boynton/sadl/lsp"
 )
 
 type ArrayOption []string
@@ -39,7 +40,8 @@ output directory, conversions to other API description formats just output to st
 
 Supported generators and options used from config if present
    sadl: Prints the SADL representation to stdout. This is the default.
-   json: Prints the parsed SADL data representation in JSON to stdout
+   json: Prints the parsed SADL data representation in JSON to stdout. Options:
+      spans: include the source position of each definition, default is false
    smithy: Prints the Smithy IDL representation to stdout. Options:
       name: supply this value as the name for a service for inputs that do not have a name
       namespace: supply this value as the namespace for inputs that do not have a namespace
@@ -70,13 +72,35 @@ Supported generators and options used from config if present
    go-client: a shorthand for specifying the "client" option to the "go" generator. Same options.
    http-trace: Generates an HTTP (curl-style) simulation of the API's example HTTP actions, based on examples in the model
 
+The 'sadl fmt' command formats .sadl files in a canonical layout, keeping all comments. The '-w' option rewrites
+the files in place, the '-d' option shows a diff and exits with status 1 if any file is not formatted.
+
+The 'sadl refactor' command renames a type or field, inlines a type, or extracts fields into a new type, updating
+every reference, and prints the model as formatted SADL. The '-w' option rewrites the file in place.
+
+The 'sadl lsp' command runs a Language Server Protocol server over stdin/stdout, for editor support of .sadl files.
+
 `
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(formatFiles(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "refactor" {
+		os.Exit(refactorFile(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		err := lsp.NewServer(os.Stdin, os.Stdout).Serve()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "*** %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	var genOpts ArrayOption
 	pType := flag.String("t", "", "Only read files of this type. By default, any valid input file type is accepted.")
 	pOut := flag.String("o", "/tmp/generated", "The output file or directory.")
 	pName := flag.String("n", "", "The name of the model, overrides any name present in the source")
 	pNamespace := flag.String("ns", "", "The namespace of the model, overrides any namespace present in the source")
-	pService := flag.String("s", "", "The single service to consider in the model. Default is to use the only one present.")
+	pService := flag.String("s", "", "The single service to consider in the model. Default is to use all of those present.")
 	pBase := flag.String("b", "", "The base path for service operations")
 	pGen := flag.String("g", "sadl", "The generator for output")
 	pConf := flag.String("c", "", "The JSON config file for default settings. Default is $HOME/.sadl-config.yaml")
@@ -85,7 +109,7 @@ Supported generators and options used from config if present
 	pVersion := flag.Bool("v", false, "Show SADL version and exit")
 	pHelp := flag.Bool("h", false, "Show more helpful information")
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: sadl [options] file ...\n\nOptions:\n")
+		fmt.Fprintf(os.Stderr, "Usage: sadl [options] file ...\n       sadl fmt [-w] [-d] file ...\n       sadl refactor [-w] file.sadl command arg ...\n       sadl lsp\n\nOptions:\n")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/boynton/sadl"
+)
+
+// refactorFile implements 'sadl refactor', returning the exit status.
+func refactorFile(args []string) int {
+	flags := flag.NewFlagSet("refactor", flag.ExitOnError)
+	pWrite := flags.Bool("w", false, "Write the result to the source file instead of stdout")
+	pKeepWireName := flags.Bool("keep-wire-name", true, "For rename-field, keep the JSON name of the field with an x_wire_name annotation")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: sadl refactor [-w] file.sadl rename-type Type NewType
+       sadl refactor [-w] [-keep-wire-name=false] file.sadl rename-field Type field newField
+       sadl refactor [-w] file.sadl inline Type
+       sadl refactor [-w] file.sadl extract Type NewType newField field ...
+
+Every reference to what is changed is updated, and the model is written as formatted SADL.
+
+Options:
+`)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	args = flags.Args()
+	if len(args) < 3 {
+		flags.Usage()
+		return 2
+	}
+	path, cmd, params := args[0], args[1], args[2:]
+	model, err := sadl.ParseSadlFile(path, sadl.NewData())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	for _, td := range model.Types {
+		if sadl.GetAnnotation(td.Annotations, "x_include") != "" {
+			fmt.Fprintf(os.Stderr, "*** Cannot refactor %s: models with included files are not supported\n", path)
+			return 2
+		}
+	}
+	switch {
+	case cmd == "rename-type" && len(params) == 2:
+		err = model.RenameType(params[0], params[1])
+	case cmd == "rename-field" && len(params) == 3:
+		err = model.RenameField(params[0], params[1], params[2], *pKeepWireName)
+	case cmd == "inline" && len(params) == 1:
+		err = model.InlineType(params[0])
+	case cmd == "extract" && len(params) >= 4:
+		err = model.ExtractType(params[0], params[1], params[2], params[3:]...)
+	default:
+		flags.Usage()
+		return 2
+	}
+	if err == nil {
+		//the result must still be a valid model
+		_, err = sadl.ParseSadlString(sadl.DecompileSadl(model), sadl.NewData())
+	}
+	var formatted string
+	if err == nil {
+		formatted, err = sadl.FormatSadl(sadl.DecompileSadl(model))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "*** %v\n", err)
+		return 1
+	}
+	if !*pWrite {
+		fmt.Print(formatted)
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err == nil {
+		err = ioutil.WriteFile(path, []byte(formatted), info.Mode())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "*** %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -72,7 +72,7 @@ func DecimalValue(val *Decimal, defval interface{}) *Decimal {
 		switch n := defval.(type) {
 		case *Decimal:
 			return n
-		case int64:
+		case int, int64:
 			d, _ := ParseDecimal(fmt.Sprint(n))
 			return d
 		case int32:
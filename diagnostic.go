@@ -0,0 +1,81 @@
+package sadl
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem found in the source, attributed to a span of it when the location is known.
+type Diagnostic struct {
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Span      *Span  `json:"span,omitempty"`
+	formatted string
+}
+
+func (d *Diagnostic) Error() string {
+	if d.formatted != "" {
+		return d.formatted
+	}
+	if d.Span == nil {
+		return d.Message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.Span.File, d.Span.Line, d.Span.Col, d.Message)
+}
+
+// Diagnostics is the list of problems found parsing and validating a model. It is returned as the error from parsing,
+// so that all the problems can be reported rather than only the first.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	var sb strings.Builder
+	for _, d := range ds {
+		s := d.Error()
+		sb.WriteString(s)
+		if !strings.HasSuffix(s, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// HasErrors returns true if any of the diagnostics has error severity.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity != SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// AsDiagnostics returns the diagnostics carried by the error. Errors that are not diagnostics become a single
+// diagnostic without a location.
+func AsDiagnostics(err error) Diagnostics {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case Diagnostics:
+		return e
+	case *Diagnostic:
+		return Diagnostics{e}
+	}
+	return Diagnostics{&Diagnostic{Severity: SeverityError, Message: err.Error()}}
+}
+
+func (ds *Diagnostics) add(err error) {
+	*ds = append(*ds, AsDiagnostics(err)...)
+}
+
+// the diagnostics as an error, or nil if there are none
+func (ds Diagnostics) err() error {
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds
+}
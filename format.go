@@ -0,0 +1,364 @@
+package sadl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatSadl returns the SADL source in canonical layout: tab indentation by nesting, normalized spacing between
+// tokens, options in a standard order, aligned trailing comments on consecutive lines, and no more than one blank line
+// in a row. Unlike DecompileSadl, the source is not regenerated from the model, so every comment is kept where it was
+// and the directives are not reordered. Formatting already formatted source does not change it.
+func FormatSadl(src string) (string, error) {
+	f := &formatter{}
+	for i, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		err := f.formatLine(i+1, line)
+		if err != nil {
+			return "", err
+		}
+	}
+	if f.inBlock {
+		return "", fmt.Errorf("Unterminated block comment")
+	}
+	if f.depth != 0 {
+		return "", fmt.Errorf("Unbalanced brackets: %d not closed at end of file", f.depth)
+	}
+	f.alignComments()
+	var sb strings.Builder
+	blank := true //suppresses blank lines at the start
+	for _, line := range f.lines {
+		if line.blank {
+			if !blank {
+				sb.WriteString("\n")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		if line.verbatim {
+			sb.WriteString(line.code + "\n")
+			continue
+		}
+		sb.WriteString(strings.Repeat("\t", line.indent) + line.code)
+		if line.comment != "" {
+			if line.code != "" {
+				sb.WriteString(strings.Repeat(" ", line.pad+1))
+			}
+			sb.WriteString(line.comment)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n", nil
+}
+
+type formatter struct {
+	lines   []*formattedLine
+	depth   int
+	parens  int
+	inBlock bool
+}
+
+type formattedLine struct {
+	indent   int
+	code     string
+	comment  string
+	pad      int
+	blank    bool
+	verbatim bool
+}
+
+type formatToken struct {
+	kind byte //'w' for words and numbers, 's' for strings, 'p' for punctuation, 'c' for comments
+	text string
+}
+
+const formatPunct = "(){}[]<>,;:="
+
+func (f *formatter) formatLine(lineno int, raw string) error {
+	raw = strings.TrimRight(raw, " \t\r")
+	if f.inBlock {
+		//the continuation of a block comment is left as is
+		end := strings.Index(raw, "*/")
+		if end < 0 {
+			f.lines = append(f.lines, &formattedLine{code: raw, verbatim: true})
+			return nil
+		}
+		f.inBlock = false
+		parens := f.parens
+		toks := f.tokenize(raw[end+2:])
+		err := f.nest(lineno, toks)
+		if err != nil {
+			return err
+		}
+		if len(toks) > 0 {
+			raw = raw[:end+2] + " " + renderTokens(toks, parens)
+		}
+		f.lines = append(f.lines, &formattedLine{code: raw, verbatim: true})
+		return nil
+	}
+	if strings.TrimSpace(raw) == "" {
+		f.lines = append(f.lines, &formattedLine{blank: true})
+		return nil
+	}
+	parens := f.parens //for options that continue from the previous line
+	toks := reorderOptions(f.tokenize(raw))
+	line := &formattedLine{}
+	if n := len(toks); toks[n-1].kind == 'c' {
+		line.comment = toks[n-1].text
+		toks = toks[:n-1]
+	}
+	closers := 0
+	for closers < len(toks) && toks[closers].kind == 'p' && strings.Contains(")]}", toks[closers].text) {
+		closers++
+	}
+	line.indent = f.depth - closers
+	err := f.nest(lineno, toks)
+	if err != nil {
+		return err
+	}
+	line.code = renderTokens(toks, parens)
+	f.lines = append(f.lines, line)
+	return nil
+}
+
+// tracks the nesting depth through the tokens of a line
+func (f *formatter) nest(lineno int, toks []formatToken) error {
+	for _, tok := range toks {
+		if tok.kind != 'p' {
+			continue
+		}
+		switch tok.text {
+		case "(":
+			f.parens++
+		case ")":
+			f.parens--
+		}
+		switch tok.text {
+		case "(", "[", "{":
+			f.depth++
+		case ")", "]", "}":
+			f.depth--
+			if f.depth < 0 {
+				return fmt.Errorf("Unbalanced brackets: unexpected '%s' on line %d", tok.text, lineno)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *formatter) tokenize(s string) []formatToken {
+	var toks []formatToken
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j++
+			if j > len(s) {
+				j = len(s)
+			}
+			toks = append(toks, formatToken{'s', s[i:j]})
+			i = j
+		case strings.HasPrefix(s[i:], "//"):
+			toks = append(toks, formatToken{'c', s[i:]})
+			i = len(s)
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				f.inBlock = true
+				toks = append(toks, formatToken{'c', s[i:]})
+				i = len(s)
+			} else {
+				j := i + 2 + end + 2
+				toks = append(toks, formatToken{'c', s[i:j]})
+				i = j
+			}
+		case strings.IndexByte(formatPunct, c) >= 0:
+			toks = append(toks, formatToken{'p', s[i : i+1]})
+			i++
+		default:
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' && s[j] != '"' && strings.IndexByte(formatPunct, s[j]) < 0 && !strings.HasPrefix(s[j:], "//") && !strings.HasPrefix(s[j:], "/*") {
+				j++
+			}
+			toks = append(toks, formatToken{'w', s[i:j]})
+			i = j
+		}
+	}
+	return toks
+}
+
+func renderTokens(toks []formatToken, parens int) string {
+	var sb strings.Builder
+	angles := 0
+	for i, tok := range toks {
+		if i > 0 && spaceBetween(toks[i-1], tok, parens, angles) {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(tok.text)
+		if tok.kind == 'p' {
+			switch tok.text {
+			case "(":
+				parens++
+			case ")":
+				parens--
+			case "<":
+				angles++
+			case ">":
+				angles--
+			}
+		}
+	}
+	return sb.String()
+}
+
+func spaceBetween(prev, next formatToken, parens, angles int) bool {
+	if prev.kind == 'c' || next.kind == 'c' {
+		return true
+	}
+	if prev.kind == 'p' && strings.Contains("([<", prev.text) {
+		return false
+	}
+	if next.kind == 'p' && strings.Contains(",;)]>:<", next.text) {
+		return false
+	}
+	if (prev.kind == 'p' && prev.text == "=") || (next.kind == 'p' && next.text == "=") {
+		//options are written as name=value, but the constant directive as name Type = value
+		return parens == 0
+	}
+	if prev.kind == 'p' && prev.text == "," {
+		//i.e. Map<String,Int32>
+		return angles == 0
+	}
+	if prev.kind == 'p' && prev.text == "{" && next.kind == 'p' && next.text == "}" {
+		return false
+	}
+	return true
+}
+
+// puts the options in each parenthesized list in the order of OptionNames, followed by any x_* annotations sorted by
+// name. Lists that continue on another line, or contain comments, are left alone.
+func reorderOptions(toks []formatToken) []formatToken {
+	for i := 0; i < len(toks); i++ {
+		if toks[i].kind != 'p' || toks[i].text != "(" {
+			continue
+		}
+		end := -1
+		depth := 0
+		for j := i; j < len(toks) && end < 0; j++ {
+			if toks[j].kind == 'c' {
+				return toks
+			}
+			if toks[j].kind != 'p' {
+				continue
+			}
+			switch toks[j].text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+				if depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			return toks
+		}
+		var items [][]formatToken
+		var item []formatToken
+		depth = 0
+		for _, tok := range toks[i+1 : end] {
+			if tok.kind == 'p' {
+				switch tok.text {
+				case "(", "[", "{":
+					depth++
+				case ")", "]", "}":
+					depth--
+				case ",":
+					if depth == 0 {
+						items = append(items, item)
+						item = nil
+						continue
+					}
+				}
+			}
+			item = append(item, tok)
+		}
+		items = append(items, item)
+		for _, item := range items {
+			if len(item) == 0 {
+				return toks //a syntax error, leave it for the parser to report
+			}
+		}
+		sort.SliceStable(items, func(a, b int) bool {
+			ra, na := optionRank(items[a])
+			rb, nb := optionRank(items[b])
+			return ra < rb || (ra == rb && na < nb)
+		})
+		reordered := append([]formatToken{}, toks[:i+1]...)
+		for n, item := range items {
+			if n > 0 {
+				reordered = append(reordered, formatToken{'p', ","})
+			}
+			reordered = append(reordered, item...)
+		}
+		toks = append(reordered, toks[end:]...)
+		i = end
+	}
+	return toks
+}
+
+func optionRank(item []formatToken) (int, string) {
+	name := item[0].text
+	for i, opt := range OptionNames {
+		if strings.EqualFold(opt, name) {
+			return i, ""
+		}
+	}
+	if strings.HasPrefix(name, "x_") {
+		return len(OptionNames) + 1, name
+	}
+	return len(OptionNames), ""
+}
+
+// trailing comments on consecutive lines at the same indentation are aligned. Lines that open or close a block
+// are not aligned with their neighbors.
+func (f *formatter) alignComments() {
+	alignable := func(line *formattedLine) bool {
+		return !line.blank && !line.verbatim && line.code != "" && !strings.HasSuffix(line.code, "{") && !strings.HasPrefix(line.code, "}")
+	}
+	for i := 0; i < len(f.lines); {
+		j := i
+		for j < len(f.lines) && alignable(f.lines[j]) && f.lines[j].indent == f.lines[i].indent {
+			j++
+		}
+		if j == i {
+			i++
+			continue
+		}
+		width := 0
+		for _, line := range f.lines[i:j] {
+			if n := utf8.RuneCountInString(line.code); line.comment != "" && n > width {
+				width = n
+			}
+		}
+		for _, line := range f.lines[i:j] {
+			if line.comment != "" {
+				line.pad = width - utf8.RuneCountInString(line.code)
+			}
+		}
+		i = j
+	}
+}
@@ -14,6 +14,10 @@ import (
 
 func Export(model *sadl.Model, dir string, conf *sadl.Data) error {
 	gen := NewGenerator(model, dir, conf)
+	if gen.createServer {
+		//the server's param helpers use Timestamp, whether the model does or not
+		gen.createTimestamp = true
+	}
 	if gen.createModel {
 		gen.CreateModel()
 	}
@@ -39,6 +43,7 @@ type Generator struct {
 	createClient    bool
 	createTimestamp bool //set if Timestamps are encountered in the model
 	createDecimal   bool //set if Decimals are encountered in the model
+	createNullable  bool //set if optional nullable fields are encountered in the model
 	runtime         bool
 	pkgpath         string
 	imports         []string
@@ -171,6 +176,8 @@ func (gen *Generator) nativeTypeName(ts *sadl.TypeSpec, name string) string {
 	switch name {
 	case "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Bool", "String":
 		return uncapitalize(name)
+	case "Bytes":
+		return "[]byte"
 	case "Decimal":
 		if gen.runtime {
 			gen.addImport("github.com/boynton/sadl")
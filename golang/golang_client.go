@@ -2,6 +2,7 @@ package golang
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -23,36 +24,58 @@ func (gen *Generator) CreateClient() {
 
 func (gen *Generator) EmitClient() {
 	gen.imports = nil
-	gen.addImport("encoding/json")
-	gen.addImport("fmt")
 	gen.addImport("net/http")
 	//	gen.addImport("net/url")
 	gen.addImport("strings")
 	//	gen.addImport("time")
 	funcMap := template.FuncMap{
-		"openBrace":   func() string { return "{" },
-		"clientName":  func() string { return sadl.Capitalize(gen.Name) + "Client" },
-		"methodName":  func(hd *sadl.HttpDef) string { return sadl.Capitalize(hd.Name) },
-		"reqTypeName": func(hd *sadl.HttpDef) string { return gen.RequestTypeName(hd) },
-		"resTypeName": func(hd *sadl.HttpDef) string { return gen.ResponseTypeName(hd) },
-		"inputs":      func(hd *sadl.HttpDef) string { return "// fix me: 'inputs'" },
-		"outputs":     func(hd *sadl.HttpDef) string { return "// fix me: 'outputs'" },
-		"exceptions":  func(hd *sadl.HttpDef) string { return "// fix me: 'exceptions'" },
+		"openBrace":         func() string { return "{" },
+		"clientName":        func() string { return sadl.Capitalize(gen.Name) + "Client" },
+		"resourceInterface": gen.resourceInterfaceName,
+		"resourceOps":       gen.resourceOps,
+		"uncapitalize":      sadl.Uncapitalize,
+		"methodName":        func(hd *sadl.HttpDef) string { return sadl.Capitalize(hd.Name) },
+		"reqTypeName":       func(hd *sadl.HttpDef) string { return gen.RequestTypeName(hd) },
+		"resTypeName":       func(hd *sadl.HttpDef) string { return gen.ResponseTypeName(hd) },
+		"inputs":            func(hd *sadl.HttpDef) string { return "// fix me: 'inputs'" },
+		"outputs":           func(hd *sadl.HttpDef) string { return "// fix me: 'outputs'" },
+		"exceptions":        func(hd *sadl.HttpDef) string { return "// fix me: 'exceptions'" },
 		"requestEntityContentType": func(hd *sadl.HttpDef) string {
 			switch hd.Method {
 			case "PUT", "POST", "PATCH":
+				if in := gen.bodyInput(hd); in != nil && in.MediaType == "multipart/form-data" {
+					return "\threq.Header.Add(\"Content-Type\", mw.FormDataContentType())"
+				} else if in != nil {
+					return "\threq.Header.Add(\"Content-Type\", \"" + in.BodyMediaType() + "\")"
+				}
 				return "\threq.Header.Add(\"Content-Type\", \"application/json\")"
 			}
 			return ""
 		},
+		"requestBody": func(hd *sadl.HttpDef) string {
+			switch hd.Method {
+			case "PUT", "POST", "PATCH":
+				if in := gen.bodyInput(hd); in != nil && sadl.IsFormMediaType(in.MediaType) {
+					return gen.formBody(in, "req."+sadl.Capitalize(in.Name))
+				}
+			}
+			return ""
+		},
 		"reqBodyReader": func(hd *sadl.HttpDef) string {
 			switch hd.Method {
 			case "PUT", "POST", "PATCH":
-				for _, in := range hd.Inputs {
-					if !in.Path && in.Query == "" && in.Header == "" {
-						gen.addImport("bytes")
-						return "bytes.NewReader([]byte(Json(req." + sadl.Capitalize(in.Name) + ")))"
+				if in := gen.bodyInput(hd); in != nil {
+					switch {
+					case in.MediaType == "application/x-www-form-urlencoded":
+						return "strings.NewReader(form.Encode())"
+					case in.MediaType == "multipart/form-data":
+						return "body"
+					}
+					gen.addImport("bytes")
+					if !sadl.IsJsonMediaType(in.MediaType) {
+						return "bytes.NewReader([]byte(req." + sadl.Capitalize(in.Name) + "))"
 					}
+					return "bytes.NewReader([]byte(Json(req." + sadl.Capitalize(in.Name) + ")))"
 				}
 			}
 			return "nil"
@@ -60,25 +83,91 @@ func (gen *Generator) EmitClient() {
 		"queryParams": func(hd *sadl.HttpDef) string {
 			s := ""
 			for _, in := range hd.Inputs {
-				if in.Query != "" {
-					s = s + "\tif req." + sadl.Capitalize(in.Name) + " != " + gen.notSet(in.Type) + " {\n"
-					s = s + "\t\targs = append(args, fmt.Sprintf(\"" + in.Name + "=" + gen.typeFormat(in.Type) + "\", req." + sadl.Capitalize(in.Name) + "))\n"
+				if in.Query != "" && gen.baseType(in.Type) == "Array" {
+					gen.addImport("net/url")
+					field := "req." + sadl.Capitalize(in.Name)
+					item := gen.paramValue(gen.arrayItems(in), "item")
+					if in.Csv {
+						s = s + "\tif len(" + field + ") > 0 {\n"
+						s = s + "\t\tvar items []string\n"
+						s = s + "\t\tfor _, item := range " + field + " {\n"
+						s = s + "\t\t\titems = append(items, url.QueryEscape(fmt.Sprint(" + item + ")))\n"
+						s = s + "\t\t}\n"
+						s = s + "\t\targs = append(args, \"" + in.Query + "=\"+strings.Join(items, \",\"))\n"
+						s = s + "\t}\n"
+					} else {
+						s = s + "\tfor _, item := range " + field + " {\n"
+						s = s + "\t\targs = append(args, \"" + in.Query + "=\"+url.QueryEscape(fmt.Sprint(" + item + ")))\n"
+						s = s + "\t}\n"
+					}
+				} else if in.Query != "" {
+					field := "req." + sadl.Capitalize(in.Name)
+					s = s + "\tif " + gen.isSet(in.Type, field) + " {\n"
+					gen.addImport("net/url")
+					s = s + "\t\targs = append(args, \"" + in.Query + "=\"+url.QueryEscape(fmt.Sprintf(\"" + gen.typeFormat(in.Type) + "\", " + gen.paramValue(in.Type, field) + ")))\n"
 					s = s + "\t}\n"
 				}
 			}
+			if s != "" {
+				gen.addImport("fmt")
+			}
+			return s
+		},
+		"headerParams": func(hd *sadl.HttpDef) string {
+			s := ""
+			for _, in := range hd.Inputs {
+				field := "req." + sadl.Capitalize(in.Name)
+				if in.Cookie != "" {
+					s = s + "\tif " + gen.isSet(in.Type, field) + " {\n"
+					s = s + "\t\threq.AddCookie(&http.Cookie{Name: \"" + in.Cookie + "\", Value: fmt.Sprint(" + gen.paramValue(in.Type, field) + ")})\n"
+					s = s + "\t}\n"
+				}
+				if in.Header == "" {
+					continue
+				}
+				if gen.baseType(in.Type) == "Array" {
+					s = s + "\tfor _, item := range " + field + " {\n"
+					s = s + "\t\threq.Header.Add(\"" + in.Header + "\", fmt.Sprint(" + gen.paramValue(gen.arrayItems(in), "item") + "))\n"
+					s = s + "\t}\n"
+				} else {
+					s = s + "\tif " + gen.isSet(in.Type, field) + " {\n"
+					s = s + "\t\threq.Header.Set(\"" + in.Header + "\", fmt.Sprint(" + gen.paramValue(in.Type, field) + "))\n"
+					s = s + "\t}\n"
+				}
+			}
+			if s != "" {
+				gen.addImport("fmt")
+			}
 			return s
 		},
 		"methodSignature": func(hd *sadl.HttpDef) string {
+			gen.addImport("context")
 			name := sadl.Capitalize(hd.Name)
 			return name + "(req *" + name + "Request) (*" + name + "Response, error)"
 		},
 		"methodPath": func(hd *sadl.HttpDef) string {
-			path := hd.Path
+			path := gen.Model.HttpBase(hd) + hd.Path
 			i := strings.Index(path, "?")
 			if i >= 0 {
 				path = path[0:i]
 			}
-			return path
+			//the path variables are escaped, but for the slashes of a greedy one, which separate its segments
+			expr := strconv.Quote(path)
+			for _, in := range hd.Inputs {
+				if !in.Path {
+					continue
+				}
+				gen.addImport("fmt")
+				gen.addImport("net/url")
+				value := "url.PathEscape(fmt.Sprint(" + gen.paramValue(in.Type, "req."+sadl.Capitalize(in.Name)) + "))"
+				label := "{" + in.Name + "}"
+				if in.Greedy {
+					value = "strings.ReplaceAll(" + value + ", \"%2F\", \"/\")"
+					label = "{" + in.Name + "+}"
+				}
+				expr = strings.Replace(expr, label, "\" + "+value+" + \"", 1)
+			}
+			return strings.TrimSuffix(expr, " + \"\"")
 		},
 		"expectedResults": func(hd *sadl.HttpDef) string {
 			s := fmt.Sprintf("\tcase %d:\n", hd.Expected.Status)
@@ -91,7 +180,15 @@ func (gen *Generator) EmitClient() {
 			for _, out := range hd.Expected.Outputs {
 				natType := gen.nativeType(out.Type)
 				name := sadl.Capitalize(out.Name)
-				if out.Header == "" {
+				if out.Header == "" && out.Cookie == "" && !sadl.IsJsonMediaType(out.MediaType) {
+					gen.addImport("io")
+					s = s + "\t\tdata, err := io.ReadAll(res.Body)\n"
+					s = s + "\t\tif err != nil {\n"
+					s = s + "\t\t\treturn nil, err\n"
+					s = s + "\t\t}\n"
+					s = s + "\t\tresponse." + name + " = " + natType + "(data)\n"
+				} else if out.Header == "" && out.Cookie == "" {
+					gen.addImport("encoding/json")
 					s = s + "\t\tvar entity " + natType + "\n"
 					s = s + "\t\terr = json.NewDecoder(res.Body).Decode(&entity)\n"
 					s = s + "\t\tif err != nil {\n"
@@ -99,23 +196,72 @@ func (gen *Generator) EmitClient() {
 					s = s + "\t\t}\n"
 					s = s + "\t\tresponse." + name + " = entity\n"
 				} else {
-					i := "res.Header.Get(" + fmt.Sprintf("%q", out.Header) + ")" //fold header name consistently?
-					switch natType {
-					case "*Timestamp":
-						i = "TimestampFromString(" + i + ")"
-					default:
-					}
-					s = s + "\t\tresponse." + name + " = " + i + "\n"
+					s = s + gen.headerResult(out, "response")
 				}
 			}
 			s = s + "\t\treturn response, nil\n"
 			return s
 		},
+		"nextPage": func(hd *sadl.HttpDef) string {
+			gen.addImport("context")
+			path := gen.Model.HttpOutputPath(hd, hd.Paginated.OutputToken)
+			var conds []string
+			token := "res"
+			for i, fd := range path {
+				token = token + "." + sadl.Capitalize(fd.Name)
+				if fd.Nullable && !fd.Required {
+					//an optional nullable field is a Nullable, whose Value is nil when the field is absent or null
+					conds = append(conds, token+".Value == nil")
+					token = token + ".Value"
+				} else if i < len(path)-1 || fd.Nullable {
+					conds = append(conds, token+" == nil")
+				}
+			}
+			if path[len(path)-1].Nullable {
+				token = "*" + token
+			}
+			conds = append(conds, token+" == \"\"")
+			in := hd.Input(hd.Paginated.InputToken)
+			if tn := gen.nativeType(in.Type); tn != gen.nativeType(path[len(path)-1].Type) {
+				token = tn + "(" + token + ")"
+			}
+			s := "\t\tif " + strings.Join(conds, " || ") + " {\n"
+			s = s + "\t\t\treturn nil\n"
+			s = s + "\t\t}\n"
+			s = s + "\t\tpageReq." + sadl.Capitalize(in.Name) + " = " + token + "\n"
+			return s
+		},
+		"defaultException": func(hd *sadl.HttpDef) bool {
+			for _, es := range gen.Model.HttpExceptions(hd) {
+				if es.Status == 0 {
+					return true
+				}
+			}
+			gen.addImport("fmt")
+			return false
+		},
 		"exceptionResults": func(hd *sadl.HttpDef) string {
 			s := ""
-			for _, es := range hd.Exceptions {
+			for _, es := range gen.Model.HttpExceptions(hd) {
+				if len(es.Outputs) > 0 {
+					s = s + exceptionCase(es)
+					gen.addImport("encoding/json")
+					s = s + "\t\terrEntity := &" + gen.ExceptionTypeName(hd, es) + "{}\n"
+					s = s + "\t\terr = json.NewDecoder(res.Body).Decode(&errEntity." + es.Type + ")\n"
+					s = s + "\t\tif err != nil {\n"
+					s = s + "\t\t\treturn nil, err\n"
+					s = s + "\t\t}\n"
+					for _, out := range es.Outputs {
+						if out.Header != "" || out.Cookie != "" {
+							s = s + gen.headerResult(out, "errEntity")
+						}
+					}
+					s = s + "\t\treturn nil, errEntity\n"
+					continue
+				}
 				natType := gen.nativeType(es.Type)
-				s = s + fmt.Sprintf("\tcase %d:\n", es.Status)
+				s = s + exceptionCase(es)
+				gen.addImport("encoding/json")
 				s = s + "\t\tvar errEntity " + natType + "\n"
 				s = s + "\t\terr = json.NewDecoder(res.Body).Decode(&errEntity)\n"
 				s = s + "\t\tif err != nil {\n"
@@ -129,14 +275,99 @@ func (gen *Generator) EmitClient() {
 	gen.EmitTemplate("client", clientTemplate, gen, funcMap)
 }
 
+// exceptionCase returns the switch case for the status of an exception, the default case if it has none.
+func exceptionCase(es *sadl.HttpExceptionSpec) string {
+	if es.Status == 0 {
+		return "\tdefault:\n"
+	}
+	return fmt.Sprintf("\tcase %d:\n", es.Status)
+}
+
+// bodyInput returns the input that is the body of a request, or nil if there is none.
+func (gen *Generator) bodyInput(hd *sadl.HttpDef) *sadl.HttpParamSpec {
+	for _, in := range hd.Inputs {
+		if !in.Path && in.Query == "" && in.Header == "" && in.Cookie == "" {
+			return in
+		}
+	}
+	return nil
+}
+
+// formBody returns the statements that encode a Struct input as a form, either as url.Values or by a multipart.Writer.
+func (gen *Generator) formBody(in *sadl.HttpParamSpec, v string) string {
+	gen.addImport("fmt")
+	multi := in.MediaType == "multipart/form-data"
+	s := ""
+	if multi {
+		gen.addImport("bytes")
+		gen.addImport("mime/multipart")
+		s = "\tbody := new(bytes.Buffer)\n\tmw := multipart.NewWriter(body)\n"
+	} else {
+		gen.addImport("net/url")
+		s = "\tform := url.Values{}\n"
+	}
+	s = s + "\tif " + v + " != nil {\n"
+	for _, fd := range gen.Model.FormFields(in) {
+		field := v + "." + sadl.Capitalize(fd.Name)
+		key := fmt.Sprintf("%q", fd.WireName())
+		switch gen.baseType(fd.Type) {
+		case "Bytes":
+			s = s + "\t\tif " + field + " != nil {\n"
+			s = s + "\t\t\tfw, err := mw.CreateFormFile(" + key + ", " + key + ")\n"
+			s = s + "\t\t\tif err != nil {\n\t\t\t\treturn nil, err\n\t\t\t}\n"
+			s = s + "\t\t\tfw.Write(" + field + ")\n"
+			s = s + "\t\t}\n"
+		case "Array":
+			item := gen.paramValue(gen.arrayItems(&sadl.HttpParamSpec{StructFieldDef: *fd}), "item")
+			s = s + "\t\tfor _, item := range " + field + " {\n"
+			if multi {
+				s = s + "\t\t\tmw.WriteField(" + key + ", fmt.Sprint(" + item + "))\n"
+			} else {
+				s = s + "\t\t\tform.Add(" + key + ", fmt.Sprint(" + item + "))\n"
+			}
+			s = s + "\t\t}\n"
+		default:
+			s = s + "\t\tif " + gen.isSet(fd.Type, field) + " {\n"
+			if multi {
+				s = s + "\t\t\tmw.WriteField(" + key + ", fmt.Sprint(" + gen.paramValue(fd.Type, field) + "))\n"
+			} else {
+				s = s + "\t\t\tform.Set(" + key + ", fmt.Sprint(" + gen.paramValue(fd.Type, field) + "))\n"
+			}
+			s = s + "\t\t}\n"
+		}
+	}
+	s = s + "\t}\n"
+	if multi {
+		s = s + "\tif err := mw.Close(); err != nil {\n\t\treturn nil, err\n\t}\n"
+	}
+	return s
+}
+
+// headerResult returns the statement that sets a field of v from a response header or cookie.
+func (gen *Generator) headerResult(out *sadl.HttpParamSpec, v string) string {
+	name := v + "." + sadl.Capitalize(out.Name)
+	i := "res.Header.Get(" + fmt.Sprintf("%q", out.Header) + ")" //fold header name consistently?
+	if out.Cookie != "" {
+		i = "cookieValue(res.Cookies(), " + fmt.Sprintf("%q", out.Cookie) + ")"
+	}
+	switch gen.baseType(out.Type) {
+	case "Timestamp":
+		i = "TimestampFromString(" + i + ")"
+	case "Bool", "Int8", "Int16", "Int32", "Int64", "Float32", "Float64":
+		gen.addImport("fmt")
+		return "\t\tfmt.Sscan(" + i + ", &" + name + ")\n"
+	}
+	return "\t\t" + name + " = " + i + "\n"
+}
+
 func (gen *Generator) notSet(sadlType string) string {
 	bt := gen.baseType(sadlType)
 	switch bt {
-	case "String":
+	case "String", "UUID":
 		return `""`
-	case "Int8", "Int16", "Int32", "Int64":
+	case "Int8", "Int16", "Int32", "Int64", "Enum":
 		return "0"
-	case "Float32", "Float64", "Decimal":
+	case "Float32", "Float64":
 		return "0.0"
 	case "Bool":
 		return "false"
@@ -145,6 +376,35 @@ func (gen *Generator) notSet(sadlType string) string {
 	}
 }
 
+// isSet returns the condition that the param v of the type has a value to send.
+func (gen *Generator) isSet(sadlType string, v string) string {
+	if gen.namedDecimal(sadlType) {
+		return gen.paramValue(sadlType, v) + ".Sign() != 0"
+	}
+	return v + " != " + gen.notSet(sadlType)
+}
+
+// paramValue returns v as a value that formats as its type would in a param. A named Decimal type is not a pointer,
+// so does not have the methods of one.
+func (gen *Generator) paramValue(sadlType string, v string) string {
+	if gen.namedDecimal(sadlType) {
+		return "(*Decimal)(&" + v + ")"
+	}
+	return v
+}
+
+func (gen *Generator) namedDecimal(sadlType string) bool {
+	return gen.baseType(sadlType) == "Decimal" && !strings.HasPrefix(gen.nativeType(sadlType), "*")
+}
+
+// arrayItems returns the item type of an Array param, given inline or by its named type.
+func (gen *Generator) arrayItems(in *sadl.HttpParamSpec) string {
+	if td := gen.Model.FindType(in.Type); in.Items == "" && td != nil {
+		return td.Items
+	}
+	return in.Items
+}
+
 func (gen *Generator) typeFormat(sadlType string) string {
 	bt := gen.baseType(sadlType)
 	switch bt {
@@ -153,7 +413,7 @@ func (gen *Generator) typeFormat(sadlType string) string {
 	case "Int8", "Int16", "Int32", "Int64":
 		return "%d"
 	case "Float32", "Float64", "Decimal":
-		return "&g"
+		return "%g"
 	default:
 		return "%v"
 	}
@@ -164,24 +424,29 @@ type {{clientName}} struct {
 	Target string
 }
 
-func NewClient(target string) (*CrudlClient, error) {
-	return &CrudlClient{
+func NewClient(target string) (*{{clientName}}, error) {
+	return &{{clientName}}{
 		Target: target,
 	}, nil
 }
 {{range .Model.Http}}
 func (client *{{clientName}}) {{methodSignature .}} {
-	url := client.Target + "{{methodPath .}}"
+	return client.{{methodName .}}Context(context.Background(), req)
+}
+
+// {{methodName .}}Context is {{methodName .}}, with a context that cancels the call when it is done.
+func (client *{{clientName}}) {{methodName .}}Context(ctx context.Context, req *{{reqTypeName .}}) (*{{resTypeName .}}, error) {
+{{requestBody .}}	target := client.Target + {{methodPath .}}
 	var args []string
 {{queryParams .}}	if len(args) > 0 {
-		url = url + "?" + strings.Join(args, "&")
+		target = target + "?" + strings.Join(args, "&")
 	}
-	hreq, err := http.NewRequest("{{.Method}}", url, {{reqBodyReader .}})
+	hreq, err := http.NewRequestWithContext(ctx, "{{.Method}}", target, {{reqBodyReader .}})
 	if err != nil {
 		return nil, err
 	}
 {{requestEntityContentType .}}
-	res, err := http.DefaultClient.Do(hreq)
+{{headerParams .}}	res, err := http.DefaultClient.Do(hreq)
 	if err != nil {
 		return nil, err
 	}
@@ -190,8 +455,47 @@ func (client *{{clientName}}) {{methodSignature .}} {
 {{expectedResults .}}
 {{exceptionResults .}}
 	}
-   return nil,fmt.Errorf("whoops")
+{{if not (defaultException .)}}   return nil,fmt.Errorf("whoops")
+{{end}}}
+{{if .Paginated}}
+// {{methodName .}}Pages calls {{methodName .}} for each page of its results, until fn returns false, there are no more
+// pages, or the context is done.
+func (client *{{clientName}}) {{methodName .}}Pages(ctx context.Context, req *{{reqTypeName .}}, fn func(*{{resTypeName .}}) bool) error {
+	pageReq := *req
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res, err := client.{{methodName .}}Context(ctx, &pageReq)
+		if err != nil {
+			return err
+		}
+		if !fn(res) {
+			return nil
+		}
+{{nextPage .}}	}
+}
+{{end}}{{end}}{{range .Model.Resources}}
+// {{resourceInterface .}} returns the lifecycle of the {{.Name}} resource, performed by the client.
+func (client *{{clientName}}) {{resourceInterface .}}() {{resourceInterface .}} {
+	return {{uncapitalize (resourceInterface .)}}Client{client}
+}
+
+type {{uncapitalize (resourceInterface .)}}Client struct {
+	client *{{clientName}}
+}
+{{$rd := .}}{{range resourceOps .}}
+func (rc {{uncapitalize (resourceInterface $rd)}}Client) {{.Method}}(req *{{reqTypeName .Http}}) (*{{resTypeName .Http}}, error) {
+	return rc.client.{{methodName .Http}}(req)
+}
+{{end}}{{end}}
+func cookieValue(cookies []*http.Cookie, name string) string {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return ""
 }
-{{end}}
 
 `
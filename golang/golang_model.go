@@ -2,6 +2,7 @@ package golang
 
 import (
 	"fmt"
+	"strings"
 	"text/template"
 
 	"github.com/boynton/sadl"
@@ -14,40 +15,124 @@ func (gen *Generator) CreateModel() {
 	gen.Begin()
 	gen.EmitInterface()
 	gen.EmitTypeDefs()
+	gen.EmitConstants()
 	if gen.createTimestamp {
 		gen.EmitTimestamp()
 	}
 	if gen.createDecimal {
 		gen.EmitDecimal()
 	}
+	if gen.createNullable {
+		gen.EmitNullable()
+	}
 	content := gen.End()
 	fname := sadl.Uncapitalize(gen.Name) + "_model.go"
 	gen.WriteGoFile(fname, content, gen.Pkg)
 }
 
 func (gen *Generator) EmitInterface() {
+	signature := func(hd *sadl.HttpDef) string {
+		name := gen.Capitalize(hd.Name)
+		reqType := gen.RequestTypeName(hd)
+		resType := gen.ResponseTypeName(hd)
+		return name + "(req *" + reqType + ") (*" + resType + ", error)"
+	}
 	funcMap := template.FuncMap{
-		"openBrace":   func() string { return "{" },
-		"serviceName": func() string { return gen.Name },
-		"signature": func(hd *sadl.HttpDef) string {
-			name := gen.Capitalize(hd.Name)
-			reqType := gen.RequestTypeName(hd)
-			resType := gen.ResponseTypeName(hd)
-			return name + "(req *" + reqType + ") (*" + resType + ", error)"
+		"openBrace":        func() string { return "{" },
+		"serviceName":      func() string { return gen.Name },
+		"signature":        signature,
+		"serviceInterface": gen.serviceInterfaceName,
+		"serviceHttp":      gen.Model.ServiceHttp,
+		"members": func() []string {
+			//the interface of the model embeds those of its services, if it declares any
+			var members []string
+			for _, sd := range gen.Model.Services {
+				if iname := gen.serviceInterfaceName(sd); iname != "" {
+					members = append(members, iname)
+				} else {
+					for _, hd := range gen.Model.ServiceHttp(sd) {
+						members = append(members, signature(hd))
+					}
+				}
+			}
+			if len(gen.Model.Services) == 0 {
+				for _, hd := range gen.Model.Http {
+					members = append(members, signature(hd))
+				}
+			}
+			return members
 		},
-		"capitalize": func(s string) string { return gen.Capitalize(s) },
+		"resourceInterface": gen.resourceInterfaceName,
+		"resourceOps":       gen.resourceOps,
+		"reqTypeName":       gen.RequestTypeName,
+		"resTypeName":       gen.ResponseTypeName,
+		"capitalize":        func(s string) string { return gen.Capitalize(s) },
 	}
 	gen.EmitTemplate("interface", interfaceTemplate, gen, funcMap)
 }
 
-var interfaceTemplate = `
+// resourceOp is an http action bound to a lifecycle operation of a resource, which is a method of its interface.
+type resourceOp struct {
+	Method string
+	Http   *sadl.HttpDef
+}
+
+func (gen *Generator) resourceOps(rd *sadl.ResourceDef) []*resourceOp {
+	var ops []*resourceOp
+	for _, op := range sadl.ResourceLifecycle {
+		if hd := gen.Model.FindHttp(rd.LifecycleAction(op)); hd != nil {
+			ops = append(ops, &resourceOp{Method: gen.Capitalize(op), Http: hd})
+		}
+	}
+	return ops
+}
+
+func (gen *Generator) resourceInterfaceName(rd *sadl.ResourceDef) string {
+	return gen.Capitalize(rd.Name) + "Resource"
+}
+
+// serviceInterfaceName returns the name of the interface of a service, or "" if it is that of the model.
+func (gen *Generator) serviceInterfaceName(sd *sadl.ServiceDef) string {
+	if name := gen.Capitalize(sd.Name); name != gen.Name {
+		return name
+	}
+	return ""
+}
+
+var interfaceTemplate = `{{range .Model.Services}}{{if serviceInterface .}}
 //
-// {{serviceName}} is the interface that the service implementation must conform to
+// {{serviceInterface .}} is the interface of the {{.Name}} service
 //
-type {{serviceName}} interface {{openBrace}}{{range .Model.Http}}
+type {{serviceInterface .}} interface {{openBrace}}{{range serviceHttp .}}
     {{signature .}}{{end}}
 }
+{{end}}{{end}}
+//
+// {{serviceName}} is the interface that the service implementation must conform to
+//
+type {{serviceName}} interface {{openBrace}}{{range members}}
+    {{.}}{{end}}
+}
+{{range .Model.Resources}}
+//
+// {{resourceInterface .}} is the lifecycle of the {{.Name}} resource
+//
+type {{resourceInterface .}} interface {{openBrace}}{{range resourceOps .}}
+    {{.Method}}(req *{{reqTypeName .Http}}) (*{{resTypeName .Http}}, error){{end}}
+}
 
+//
+// {{resourceInterface .}}Actions implements the http actions of the {{.Name}} resource with its lifecycle. An
+// implementation of the service may embed it.
+//
+type {{resourceInterface .}}Actions struct {
+    Resource {{resourceInterface .}}
+}
+{{$rd := .}}{{range resourceOps .}}
+func (actions {{resourceInterface $rd}}Actions) {{signature .Http}} {
+    return actions.Resource.{{.Method}}(req)
+}
+{{end}}{{end}}
 `
 
 func (gen *Generator) RequestTypeName(hd *sadl.HttpDef) string {
@@ -58,6 +143,11 @@ func (gen *Generator) ResponseTypeName(hd *sadl.HttpDef) string {
 	return gen.Capitalize(hd.Name) + "Response"
 }
 
+// ExceptionTypeName returns the name of the error type for an exception with headers or cookies, which wraps its body.
+func (gen *Generator) ExceptionTypeName(hd *sadl.HttpDef, exc *sadl.HttpExceptionSpec) string {
+	return gen.Capitalize(hd.Name) + "Except" + exc.Type
+}
+
 func (gen *Generator) EmitRequestType(hd *sadl.HttpDef) {
 	name := gen.RequestTypeName(hd)
 	td := &sadl.TypeDef{
@@ -86,13 +176,31 @@ func (gen *Generator) EmitResponseType(hd *sadl.HttpDef) {
 	gen.EmitStructType(td, nil)
 }
 
+func (gen *Generator) EmitExceptionType(hd *sadl.HttpDef, exc *sadl.HttpExceptionSpec) {
+	name := gen.ExceptionTypeName(hd, exc)
+	gen.Emit("type " + name + " struct {\n")
+	for _, out := range exc.Outputs {
+		ftype := gen.nativeTypeName(&out.TypeSpec, out.Type)
+		if out.Header == "" && out.Cookie == "" {
+			gen.Emit("    " + ftype + "\n")
+		} else {
+			gen.Emit("    " + capitalize(out.Name) + " " + ftype + "\n")
+		}
+	}
+	gen.Emit("}\n\n")
+	gen.Emit("func (e *" + name + ") Error() string {\n\treturn \"" + exc.Type + "\"\n}\n\n")
+}
+
 func (gen *Generator) EmitTypeDefs() {
 	errors := make(map[string]bool, 0)
 	for _, hd := range gen.Model.Http {
 		gen.EmitRequestType(hd)
 		gen.EmitResponseType(hd)
-		for _, ed := range hd.Exceptions {
+		for _, ed := range gen.Model.HttpExceptions(hd) {
 			errors[ed.Type] = true
+			if len(ed.Outputs) > 0 {
+				gen.EmitExceptionType(hd, ed)
+			}
 		}
 	}
 	for _, td := range gen.Model.Types {
@@ -115,6 +223,8 @@ func (gen *Generator) EmitType(td *sadl.TypeDef, errors map[string]bool) {
 		gen.Emit("type " + td.Name + " string\n")
 	case "UUID":
 		gen.Emit("type " + td.Name + " string //UUID\n")
+	case "Bytes":
+		gen.Emit("type " + td.Name + " []byte\n")
 	case "Decimal":
 		gen.Emit("type " + td.Name + " Decimal\n")
 		gen.createDecimal = true
@@ -138,6 +248,40 @@ func (gen *Generator) EmitType(td *sadl.TypeDef, errors map[string]bool) {
 
 }
 
+func (gen *Generator) EmitConstants() {
+	if len(gen.Model.Constants) == 0 {
+		return
+	}
+	gen.Emit("\n//\n// Constants\n//\n")
+	for _, cd := range gen.Model.Constants {
+		if cd.Comment != "" {
+			gen.Emit("\n// " + cd.Comment + "\n")
+		}
+		ctype := gen.nativeTypeName(nil, cd.Type)
+		switch gen.Model.BaseType(cd.Type) {
+		case "Decimal":
+			if gen.runtime {
+				gen.Emit("var " + cd.Name + ", _ = sadl.ParseDecimal(\"" + sadl.ToString(cd.Value) + "\")\n")
+			} else {
+				gen.Emit("var " + cd.Name + " = DecimalFromString(\"" + sadl.ToString(cd.Value) + "\")\n")
+			}
+		case "Timestamp":
+			if gen.runtime {
+				gen.Emit("var " + cd.Name + ", _ = sadl.ParseTimestamp(" + sadl.ToString(cd.Value) + ")\n")
+			} else {
+				gen.createTimestamp = true
+				gen.Emit("var " + cd.Name + " = TimestampFromString(" + sadl.ToString(cd.Value) + ")\n")
+			}
+		case "Enum":
+			if s := sadl.AsString(cd.Value); s != "" {
+				gen.Emit("const " + cd.Name + " " + ctype + " = " + s + "\n")
+			}
+		default:
+			gen.Emit("const " + cd.Name + " " + ctype + " = " + sadl.ToString(cd.Value) + "\n")
+		}
+	}
+}
+
 func (gen *Generator) EmitArrayType(td *sadl.TypeDef) {
 	itemType := gen.nativeTypeName(&td.TypeSpec, td.Items)
 	gen.Emit("type " + td.Name + " []" + itemType + "\n")
@@ -154,8 +298,14 @@ func (gen *Generator) EmitStructType(td *sadl.TypeDef, errors map[string]bool) {
 	for _, fd := range td.Fields {
 		fname := capitalize(fd.Name)
 		ftype := gen.nativeTypeName(&fd.TypeSpec, fd.Type)
-		anno := " `json:\"" + fd.Name
-		if !fd.Required {
+		anno := " `json:\"" + fd.WireName()
+		if fd.Nullable && !fd.Required {
+			gen.createNullable = true
+			ftype = "Nullable[" + strings.TrimPrefix(ftype, "*") + "]"
+			anno = anno + ",omitzero"
+		} else if fd.Nullable {
+			ftype = nullableTypeName(ftype)
+		} else if !fd.Required {
 			anno = anno + ",omitempty"
 		}
 		anno = anno + "\"`"
@@ -169,6 +319,15 @@ func (gen *Generator) EmitStructType(td *sadl.TypeDef, errors map[string]bool) {
 	}
 }
 
+// a required nullable field is represented as a pointer, so that nil is marshaled as an explicit null. An optional one
+// is a Nullable, which also tells an absent value from a null one.
+func nullableTypeName(ftype string) string {
+	if strings.HasPrefix(ftype, "*") || strings.HasPrefix(ftype, "[]") || strings.HasPrefix(ftype, "map[") {
+		return ftype
+	}
+	return "*" + ftype
+}
+
 func (gen *Generator) EmitUnionType(td *sadl.TypeDef, errors map[string]bool) {
 	tagType := td.Name + "VariantTag"
 	gen.Emit("type " + tagType + " int\n")
@@ -183,7 +342,7 @@ func (gen *Generator) EmitUnionType(td *sadl.TypeDef, errors map[string]bool) {
 	for _, fd := range td.Variants {
 		fname := capitalize(fd.Name)
 		ftype := gen.nativeTypeName(&fd.TypeSpec, fd.Type)
-		anno := " `json:\"" + fd.Name + ",omitempty\"`"
+		anno := " `json:\"" + fd.WireName() + ",omitempty\"`"
 		gen.Emit("    " + fname + " " + ftype + anno + "\n")
 	}
 	gen.Emit("}\n\n")
@@ -201,11 +360,7 @@ func (gen *Generator) EmitEnumType(td *sadl.TypeDef) {
 	funcMap := template.FuncMap{
 		"openBrace": func() string { return "{" },
 		"enumValue": func(el sadl.EnumElementDef) string {
-			val := sadl.GetAnnotation(el.Annotations, "x_enumValue")
-			if val == "" {
-				return el.Symbol
-			}
-			return val
+			return el.WireName()
 		},
 	}
 	gen.EmitTemplate("enumType", enumTemplate, td, funcMap)
@@ -338,6 +493,54 @@ func (d *Decimal) AsBigFloat() *big.Float {
 
 `
 
+func (gen *Generator) EmitNullable() {
+	if gen.Err != nil {
+		return
+	}
+	gen.addImport("encoding/json")
+	gen.Emit(nullable)
+}
+
+var nullable = `
+
+// Nullable is an optional field that may be null. Set is false if the field is absent, and Value is nil if it is null.
+// A field that is not Set is omitted when marshaled, which needs the omitzero option of Go 1.24 or later.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// NullableOf returns a Nullable that is set to the value.
+func NullableOf[T any](v T) Nullable[T] {
+	return Nullable[T]{Set: true, Value: &v}
+}
+
+func (n Nullable[T]) IsZero() bool {
+	return !n.Set && n.Value == nil
+}
+
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if n.Value == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	n.Value = nil
+	if string(b) == "null" {
+		return nil
+	}
+	var v T
+	err := json.Unmarshal(b, &v)
+	if err == nil {
+		n.Value = &v
+	}
+	return err
+}
+`
+
 func (gen *Generator) EmitTimestamp() {
 	if gen.Err != nil {
 		return
@@ -45,11 +45,16 @@ func (gen *Generator) EmitServerAdaptor() {
 		"resTypeName": func(hd *sadl.HttpDef) string { return gen.ResponseTypeName(hd) },
 		"methodName":  func(hd *sadl.HttpDef) string { return sadl.Capitalize(hd.Name) },
 		"routePath": func(hd *sadl.HttpDef) string {
-			path := hd.Path
+			path := gen.Model.HttpBase(hd) + hd.Path
 			i := strings.Index(path, "?")
 			if i >= 0 {
 				path = path[0:i]
 			}
+			for _, in := range hd.Inputs {
+				if in.Greedy {
+					path = strings.Replace(path, "{"+in.Name+"+}", "{"+in.Name+":.*}", 1)
+				}
+			}
 			return path
 		},
 		"defaultLiteral": func(any interface{}) string {
@@ -65,6 +70,21 @@ func (gen *Generator) EmitServerAdaptor() {
 				return fmt.Sprint(val)
 			}
 		},
+		"authenticate": func(hd *sadl.HttpDef) string {
+			var schemes []string
+			for _, ad := range gen.Model.HttpAuth(hd) {
+				schemes = append(schemes, fmt.Sprintf("%q", ad.Name))
+			}
+			if len(schemes) == 0 {
+				return ""
+			}
+			scopes := "nil"
+			if len(hd.Scopes) > 0 {
+				scopes = fmt.Sprintf("%#v", hd.Scopes)
+			}
+			s := fmt.Sprintf("\tif err := handler.authenticate(r, []string{%s}, %s); err != nil {\n", strings.Join(schemes, ", "), scopes)
+			return s + "\t\terrorResponse(w, 401, fmt.Sprint(err))\n\t\treturn\n\t}\n"
+		},
 		"inputs": func(hd *sadl.HttpDef) string {
 			//1. the struct is already declared, as is the err variable.
 			form := false
@@ -80,12 +100,23 @@ func (gen *Generator) EmitServerAdaptor() {
 			}
 			for _, in := range hd.Inputs {
 				name := sadl.Capitalize(in.Name)
-				if in.Query != "" {
+				if gen.baseType(in.Type) == "Array" && in.Query != "" {
+					s = s + gen.arrayParam(in, "req."+name, fmt.Sprintf("r.Form[%q]", in.Query), in.Csv)
+				} else if gen.baseType(in.Type) == "Array" && in.Header != "" {
+					s = s + gen.arrayParam(in, "req."+name, fmt.Sprintf("r.Header.Values(%q)", in.Header), true)
+				} else if in.Query != "" {
 					s = s + "\treq." + name + " = " + gen.paramAccessor(in, fmt.Sprintf("r.Form.Get(%q)", in.Query)) + "\n"
 				} else if in.Header != "" {
 					s = s + "\treq." + name + " = " + gen.paramAccessor(in, fmt.Sprintf("r.Header.Get(%q)", in.Header)) + "\n"
+				} else if in.Cookie != "" {
+					s = s + "\treq." + name + " = " + gen.paramAccessor(in, fmt.Sprintf("cookieParam(r, %q)", in.Cookie)) + "\n"
 				} else if in.Path {
 					s = s + "\treq." + name + " = " + gen.paramAccessor(in, fmt.Sprintf("mux.Vars(r)[%q]", in.Name)) + "\n"
+				} else if sadl.IsFormMediaType(in.MediaType) {
+					s = s + gen.formInput(in, "req."+name)
+				} else if !sadl.IsJsonMediaType(in.MediaType) {
+					s = s + "\tif body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize)); err != nil {\n\t\terrorResponse(w, bodyErrorStatus(err), fmt.Sprint(err))\n\t\treturn\n"
+					s = s + "\t} else {\n\t\treq." + name + " = " + gen.nativeType(in.Type) + "(body)\n\t}\n"
 				} else {
 					s = s + "\terr := json.NewDecoder(r.Body).Decode(&req." + name + ")\n"
 					s = s + "\tif err != nil {\n\t\terrorResponse(w, 400, fmt.Sprint(err))\n\t\treturn\n\t}\n"
@@ -94,39 +125,41 @@ func (gen *Generator) EmitServerAdaptor() {
 			return s
 		},
 		"outputs": func(hd *sadl.HttpDef) string {
-			s := ""
-			for _, out := range hd.Expected.Outputs {
-				name := sadl.Capitalize(out.Name)
-				if out.Header != "" {
-					s = s + "\t\tw.Header().Add(\"" + out.Header + "\", normalizeHeaderValue(\"" + out.Header + "\", res." + name + "))\n"
-				}
-			}
-			return s
+			return gen.outputHeaders(hd.Expected.Outputs, "res", "\t\t")
 		},
 		"exceptions": func(hd *sadl.HttpDef) string {
 			s := ""
-			for _, e := range hd.Exceptions {
+			for _, e := range gen.Model.HttpExceptions(hd) {
+				if len(e.Outputs) > 0 {
+					etype := "*" + gen.ExceptionTypeName(hd, e)
+					s = s + "\t\tcase " + etype + ":\n"
+					s = s + "\t\t\te := err.(" + etype + ")\n"
+					s = s + gen.outputHeaders(e.Outputs, "e", "\t\t\t")
+					s = s + gen.entityResponse(gen.bodyOutput(e.Outputs), e.ResponseStatus(), "e."+e.Type, "\t\t\t")
+					continue
+				}
 				s = s + "\t\tcase " + gen.nativeType(e.Type) + ":\n"
-				s = s + fmt.Sprintf("\t\t\tjsonResponse(w, %d, err)\n", e.Status)
+				s = s + fmt.Sprintf("\t\t\tjsonResponse(w, %d, err)\n", e.ResponseStatus())
 			}
 			return s
 		},
 		"expectedResult": func(hd *sadl.HttpDef) string {
 			switch hd.Expected.Status {
 			case 204, 304:
-				return "_"
+				if len(hd.Expected.Outputs) == 0 {
+					return "_"
+				}
+				return "res" //for the headers and cookies
 			default:
 				return "res"
 			}
 		},
-		"expectedEntity": func(hd *sadl.HttpDef) string {
-			for _, out := range hd.Expected.Outputs {
-				name := sadl.Capitalize(out.Name)
-				if out.Header == "" {
-					return "res." + name
-				}
+		"expectedResponse": func(hd *sadl.HttpDef) string {
+			out := gen.bodyOutput(hd.Expected.Outputs)
+			if out == nil {
+				return fmt.Sprintf("\t\tjsonResponse(w, %d, nil)\n", hd.Expected.Status)
 			}
-			return "nil"
+			return gen.entityResponse(out, hd.Expected.Status, "res."+sadl.Capitalize(out.Name), "\t\t")
 		},
 		"signature": func(hd *sadl.HttpDef) string {
 			name := gen.Capitalize(hd.Name)
@@ -134,11 +167,41 @@ func (gen *Generator) EmitServerAdaptor() {
 			resType := gen.ResponseTypeName(hd)
 			return "func " + name + "(req *" + reqType + ") (*" + resType + ", error)"
 		},
-		"capitalize": func(s string) string { return gen.Capitalize(s) },
+		"capitalize":    func(s string) string { return gen.Capitalize(s) },
+		"decimalParams": func() bool { return gen.decimalParams() },
+		"rawBodies": func() bool {
+			if gen.rawBodies() {
+				gen.addImport("errors")
+				return true
+			}
+			return false
+		},
+		"errorEntity": func(hd *sadl.HttpDef) string {
+			return gen.errorEntity(defaultException(gen.Model.HttpExceptions(hd)), "500", "fmt.Sprint(err)")
+		},
+		"commonErrorEntity": func() string {
+			return gen.errorEntity(defaultException(gen.Model.Exceptions), "status", "message")
+		},
 	}
 	gen.EmitTemplate("server", serverTemplate, gen, funcMap)
 }
 
+// outputHeaders returns the statements that set the headers and cookies of a response from the fields of v.
+func (gen *Generator) outputHeaders(outputs []*sadl.HttpParamSpec, v string, indent string) string {
+	s := ""
+	for _, out := range outputs {
+		name := v + "." + sadl.Capitalize(out.Name)
+		if out.Header != "" {
+			s = s + indent + "w.Header().Add(\"" + out.Header + "\", normalizeHeaderValue(\"" + out.Header + "\", " + name + "))\n"
+		} else if out.Cookie != "" {
+			s = s + indent + "if " + name + " != " + gen.notSet(out.Type) + " {\n"
+			s = s + indent + "\thttp.SetCookie(w, &http.Cookie{Name: \"" + out.Cookie + "\", Value: fmt.Sprint(" + name + ")})\n"
+			s = s + indent + "}\n"
+		}
+	}
+	return s
+}
+
 func (gen *Generator) paramAccessor(in *sadl.HttpParamSpec, v string) string {
 	coerceTo := gen.nativeType(in.Type)
 	if strings.HasPrefix(coerceTo, "*") {
@@ -160,16 +223,234 @@ func (gen *Generator) paramAccessor(in *sadl.HttpParamSpec, v string) string {
 			vv = fmt.Sprintf("%s(%s)", coerceTo, vv)
 		}
 		return vv
-		/*
-			case "Float32", "Float64":
-			case "Decimal":
-			case "UUID":
-		*/
+	case "Float32", "Float64":
+		vv := fmt.Sprintf("floatParam(%s, %v)", v, floatDefault(in.Default))
+		if coerceTo != "float64" {
+			vv = fmt.Sprintf("%s(%s)", coerceTo, vv)
+		}
+		return vv
+	case "Bool":
+		vv := fmt.Sprintf("boolParam(%s, %v)", v, in.Default == true)
+		if coerceTo != "bool" {
+			vv = fmt.Sprintf("%s(%s)", coerceTo, vv)
+		}
+		return vv
+	case "Decimal":
+		if coerceTo == "&Decimal" {
+			return fmt.Sprintf("decimalParam(%s, %q)", v, decimalDefault(in.Default))
+		}
+		return fmt.Sprintf("%s(decimalValue(%s, %q))", coerceTo, v, decimalDefault(in.Default))
+	case "Enum":
+		return fmt.Sprintf("%s(enumValueParam(%s, names%s, %s))", coerceTo, v, coerceTo, gen.enumDefault(in.Type, in.Default))
 	default:
 		panic("Fix this: " + in.Type)
 	}
 }
 
+// defaultException returns the exception with no status, which is the type of all other errors, or nil if there is none.
+func defaultException(exceptions []*sadl.HttpExceptionSpec) *sadl.HttpExceptionSpec {
+	for _, exc := range exceptions {
+		if exc.Status == 0 {
+			return exc
+		}
+	}
+	return nil
+}
+
+// errorEntity returns the body of an error response that is not one of the declared exceptions, given the expressions
+// of its status and message. It is of the type of the default exception if there is one, with the fields that look
+// like they are for the status or message set.
+func (gen *Generator) errorEntity(exc *sadl.HttpExceptionSpec, status string, message string) string {
+	if exc == nil {
+		return "&serverError{Error: http.StatusText(" + status + "), Message: " + message + "}"
+	}
+	td := gen.Model.FindType(exc.Type)
+	if td == nil || td.Type != "Struct" {
+		return "&serverError{Error: http.StatusText(" + status + "), Message: " + message + "}"
+	}
+	var fields []string
+	for _, fd := range td.Fields {
+		if fd.Nullable {
+			continue
+		}
+		name := sadl.Capitalize(fd.Name) + ": "
+		switch gen.baseType(fd.Type) {
+		case "String":
+			coerce := func(v string) string {
+				if tn := gen.nativeType(fd.Type); tn != "string" {
+					return tn + "(" + v + ")"
+				}
+				return v
+			}
+			switch strings.ToLower(fd.Name) {
+			case "message", "detail", "description":
+				fields = append(fields, name+coerce(message))
+			case "error", "title":
+				fields = append(fields, name+coerce("http.StatusText("+status+")"))
+			case "code", "status":
+				fields = append(fields, name+coerce("strconv.Itoa("+status+")"))
+			}
+		case "Int8", "Int16", "Int32", "Int64":
+			switch strings.ToLower(fd.Name) {
+			case "code", "status":
+				fields = append(fields, name+gen.nativeType(fd.Type)+"("+status+")")
+			}
+		}
+	}
+	return "&" + exc.Type + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// bodyOutput returns the output that is the body of a response, or nil if there is none.
+func (gen *Generator) bodyOutput(outputs []*sadl.HttpParamSpec) *sadl.HttpParamSpec {
+	for _, out := range outputs {
+		if out.Header == "" && out.Cookie == "" {
+			return out
+		}
+	}
+	return nil
+}
+
+// entityResponse returns the statement that writes v as the body of a response, in the media type of the output.
+func (gen *Generator) entityResponse(out *sadl.HttpParamSpec, status int32, v string, indent string) string {
+	switch {
+	case out == nil || out.MediaType == "" || out.MediaType == "application/json":
+		return fmt.Sprintf("%sjsonResponse(w, %d, %s)\n", indent, status, v)
+	case sadl.IsJsonMediaType(out.MediaType):
+		return fmt.Sprintf("%scontentResponse(w, %d, %q, []byte(Pretty(%s)))\n", indent, status, out.MediaType, v)
+	default:
+		return fmt.Sprintf("%scontentResponse(w, %d, %q, []byte(%s))\n", indent, status, out.MediaType, v)
+	}
+}
+
+// formInput returns the statements that set a Struct input from the parts of a form. A urlencoded form is taken from
+// the body only, not the query, and a multipart form may have files, for its Bytes fields.
+func (gen *Generator) formInput(in *sadl.HttpParamSpec, target string) string {
+	s := "\tif err := r.ParseForm(); err != nil {\n"
+	if in.MediaType == "multipart/form-data" {
+		s = "\tif err := r.ParseMultipartForm(32 << 20); err != nil {\n"
+	}
+	s = s + "\t\terrorResponse(w, 400, fmt.Sprint(err))\n\t\treturn\n\t}\n"
+	s = s + "\t" + target + " = &" + strings.TrimPrefix(gen.nativeType(in.Type), "*") + "{}\n"
+	for _, fd := range gen.Model.FormFields(in) {
+		field := &sadl.HttpParamSpec{StructFieldDef: *fd}
+		ftarget := target + "." + sadl.Capitalize(fd.Name)
+		switch gen.baseType(fd.Type) {
+		case "Array":
+			s = s + gen.arrayParam(field, ftarget, fmt.Sprintf("r.PostForm[%q]", fd.WireName()), false)
+		case "Bytes":
+			s = s + "\t" + ftarget + " = " + gen.nativeType(fd.Type) + fmt.Sprintf("(formFile(r, %q))\n", fd.WireName())
+		default:
+			s = s + "\t" + ftarget + " = " + gen.paramAccessor(field, fmt.Sprintf("r.PostForm.Get(%q)", fd.WireName())) + "\n"
+		}
+	}
+	return s
+}
+
+// arrayParam returns the statements that set the Array target from the values of a query parameter, header, or form
+// field, each of which may be a comma-separated list of items. A bad item is a 400 error.
+func (gen *Generator) arrayParam(in *sadl.HttpParamSpec, target string, values string, csv bool) string {
+	items := in.Items
+	if td := gen.Model.FindType(in.Type); items == "" && td != nil {
+		items = td.Items
+	}
+	itemType := gen.nativeType(items)
+	s := fmt.Sprintf("\tfor _, v := range paramValues(%s, %v) {\n", values, csv)
+	bt := gen.baseType(items)
+	switch bt {
+	case "String", "UUID":
+		if itemType == "string" {
+			return s + "\t\t" + target + " = append(" + target + ", v)\n\t}\n"
+		}
+		return s + "\t\t" + target + " = append(" + target + ", " + itemType + "(v))\n\t}\n"
+	case "Int8", "Int16", "Int32", "Int64":
+		s = s + fmt.Sprintf("\t\tn, err := strconv.ParseInt(v, 10, %s)\n", bitSize(bt))
+	case "Float32", "Float64":
+		s = s + fmt.Sprintf("\t\tn, err := strconv.ParseFloat(v, %s)\n", bitSize(bt))
+	case "Bool":
+		s = s + "\t\tn, err := strconv.ParseBool(v)\n"
+	case "Enum":
+		s = s + fmt.Sprintf("\t\tn, err := enumParam(v, names%s)\n", itemType)
+	case "Timestamp":
+		s = s + "\t\tn, err := ParseTimestamp(v)\n"
+	case "Decimal":
+		s = s + "\t\tn, err := ParseDecimal(v)\n"
+	default:
+		panic("Fix this: " + in.Type + "<" + items + ">")
+	}
+	s = s + "\t\tif err != nil {\n"
+	s = s + fmt.Sprintf("\t\t\terrorResponse(w, 400, fmt.Sprintf(\"Bad value for %s: %%q\", v))\n", in.Name)
+	s = s + "\t\t\treturn\n\t\t}\n"
+	item := itemType + "(n)"
+	switch {
+	case bt == "Timestamp" && itemType == "*Timestamp":
+		item = "&n"
+	case bt == "Decimal" && itemType == "*Decimal":
+		item = "n"
+	case bt == "Decimal":
+		item = itemType + "(*n)"
+	}
+	s = s + "\t\t" + target + " = append(" + target + ", " + item + ")\n"
+	return s + "\t}\n"
+}
+
+// decimalParams returns true if any input is taken from a single Decimal parameter or form field, which needs the
+// decimalParam helpers. The model declares Decimal if so.
+func (gen *Generator) decimalParams() bool {
+	for _, hd := range gen.Model.Http {
+		for _, in := range hd.Inputs {
+			if (in.Query != "" || in.Header != "" || in.Cookie != "" || in.Path) && gen.baseType(in.Type) == "Decimal" {
+				return true
+			}
+			if sadl.IsFormMediaType(in.MediaType) {
+				for _, fd := range gen.Model.FormFields(in) {
+					if gen.baseType(fd.Type) == "Decimal" {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// rawBodies returns true if any input is a request body neither in JSON nor a form, which is read whole, up to a limit.
+func (gen *Generator) rawBodies() bool {
+	for _, hd := range gen.Model.Http {
+		for _, in := range hd.Inputs {
+			if !in.Path && in.Query == "" && in.Header == "" && in.Cookie == "" && !sadl.IsFormMediaType(in.MediaType) && !sadl.IsJsonMediaType(in.MediaType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enumDefault returns the constant of the element of the Enum type that is the default, or 0 for none.
+func (gen *Generator) enumDefault(tname string, any interface{}) string {
+	s := sadl.AsString(any)
+	if td := gen.Model.FindType(tname); td != nil && s != "" {
+		for _, el := range td.Elements {
+			if el.Symbol == s || el.WireName() == s {
+				return "int(" + el.Symbol + ")"
+			}
+		}
+	}
+	return "0"
+}
+
+func bitSize(baseType string) string {
+	switch baseType {
+	case "Int8":
+		return "8"
+	case "Int16":
+		return "16"
+	case "Int32", "Float32":
+		return "32"
+	default:
+		return "64"
+	}
+}
+
 func (gen *Generator) nativeType(name string) string {
 	td := gen.Model.FindType(name)
 	if td == nil {
@@ -211,6 +492,23 @@ func timestampDefault(any interface{}) string {
 	return "nil"
 }
 
+func decimalDefault(v interface{}) string {
+	if n, ok := v.(*sadl.Decimal); ok {
+		return n.String()
+	}
+	return ""
+}
+
+func floatDefault(v interface{}) float64 {
+	if v != nil {
+		switch n := v.(type) {
+		case *sadl.Decimal:
+			return n.AsFloat64()
+		}
+	}
+	return 0
+}
+
 func intDefault(v interface{}) int64 {
 	if v != nil {
 		switch n := v.(type) {
@@ -223,28 +521,49 @@ func intDefault(v interface{}) int64 {
 
 var serverTemplate = `
 type {{adaptorName}} struct {
-	impl {{serviceName}}
+	impl {{serviceName}}{{if .Model.Auth}}
+	auth Authenticator{{end}}
+}
+{{if .Model.Auth}}
+// Authenticator checks the credentials of a request for one of the auth schemes of the service, as named in the model,
+// and the scopes that the action requires. An error fails the request with a 401.
+type Authenticator interface {
+	Authenticate(r *http.Request, scheme string, scopes []string) error
 }
 
+// authenticate succeeds if the request has the credentials of any one of the schemes.
+func (handler *{{adaptorName}}) authenticate(r *http.Request, schemes []string, scopes []string) error {
+	err := fmt.Errorf("Unauthorized")
+	if handler.auth == nil {
+		return err
+	}
+	for _, scheme := range schemes {
+		if err = handler.auth.Authenticate(r, scheme, scopes); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+{{end}}
 {{range .Model.Http}}
 func (handler *{{adaptorName}}) {{methodName .}}Handler(w http.ResponseWriter, r *http.Request) {
-	req := new({{reqTypeName .}})
+{{authenticate .}}	req := new({{reqTypeName .}})
 {{inputs .}}
 	{{expectedResult .}}, err := handler.impl.{{methodName .}}(req)
 	if err != nil {
 		switch err.(type) {
 {{exceptions .}}		default:
-			jsonResponse(w, 500, &serverError{Message: fmt.Sprint(err)})
+			jsonResponse(w, 500, {{errorEntity .}})
 		}
 	} else {
-{{outputs .}}		jsonResponse(w, {{.Expected.Status}}, {{expectedEntity .}})
-	}
+{{outputs .}}{{expectedResponse .}}	}
 }
 {{end}}
 
-func InitServer(impl {{serviceName}}, baseURL string) http.Handler {
+func InitServer(impl {{serviceName}}, baseURL string{{if .Model.Auth}}, auth Authenticator{{end}}) http.Handler {
 	adaptor := &{{adaptorName}}{
-		impl: impl,
+		impl: impl,{{if .Model.Auth}}
+		auth: auth,{{end}}
 	}
    u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
    if err != nil {
@@ -257,7 +576,7 @@ func InitServer(impl {{serviceName}}, baseURL string) http.Handler {
       adaptor.{{methodName .}}Handler(w, r)
 	}).Methods("{{.Method}}"){{end}}
 	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		jsonResponse(w, 404, &serverError{Message: fmt.Sprintf("Not Found: %s", r.URL.Path)})
+		errorResponse(w, 404, fmt.Sprintf("Not Found: %s", r.URL.Path))
 	})
 	return r
 }
@@ -269,6 +588,81 @@ func stringParam(val string, def string) string {
 	return val
 }
 
+func cookieParam(r *http.Request, name string) string {
+	if c, err := r.Cookie(name); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// paramValues returns the items of a repeated parameter, splitting each value at commas if csv is set.
+func paramValues(vals []string, csv bool) []string {
+	var items []string
+	for _, val := range vals {
+		if csv {
+			for _, item := range strings.Split(val, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		} else {
+			items = append(items, val)
+		}
+	}
+	return items
+}
+
+func enumParam(val string, names []string) (int, error) {
+	for i, name := range names {
+		if i > 0 && name == val {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Bad enum symbol: %s", val)
+}
+
+// enumValueParam returns the index of the value in the names of an Enum type, or the default if it is not one.
+func enumValueParam(val string, names []string, def int) int {
+	if i, err := enumParam(val, names); err == nil {
+		return i
+	}
+	return def
+}
+{{if rawBodies}}
+// MaxBodySize is the most bytes of a request body, other than JSON or a form, that is read whole for an action.
+var MaxBodySize int64 = 32 << 20
+
+// bodyErrorStatus is the status of a failure to read a request body: 413 if it is larger than MaxBodySize.
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return 413
+	}
+	return 400
+}
+{{end}}{{if decimalParams}}
+// decimalParam returns the value as a Decimal, or the default, which is nil if there is none.
+func decimalParam(val string, def string) *Decimal {
+	if val != "" {
+		if d, err := ParseDecimal(val); err == nil {
+			return d
+		}
+	}
+	if def != "" {
+		d, _ := ParseDecimal(def)
+		return d
+	}
+	return nil
+}
+
+// decimalValue is decimalParam for a named Decimal type, which is not a pointer, so is zero if there is no value.
+func decimalValue(val string, def string) Decimal {
+	if d := decimalParam(val, def); d != nil {
+		return *d
+	}
+	return Decimal{}
+}
+{{end}}
 func timestampParam(val string, def *Timestamp) *Timestamp {
 	if val != "" {
 		ts, err := ParseTimestamp(val)
@@ -289,6 +683,40 @@ func intParam(val string, def int64) int64 {
 	return def
 }
 
+func floatParam(val string, def float64) float64 {
+	if val != "" {
+		n, err := strconv.ParseFloat(val, 64)
+		if err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+func boolParam(val string, def bool) bool {
+	if val != "" {
+		b, err := strconv.ParseBool(val)
+		if err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// formFile returns the content of a file in a multipart form, or nil if it is absent.
+func formFile(r *http.Request, name string) []byte {
+	f, _, err := r.FormFile(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
 func Pretty(obj interface{}) string {
 	buf := new(bytes.Buffer)
 	enc := json.NewEncoder(buf)
@@ -316,8 +744,14 @@ func jsonResponse(w http.ResponseWriter, status int, entity interface{}) {
 	io.WriteString(w, Pretty(entity))
 }
 
+func contentResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
 func errorResponse(w http.ResponseWriter, status int, message string) {
-	jsonResponse(w, status, &serverError{Error: http.StatusText(status), Message: message})
+	jsonResponse(w, status, {{commonErrorEntity}})
 }
 
 func normalizeHeaderValue(key string, value interface{}) string {
@@ -55,6 +55,12 @@ func FromSadl(model *sadl.Model, conf *sadl.Data) (string, error) {
 	for k, _ := range w.customScalars {
 		w.Emit("scalar %s\n", k)
 	}
+	if w.jsonNames {
+		w.Emit("directive @jsonName(name: String!) on FIELD_DEFINITION | ENUM_VALUE\n")
+	}
+	if w.nullables {
+		w.Emit("directive @nullable(required: Boolean) on FIELD_DEFINITION\n")
+	}
 	return w.End(), nil
 }
 
@@ -68,6 +74,8 @@ type GraphqlWriter struct {
 	name          string
 	version       string
 	customScalars map[string]bool
+	jsonNames     bool
+	nullables     bool
 }
 
 func (w *GraphqlWriter) Begin() {
@@ -82,7 +90,7 @@ func (w *GraphqlWriter) Emit(format string, args ...interface{}) {
 func (w *GraphqlWriter) EmitEnumDef(td *sadl.TypeDef) error {
 	w.Emit("enum %s {\n", td.Name)
 	for _, ed := range td.Elements {
-		w.Emit("  %s\n", ed.Symbol)
+		w.Emit("  %s%s\n", ed.Symbol, w.jsonName(ed.Symbol, ed.WireName()))
 	}
 	w.Emit("}\n\n")
 	return nil
@@ -92,7 +100,7 @@ func (w *GraphqlWriter) EmitStructDef(td *sadl.TypeDef) error {
 	w.Emit("type %s {\n", td.Name)
 	for _, fd := range td.Fields {
 		required := ""
-		if fd.Required {
+		if fd.Required && !fd.Nullable {
 			required = "!"
 		}
 		if fd.Comment != "" {
@@ -100,12 +108,36 @@ func (w *GraphqlWriter) EmitStructDef(td *sadl.TypeDef) error {
 			w.Emit("  # %s\n", fd.Comment)
 		}
 		ftype := w.typeRef(&fd.TypeSpec)
-		w.Emit("  %s: %s%s\n", fd.Name, ftype, required)
+		w.Emit("  %s: %s%s%s%s\n", fd.Name, ftype, required, w.nullable(fd), w.jsonName(fd.Name, fd.WireName()))
 	}
 	w.Emit("}\n\n")
 	return nil
 }
 
+// nullable returns the directive for a field that may be null. Any field that is not non-null may be null in GraphQL,
+// the directive tells those that are nullable in SADL from those that are just optional. A nullable field that is also
+// required cannot be non-null, so the directive says that it is required.
+func (w *GraphqlWriter) nullable(fd *sadl.StructFieldDef) string {
+	if !fd.Nullable {
+		return ""
+	}
+	w.nullables = true
+	if fd.Required {
+		return " @nullable(required: true)"
+	}
+	return " @nullable"
+}
+
+// jsonName returns the directive for a field or enum value that is represented in JSON by something other than its
+// name, which GraphQL has no equivalent for.
+func (w *GraphqlWriter) jsonName(name string, wireName string) string {
+	if wireName == name {
+		return ""
+	}
+	w.jsonNames = true
+	return fmt.Sprintf(" @jsonName(name: %q)", wireName)
+}
+
 func (w *GraphqlWriter) customScalar(name string, defaultMapping string) string {
 	tname := w.config.GetString("custom-scalars", name)
 	if tname != "" {
@@ -2,6 +2,8 @@ package graphql
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"strings"
 
@@ -19,15 +21,32 @@ func IsValidFile(path string) bool {
 }
 
 func Import(paths []string, conf *sadl.Data) (*sadl.Model, error) {
+	return ImportFS(nil, paths, conf)
+}
+
+// ImportFS imports the GraphQL file from the file system, or the OS file system if fsys is nil.
+func ImportFS(fsys fs.FS, paths []string, conf *sadl.Data) (*sadl.Model, error) {
 	//todo: merge multiple files
 	if len(paths) != 1 {
 		return nil, fmt.Errorf("GraphQL file merging NYI")
 	}
-	path := paths[0]
-	src, err := ioutil.ReadFile(path)
+	src, err := sadl.ReadFile(fsys, paths[0])
+	if err != nil {
+		return nil, err
+	}
+	return importSource(src, conf)
+}
+
+// ImportReader imports the GraphQL schema read from r.
+func ImportReader(r io.Reader, conf *sadl.Data) (*sadl.Model, error) {
+	src, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
+	return importSource(src, conf)
+}
+
+func importSource(src []byte, conf *sadl.Data) (*sadl.Model, error) {
 	doc, err := gql_parser.Parse(gql_parser.ParseParams{
 		Source: &gql_source.Source{
 			Body: src,
@@ -86,6 +105,8 @@ func gqlSchema(doc *gql_ast.Document, conf *sadl.Data) (*sadl.Schema, error) {
 			//ignore for now fmt.Println("fix me: interfaces")
 		case *gql_ast.InputObjectDefinition:
 			//ignore for now fmt.Println("fix me: input objects")
+		case *gql_ast.DirectiveDefinition:
+			//the directives used are recognized where they are applied
 		case *gql_ast.ScalarDefinition:
 			sname := tdef.Name.Value
 			switch sname {
@@ -144,6 +165,45 @@ func commentValue(descr string) string {
 	return strings.Replace(descr, "\n", " ", -1)
 }
 
+// nullableRequired returns true if a @nullable directive says that its field is required, as the exporter writes them.
+func nullableRequired(directives []*gql_ast.Directive) bool {
+	for _, dir := range directives {
+		if dir.Name.Value != "nullable" {
+			continue
+		}
+		for _, arg := range dir.Arguments {
+			if b, ok := arg.Value.GetValue().(bool); ok && arg.Name.Value == "required" {
+				return b
+			}
+		}
+	}
+	return false
+}
+
+func hasDirective(directives []*gql_ast.Directive, name string) bool {
+	for _, dir := range directives {
+		if dir.Name.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonNameAnnotations returns the x_wire_name annotation for a @jsonName directive, as the exporter writes them.
+func jsonNameAnnotations(directives []*gql_ast.Directive) map[string]interface{} {
+	for _, dir := range directives {
+		if dir.Name.Value != "jsonName" {
+			continue
+		}
+		for _, arg := range dir.Arguments {
+			if s, ok := arg.Value.GetValue().(string); ok && arg.Name.Value == "name" {
+				return map[string]interface{}{"x_wire_name": s}
+			}
+		}
+	}
+	return nil
+}
+
 func gqlEnum(schema *sadl.Schema, def *gql_ast.EnumDefinition) error {
 	td := &sadl.TypeDef{
 		Name: def.Name.Value,
@@ -156,7 +216,8 @@ func gqlEnum(schema *sadl.Schema, def *gql_ast.EnumDefinition) error {
 	}
 	for _, symdef := range def.Values {
 		el := &sadl.EnumElementDef{
-			Symbol: symdef.Name.Value,
+			Symbol:      symdef.Name.Value,
+			Annotations: jsonNameAnnotations(symdef.Directives),
 		}
 		if symdef.Description != nil {
 			el.Comment = commentValue(symdef.Description.Value)
@@ -199,8 +260,9 @@ func gqlStruct(schema *sadl.Schema, structDef *gql_ast.ObjectDefinition) error {
 	for _, fnode := range structDef.Fields {
 		f := (*gql_ast.FieldDefinition)(fnode)
 		fd := &sadl.StructFieldDef{
-			Name:    f.Name.Value,
-			Comment: commentValue(stringValue(f.Description)),
+			Name:        f.Name.Value,
+			Comment:     commentValue(stringValue(f.Description)),
+			Annotations: jsonNameAnnotations(f.Directives),
 		}
 		switch t := (*gql_ast.FieldDefinition)(fnode).Type.(type) {
 		case *gql_ast.Named:
@@ -239,6 +301,10 @@ func gqlStruct(schema *sadl.Schema, structDef *gql_ast.ObjectDefinition) error {
 				fd.Type = convertTypeName(typeName(t))
 			}
 		}
+		if !fd.Required && hasDirective(f.Directives, "nullable") {
+			fd.Nullable = true
+			fd.Required = nullableRequired(f.Directives)
+		}
 		td.Fields = append(td.Fields, fd)
 	}
 	schema.Types = append(schema.Types, td)
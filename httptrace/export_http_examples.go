@@ -3,7 +3,9 @@ package httptrace
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,6 +38,7 @@ type exampleData struct {
 	req map[string]interface{}
 	exc *sadl.HttpExceptionSpec
 	res map[string]interface{}
+	outputs []*sadl.HttpParamSpec
 }
 
 func generateHttpTrace(model *sadl.Model, hdef *sadl.HttpDef) (string, error) {
@@ -58,10 +61,15 @@ func generateHttpTrace(model *sadl.Model, hdef *sadl.HttpDef) (string, error) {
 				if ex.Target == resType {
 					data.res = ex.Example.(map[string]interface{})
 				} else {
-					for _, exc := range hdef.Exceptions {
+					for _, exc := range model.HttpExceptions(hdef) {
 						if exc.Type == ex.Target {
 							data.res = ex.Example.(map[string]interface{})
 							data.exc = exc
+						} else if len(exc.Outputs) > 0 && sadl.ExceptionExampleTarget(hdef, exc) == ex.Target {
+							//an example of the whole response, with its headers
+							data.res = ex.Example.(map[string]interface{})
+							data.exc = exc
+							data.outputs = exc.Outputs
 						}
 					}
 				}
@@ -80,6 +88,7 @@ func generateHttpTrace(model *sadl.Model, hdef *sadl.HttpDef) (string, error) {
 			path := hdef.Path
 			bodyExample := ""
 			headers := ""
+			var cookies []string
 			
 			for _, in := range hdef.Inputs {
 				ex := reqExample[in.Name]
@@ -92,12 +101,29 @@ func generateHttpTrace(model *sadl.Model, hdef *sadl.HttpDef) (string, error) {
 				} else if in.Header != "" {
 					sex := stringExample(ex)
 					headers = headers + in.Header + ": " + sex + "\n"
+				} else if in.Cookie != "" {
+					if ex != nil {
+						cookies = append(cookies, in.Cookie + "=" + stringExample(ex))
+					}
 				} else { //body
-					bodyExample = sadl.Pretty(ex)
+					var contentType string
+					bodyExample, contentType = mediaTypeExample(ex, in.MediaType)
+					if in.MediaType != "" {
+						headers = headers + "Content-Type: " + contentType + "\n"
+					}
 				}
 			}
+			if len(cookies) > 0 {
+				headers = headers + "Cookie: " + strings.Join(cookies, "; ") + "\n"
+			}
 			path = stripMissingOptionalQueryParams(path)
-			headers = headers + "Accept: application/json\n"
+			accept := "application/json"
+			for _, out := range hdef.Expected.Outputs {
+				if out.Header == "" && out.Cookie == "" && out.MediaType != "" {
+					accept = out.MediaType
+				}
+			}
+			headers = headers + "Accept: " + accept + "\n"
 			s := method + " " + path + " HTTP/1.1\n" + headers + "\n" + bodyExample
 			body = body + "\n" + s + "\n"
 			
@@ -108,20 +134,30 @@ func generateHttpTrace(model *sadl.Model, hdef *sadl.HttpDef) (string, error) {
 				headers := "Content-Type: application/json; charset=utf-8\n"
 
 
-				if data.exc == nil {
-					for _, out := range hdef.Expected.Outputs {
+				outputs := hdef.Expected.Outputs
+				if data.exc != nil {
+					status = data.exc.ResponseStatus()
+					outputs = data.outputs
+				}
+				if data.exc == nil || data.outputs != nil {
+					for _, out := range outputs {
 						ex := resExample[out.Name]
 						if out.Header != "" {
 							sex := stringExample(ex)
 							headers = headers + out.Header + ": " + sex + "\n"
+						} else if out.Cookie != "" {
+							if ex != nil {
+								headers = headers + "Set-Cookie: " + out.Cookie + "=" + stringExample(ex) + "\n"
+							}
 						} else { //body
 							if status != 204 {
-								bodyExample = sadl.Pretty(ex)
+								var contentType string
+								bodyExample, contentType = mediaTypeExample(ex, out.MediaType)
+								headers = strings.Replace(headers, "application/json; charset=utf-8", contentType, 1)
 							}
 						}
 					}
 				} else {
-					status = data.exc.Status
 					bodyExample = sadl.Pretty(resExample)
 				}
 				headers = headers + "Date: " + dateHeader() + "\n"
@@ -135,6 +171,62 @@ func generateHttpTrace(model *sadl.Model, hdef *sadl.HttpDef) (string, error) {
 	return body, nil
 }
 
+// mediaTypeExample returns the example of a body in its media type, along with the Content-Type header for it.
+func mediaTypeExample(ex interface{}, mediaType string) (string, string) {
+	switch {
+	case mediaType == "" || mediaType == "application/json":
+		return sadl.Pretty(ex), "application/json; charset=utf-8"
+	case sadl.IsJsonMediaType(mediaType):
+		return sadl.Pretty(ex), mediaType
+	case mediaType == "application/x-www-form-urlencoded":
+		form := url.Values{}
+		for name, val := range sadl.AsMap(ex) {
+			for _, item := range formExampleValues(val) {
+				form.Add(name, item)
+			}
+		}
+		return form.Encode(), mediaType
+	case mediaType == "multipart/form-data":
+		boundary := "example-boundary"
+		m := sadl.AsMap(ex)
+		var names []string
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s := ""
+		for _, name := range names {
+			for _, item := range formExampleValues(m[name]) {
+				s = s + "--" + boundary + "\nContent-Disposition: form-data; name=\"" + name + "\"\n\n" + item + "\n"
+			}
+		}
+		return s + "--" + boundary + "--\n", mediaType + "; boundary=" + boundary
+	default:
+		return textExample(ex), mediaType
+	}
+}
+
+func formExampleValues(ex interface{}) []string {
+	if items, ok := ex.([]interface{}); ok {
+		var vals []string
+		for _, item := range items {
+			vals = append(vals, textExample(item))
+		}
+		return vals
+	}
+	return []string{textExample(ex)}
+}
+
+func textExample(ex interface{}) string {
+	switch v := ex.(type) {
+	case string:
+		return v
+	case *string, *sadl.Decimal:
+		return stringExample(v)
+	}
+	return fmt.Sprint(ex)
+}
+
 func dateHeader() string {
 	t := time.Now()
 	return t.Format("Mon, 2 Jan 2006 15:04:05 GMT")
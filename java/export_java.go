@@ -37,6 +37,7 @@ type Generator struct {
 	NeedTimestamp    bool
 	NeedInstant      bool
 	NeedUtil         bool
+	NeedNullable     bool
 	imports          []string
 	ServerData       *ServerData
 	ClientData       *ClientData
@@ -54,11 +55,38 @@ func Export(model *sadl.Model, dir string, conf *sadl.Data) error {
 		gen.CreateClient()
 	}
 	if gen.UseMaven {
-		gen.CreatePom("")
+		extraDepends := ""
+		if gen.NeedNullable {
+			extraDepends = nullableDepends
+		}
+		if gen.usesMultipart() && (gen.ServerCode || gen.ClientCode) {
+			extraDepends = extraDepends + multipartDepends
+		}
+		gen.CreatePom(extraDepends)
 	}
 	return gen.Err
 }
 
+// usesMultipart returns true if any action has a multipart form body, which needs the Jersey multipart feature.
+func (gen *Generator) usesMultipart() bool {
+	for _, hact := range gen.Model.Http {
+		if in := bodyInput(hact); in != nil && in.MediaType == "multipart/form-data" {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyInput returns the input of the action that is the body of its request, or nil if there is none.
+func bodyInput(hact *sadl.HttpDef) *sadl.HttpParamSpec {
+	for _, in := range hact.Inputs {
+		if in.Query == "" && in.Header == "" && in.Cookie == "" && !in.Path {
+			return in
+		}
+	}
+	return nil
+}
+
 func defaultDomain() string {
 	s := os.Getenv("DOMAIN")
 	if s == "" {
@@ -299,9 +327,13 @@ func (gen *Generator) CreateStructPojo(ts *sadl.TypeSpec, className string, inde
 			gen.Emit(gen.FormatComment(indent+"    ", fd.Comment, 100, false))
 		}
 		if !fd.Required {
-			gen.Emit(indent + "    @JsonInclude(JsonInclude.Include.NON_EMPTY) /* Optional field */\n")
+			if fd.Nullable {
+				gen.Emit(indent + "    @JsonInclude(JsonInclude.Include.NON_ABSENT) /* Optional, nullable field */\n")
+			} else {
+				gen.Emit(indent + "    @JsonInclude(JsonInclude.Include.NON_EMPTY) /* Optional field */\n")
+			}
 		}
-		tn, tanno, anonymous := gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+		tn, tanno, anonymous := gen.FieldTypeName(fd)
 		if anonymous != nil {
 			tn = gen.Capitalize(fname)
 			if tn == className {
@@ -315,6 +347,10 @@ func (gen *Generator) CreateStructPojo(ts *sadl.TypeSpec, className string, inde
 				gen.Emit(indent + "    " + anno + "\n")
 			}
 		}
+		if fd.WireName() != fname {
+			gen.AddImport("com.fasterxml.jackson.annotation.JsonProperty")
+			gen.Emit(indent + "    @JsonProperty(\"" + fd.WireName() + "\")\n")
+		}
 		if gen.UseImmutable {
 			gen.Emit(indent + "    private final " + tn + " " + fname + ";\n\n")
 		} else {
@@ -353,7 +389,7 @@ func (gen *Generator) CreateStructPojo(ts *sadl.TypeSpec, className string, inde
 func (gen *Generator) EmitAllFieldsConstructor(className string, ts *sadl.TypeSpec, indent string) {
 	var args []string
 	for _, fd := range ts.Fields {
-		tn, _, _ := gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+		tn, _, _ := gen.FieldTypeName(fd)
 		args = append(args, tn+" "+fd.Name)
 	}
 	gen.Emit(indent + "    public " + className + "(" + strings.Join(args, ", ") + ") {\n")
@@ -364,7 +400,7 @@ func (gen *Generator) EmitAllFieldsConstructor(className string, ts *sadl.TypeSp
 }
 
 func (gen *Generator) EmitGetter(className string, ts *sadl.TypeSpec, fd *sadl.StructFieldDef, indent string) {
-	tn, _, _ := gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+	tn, _, _ := gen.FieldTypeName(fd)
 	gen.Emit(indent + "    public " + tn + " get" + gen.Capitalize(fd.Name) + "() {\n")
 	gen.Emit(indent + "        return " + fd.Name + ";\n")
 	gen.Emit(indent + "    }\n\n")
@@ -381,7 +417,7 @@ func (gen *Generator) EmitBuilder(className string, ts *sadl.TypeSpec, indent st
 	gen.Emit(indent + "@JsonPOJOBuilder(withPrefix=\"\")\n")
 	gen.Emit(indent + "public static class " + builderClass + " {\n")
 	for _, fd := range ts.Fields {
-		tn, _, _ := gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+		tn, _, _ := gen.FieldTypeName(fd)
 		gen.Emit(indent + "    private " + tn + " " + fd.Name + ";\n")
 	}
 	gen.Emit("\n")
@@ -394,7 +430,7 @@ func (gen *Generator) EmitBuilder(className string, ts *sadl.TypeSpec, indent st
 	gen.Emit(indent + "    }\n\n")
 	var args []string
 	for _, fd := range ts.Fields {
-		tn, _, _ := gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+		tn, _, _ := gen.FieldTypeName(fd)
 		if fd.Type == "Timestamp" {
 			if gen.UseInstants {
 				gen.NeedUtil = true
@@ -430,10 +466,7 @@ func (gen *Generator) CreateEnumPojo(ts *sadl.TypeSpec, className string) {
 			comment = gen.FormatComment(" ", el.Comment, 0, false)
 		}
 		sym := el.Symbol //strings.ToUpper(el.Symbol) ???
-		val := sadl.GetAnnotation(el.Annotations, "x_enumValue")
-		if val == "" {
-			val = sym
-		}
+		val := el.WireName()
 		gen.Emit("    " + sym + "(\"" + val + "\")" + delim + comment)
 	}
 	gen.Emit("\n")
@@ -517,6 +550,9 @@ func (gen *Generator) CreateUnionPojo(td *sadl.TypeSpec, className string) {
 				gen.Emit(indent1 + anno + "\n")
 			}
 		}
+		if vd.WireName() != vd.Name {
+			gen.Emit(indent1 + "@JsonProperty(\"" + vd.WireName() + "\")\n")
+		}
 		//todo: if useGetters, make this private and generate the getter
 		gen.Emit(indent1 + "public final " + tn + " " + vd.Name + ";\n")
 	}
@@ -529,7 +565,7 @@ func (gen *Generator) CreateUnionPojo(td *sadl.TypeSpec, className string) {
 			delim = ""
 		}
 		tn, _, _ := gen.TypeName(&vd.TypeSpec, vd.Type, false)
-		gen.Emit("@JsonProperty(\"" + vd.Name + "\") " + tn + " " + vd.Name + delim)
+		gen.Emit("@JsonProperty(\"" + vd.WireName() + "\") " + tn + " " + vd.Name + delim)
 	}
 	gen.Emit(") {\n")
 	for _, vd := range td.Variants {
@@ -638,7 +674,7 @@ func (gen *Generator) EmitFluidSetter(className string, ts *sadl.TypeSpec, fd *s
 	if gen.Err != nil {
 		return
 	}
-	tn, _, anonymous := gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+	tn, _, anonymous := gen.FieldTypeName(fd)
 	//fixme: the annotations are getting ignored. Figure out if this is preferred or not
 	if anonymous != nil {
 		tn = gen.Capitalize(fd.Name)
@@ -649,6 +685,25 @@ func (gen *Generator) EmitFluidSetter(className string, ts *sadl.TypeSpec, fd *s
 	gen.Emit(indent + "    }\n\n")
 }
 
+// FieldTypeName returns the type of a struct field. Nullable fields are wrapped so that an explicit null can be told apart
+// from a missing value: JsonNullable for optional fields, and Optional for required fields.
+func (gen *Generator) FieldTypeName(fd *sadl.StructFieldDef) (string, []string, *sadl.TypeSpec) {
+	if !fd.Nullable {
+		return gen.TypeName(&fd.TypeSpec, fd.Type, fd.Required)
+	}
+	tn, annotations, anonymous := gen.TypeName(&fd.TypeSpec, fd.Type, false)
+	if anonymous != nil {
+		return tn, annotations, anonymous
+	}
+	gen.NeedNullable = true
+	if fd.Required {
+		gen.AddImport("java.util.Optional")
+		return "Optional<" + tn + ">", annotations, nil
+	}
+	gen.AddImport("org.openapitools.jackson.nullable.JsonNullable")
+	return "JsonNullable<" + tn + ">", annotations, nil
+}
+
 func (gen *Generator) TypeName(ts *sadl.TypeSpec, name string, required bool) (string, []string, *sadl.TypeSpec) {
 	primitiveName, isPrimitive := primitiveType(name)
 	var annotations []string
@@ -747,21 +802,30 @@ func (gen *Generator) TypeName(ts *sadl.TypeSpec, name string, required bool) (s
 	case "UUID":
 		gen.AddImport("java.util.UUID")
 		return name, annotations, nil
+	case "Bytes":
+		return "byte[]", annotations, nil
 	case "Any":
 		return "Object", annotations, nil
 	default:
 		//app-defined type. Parser will have already verified its existence
 		td := gen.Model.FindType(name)
 		if td != nil {
+			spec := &td.TypeSpec
+			if ts != nil && ts.HasConstraints() {
+				//the constraints given inline on a field apply on top of those of its type
+				spec = gen.Model.ConstrainedTypeSpec(ts)
+			}
 			switch td.Type {
 			case "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Boolean":
-				return gen.TypeName(&td.TypeSpec, td.Type, required)
+				return gen.TypeName(spec, td.Type, required)
 			case "String":
-				return gen.TypeName(&td.TypeSpec, "String", required)
+				return gen.TypeName(spec, "String", required)
+			case "Bytes":
+				return "byte[]", annotations, nil
 			case "Array":
-				return gen.TypeName(&td.TypeSpec, "Array", false) //FIXME: the "required/optional" state of the field is lost
+				return gen.TypeName(spec, "Array", false) //FIXME: the "required/optional" state of the field is lost
 			case "Map":
-				return gen.TypeName(&td.TypeSpec, "Map", false) //FIXME: the "required/optional" state of the field is lost
+				return gen.TypeName(spec, "Map", false) //FIXME: the "required/optional" state of the field is lost
 			case "Struct":
 				if name == "Struct" {
 					return name, annotations, ts
@@ -771,11 +835,11 @@ func (gen *Generator) TypeName(ts *sadl.TypeSpec, name string, required bool) (s
 				return "UUID", annotations, nil
 			case "Decimal":
 				gen.AddImport("java.math.BigDecimal")
-				if td.Min != nil {
-					annotations = append(annotations, fmt.Sprintf("@DecimalMin(%q)", td.Min.String()))
+				if spec.Min != nil {
+					annotations = append(annotations, fmt.Sprintf("@DecimalMin(%q)", spec.Min.String()))
 				}
-				if td.Max != nil {
-					annotations = append(annotations, fmt.Sprintf("@DecimalMax(%q)", td.Max.String()))
+				if spec.Max != nil {
+					annotations = append(annotations, fmt.Sprintf("@DecimalMax(%q)", spec.Max.String()))
 				}
 				return "BigDecimal", annotations, nil
 			case "Timestamp":
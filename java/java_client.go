@@ -56,7 +56,7 @@ func (gen *Generator) CreateClientDataAndFuncMap(src, rez string) {
 	}
 	entityNameType := func(hact *sadl.HttpDef) (string, string) {
 		for _, out := range hact.Expected.Outputs {
-			if out.Header == "" {
+			if out.Header == "" && out.Cookie == "" {
 				tn, _, _ := gen.TypeName(nil, out.Type, true)
 				return out.Name, tn
 			}
@@ -67,13 +67,53 @@ func (gen *Generator) CreateClientDataAndFuncMap(src, rez string) {
 		switch typename {
 		case "Instant":
 			return "Instant.parse(" + val + ")"
+		case "int":
+			return "Integer.parseInt(" + val + ")"
+		case "byte", "short", "long", "float", "double", "boolean":
+			return gen.Capitalize(typename) + ".parse" + gen.Capitalize(typename) + "(" + val + ")"
 		default:
 			return val
 		}
 	}
+	responseHeaders := func(writer *bufio.Writer, outputs []*sadl.HttpParamSpec, indent string) {
+		for _, out := range outputs {
+			if out.Header != "" {
+				tn, _, _ := gen.TypeName(nil, out.Type, true)
+				osrc := fromString(tn, "response.getHeaderString(\""+out.Header+"\")")
+				writer.WriteString(indent + tn + " " + out.Name + " = " + osrc + ";\n")
+			} else if out.Cookie != "" {
+				tn, _, _ := gen.TypeName(nil, out.Type, true)
+				cookie := "response.getCookies().get(\"" + out.Cookie + "\")"
+				osrc := fromString(tn, cookie+".getValue()")
+				writer.WriteString(indent + tn + " " + out.Name + " = " + cookie + " == null ? null : " + osrc + ";\n")
+			}
+		}
+	}
 
 	funcMap := template.FuncMap{
 		"openBrace": func() string { return "{" },
+		"multipartImports": func() string {
+			if !gen.usesMultipart() {
+				return ""
+			}
+			return "import org.glassfish.jersey.media.multipart.FormDataBodyPart;\nimport org.glassfish.jersey.media.multipart.FormDataMultiPart;\nimport org.glassfish.jersey.media.multipart.MultiPartFeature;\n"
+		},
+		"newClient": func() string {
+			if !gen.usesMultipart() {
+				return "ClientBuilder.newClient()"
+			}
+			return "ClientBuilder.newBuilder().register(MultiPartFeature.class).build()"
+		},
+		"paramHelpers": func() string {
+			for _, hact := range gen.Model.Http {
+				for _, in := range hact.Inputs {
+					if (in.Query != "" || in.Header != "") && gen.Model.BaseType(in.Type) == "Array" {
+						return arrayParamMethods
+					}
+				}
+			}
+			return ""
+		},
 		"handlerSig": func(hact *sadl.HttpDef) string {
 			name := gen.ActionName(hact)
 			resType := gen.ResponseType(gen.ActionName(hact))
@@ -87,37 +127,135 @@ func (gen *Generator) CreateClientDataAndFuncMap(src, rez string) {
 				return "new " + resClass + "()"
 			}
 		},
+		"resources": func() []*resourceData {
+			var resources []*resourceData
+			for _, rd := range gen.Model.Resources {
+				resources = append(resources, gen.resourceData(rd))
+			}
+			return resources
+		},
+		"lifecycleSig": func(op *resourceOp) string {
+			name := gen.ActionName(op.Http)
+			return gen.ResponseType(name) + " " + op.Method + "(" + reqType(name) + " req)"
+		},
+		"actionName": func(op *resourceOp) string { return gen.ActionName(op.Http) },
+		"methodName": func(hact *sadl.HttpDef) string { return gen.ActionName(hact) },
+		"pagesSig": func(hact *sadl.HttpDef) string {
+			name := gen.ActionName(hact)
+			resType := gen.ResponseType(name)
+			return "public void " + name + "Pages(" + reqType(name) + " req, java.util.function.Predicate<" + resType + "> fn)"
+		},
+		"pageRequest": func(hact *sadl.HttpDef) string {
+			//the request of each page is a copy, so that the caller's is left as it was
+			name := gen.ActionName(hact)
+			if gen.UseImmutable {
+				return "        " + reqType(name) + " pageReq = req;\n"
+			}
+			s := "        " + reqType(name) + " pageReq = new " + reqType(name) + "();\n"
+			for _, in := range hact.Inputs {
+				s = s + "        pageReq." + in.Name + " = req." + in.Name + ";\n"
+			}
+			return s
+		},
+		"pageCall": func(hact *sadl.HttpDef) string {
+			name := gen.ActionName(hact)
+			return gen.ResponseType(name) + " res = " + name + "(pageReq);"
+		},
+		"nextPage": func(hact *sadl.HttpDef) string {
+			var conds []string
+			token := "res"
+			for _, fd := range gen.Model.HttpOutputPath(hact, hact.Paginated.OutputToken) {
+				if gen.UseImmutable {
+					token = token + ".get" + gen.Capitalize(fd.Name) + "()"
+				} else {
+					token = token + "." + fd.Name
+				}
+				conds = append(conds, token+" == null")
+				if fd.Nullable && len(fd.Fields) == 0 {
+					//a nullable field is a JsonNullable if it is optional, which may hold a null, and an Optional if it is required
+					conds = append(conds, "!"+token+".isPresent()")
+					token = token + ".get()"
+					if !fd.Required {
+						conds = append(conds, token+" == null")
+					}
+				}
+			}
+			conds = append(conds, token+".isEmpty()")
+			in := hact.Paginated.InputToken
+			s := "            if (" + strings.Join(conds, " || ") + ") {\n"
+			s = s + "                return;\n"
+			s = s + "            }\n"
+			if gen.UseImmutable {
+				s = s + "            pageReq = pageReq.toBuilder()." + in + "(" + token + ").build();\n"
+			} else {
+				s = s + "            pageReq." + in + " = " + token + ";\n"
+			}
+			return s
+		},
 		"interfaceHttp": func(interfaceName string) []*sadl.HttpDef {
 			return gen.Model.Http
 		},
 		"handlerBody": func(hact *sadl.HttpDef) string {
 			var b bytes.Buffer
 			writer := bufio.NewWriter(&b)
-			pq := strings.Split(hact.Path, "?")
+			pq := strings.Split(gen.Model.HttpBase(hact)+hact.Path, "?")
 			path := pq[0]
+			for _, in := range hact.Inputs {
+				if in.Greedy {
+					path = strings.Replace(path, "{"+in.Name+"+}", "{"+in.Name+"}", 1)
+				}
+			}
 			writer.WriteString("        WebTarget target = client.target(config.getTarget()).path(\"" + path + "\")")
 			for _, in := range hact.Inputs {
 				src := "req.get" + gen.Capitalize(in.Name) + "()"
 				if in.Path {
-					writer.WriteString("\n            .resolveTemplate(\"" + in.Name + "\", " + src + ", true)")
+					//the slashes of a greedy parameter are part of the path
+					writer.WriteString("\n            .resolveTemplate(\"" + in.Name + "\", " + src + fmt.Sprintf(", %v)", !in.Greedy))
+				} else if in.Query != "" && gen.Model.BaseType(in.Type) == "Array" {
+					writer.WriteString("\n            .queryParam(\"" + in.Query + "\", " + fmt.Sprintf("queryValues(%s, %v))", src, in.Csv))
 				} else if in.Query != "" {
 					writer.WriteString("\n            .queryParam(\"" + in.Query + "\", " + src + ")")
 				}
 			}
-			writer.WriteString(";\n        Invocation.Builder inv = target.request(MediaType.APPLICATION_JSON)")
+			accept := "MediaType.APPLICATION_JSON"
+			if mt := gen.bodyMediaType(hact.Expected.Outputs); mt != "" {
+				accept = fmt.Sprintf("%q", mt)
+			}
+			writer.WriteString(";\n        Invocation.Builder inv = target.request(" + accept + ")")
 			for _, in := range hact.Inputs {
 				if in.Header != "" {
 					src := "req.get" + gen.Capitalize(in.Name) + "()"
+					if gen.Model.BaseType(in.Type) == "Array" {
+						src = "csv(" + src + ")"
+					}
 					writer.WriteString("\n            .header(\"" + in.Header + "\", " + src + ")")
 				}
 			}
+			writer.WriteString(";\n")
+			for _, in := range hact.Inputs {
+				if in.Cookie != "" {
+					src := "req.get" + gen.Capitalize(in.Name) + "()"
+					cookie := "inv.cookie(\"" + in.Cookie + "\", String.valueOf(" + src + "));\n"
+					if tn, _, _ := gen.TypeName(nil, in.Type, in.Required); tn == strings.ToLower(tn) {
+						//a primitive type
+						writer.WriteString("        " + cookie)
+					} else {
+						writer.WriteString("        if (" + src + " != null) {\n            " + cookie + "        }\n")
+					}
+				}
+			}
 			switch hact.Method {
 			case "PUT", "POST":
 				ename, _ := gen.ActionInfo(hact)
 				src := "Entity.entity(req.get" + gen.Capitalize(ename) + "(), MediaType.APPLICATION_JSON)"
-				writer.WriteString(";\n        Response response = inv." + strings.ToLower(hact.Method) + "(" + src + ");\n")
+				if in := bodyInput(hact); in != nil && sadl.IsFormMediaType(in.MediaType) {
+					src = gen.formRequestEntity(writer, in)
+				} else if in != nil && in.MediaType != "" {
+					src = "Entity.entity(req.get" + gen.Capitalize(ename) + "(), \"" + in.MediaType + "\")"
+				}
+				writer.WriteString("        Response response = inv." + strings.ToLower(hact.Method) + "(" + src + ");\n")
 			case "GET", "DELETE":
-				writer.WriteString(";\n        Response response = inv." + strings.ToLower(hact.Method) + "();\n")
+				writer.WriteString("        Response response = inv." + strings.ToLower(hact.Method) + "();\n")
 			default:
 				panic("fix me: method = " + hact.Method)
 			}
@@ -130,31 +268,58 @@ func (gen *Generator) CreateClientDataAndFuncMap(src, rez string) {
 				if etype != "void" {
 					writer.WriteString("            " + etype + " " + ename + " = response.readEntity(" + etype + ".class);\n")
 				}
-				for _, out := range hact.Expected.Outputs {
-					if out.Header != "" {
-						tn, _, _ := gen.TypeName(nil, out.Type, true)
-						osrc := fromString(tn, "response.getHeaderString(\""+out.Header+"\")")
-						writer.WriteString("            " + tn + " " + out.Name + " = " + osrc + ";\n")
-					}
-				}
+				responseHeaders(writer, hact.Expected.Outputs, "            ")
 				responseType := gen.ResponseType(hact.Name)
 				writer.WriteString("            return " + responseType + ".builder()\n")
 				if etype != "void" {
 					writer.WriteString("                ." + ename + "(" + ename + ")\n")
 				}
 				for _, out := range hact.Expected.Outputs {
-					if out.Header != "" {
+					if out.Header != "" || out.Cookie != "" {
 						writer.WriteString("                ." + out.Name + "(" + out.Name + ")\n")
 					}
 				}
 				writer.WriteString("                .build();\n")
 			}
-			for _, exc := range hact.Exceptions {
-				writer.WriteString("        case " + fmt.Sprint(exc.Status) + ":\n")
-				writer.WriteString("            throw response.readEntity(" + exc.Type + ".class);\n")
+			hasDefault := false
+			for _, exc := range gen.Model.HttpExceptions(hact) {
+				if exc.Status == 0 {
+					hasDefault = true
+					writer.WriteString("        default:\n")
+				} else {
+					writer.WriteString("        case " + fmt.Sprint(exc.Status) + ":\n")
+				}
+				if len(exc.Outputs) == 0 {
+					writer.WriteString("            throw response.readEntity(" + exc.Type + ".class);\n")
+					continue
+				}
+				//a block, so that the names of the outputs do not clash with those of other cases
+				writer.WriteString("            {\n")
+				for _, out := range exc.Outputs {
+					if out.Header == "" && out.Cookie == "" {
+						tn, _, _ := gen.TypeName(nil, out.Type, true)
+						writer.WriteString("                " + tn + " " + out.Name + " = response.readEntity(" + tn + ".class);\n")
+					}
+				}
+				responseHeaders(writer, exc.Outputs, "                ")
+				ex := gen.ExceptionType(hact, exc)
+				if gen.UseImmutable {
+					writer.WriteString("                throw " + ex + ".builder()")
+				} else {
+					writer.WriteString("                throw new " + ex + "()")
+				}
+				for _, out := range exc.Outputs {
+					writer.WriteString("." + out.Name + "(" + out.Name + ")")
+				}
+				if gen.UseImmutable {
+					writer.WriteString(".build()")
+				}
+				writer.WriteString(";\n            }\n")
+			}
+			if !hasDefault {
+				writer.WriteString("        default:\n")
+				writer.WriteString("            throw new RuntimeException(\"Unexpected service response status: \" + response.getStatus());\n")
 			}
-			writer.WriteString("        default:\n")
-			writer.WriteString("            throw new RuntimeException(\"Unexpected service response status: \" + response.getStatus());\n")
 			writer.WriteString("        }\n")
 			writer.Flush()
 			return b.String()
@@ -163,6 +328,51 @@ func (gen *Generator) CreateClientDataAndFuncMap(src, rez string) {
 	gen.ClientData.Funcs = funcMap
 }
 
+// formRequestEntity writes the statements that encode a Struct input as a form, and returns the entity to send.
+func (gen *Generator) formRequestEntity(writer *bufio.Writer, in *sadl.HttpParamSpec) string {
+	body := "req.get" + gen.Capitalize(in.Name) + "()"
+	multi := in.MediaType == "multipart/form-data"
+	if multi {
+		writer.WriteString("        FormDataMultiPart form = new FormDataMultiPart();\n")
+	} else {
+		writer.WriteString("        Form form = new Form();\n")
+	}
+	writer.WriteString("        if (" + body + " != null) {\n")
+	for _, fd := range gen.Model.FormFields(in) {
+		src := body + ".get" + gen.Capitalize(fd.Name) + "()"
+		key := "\"" + fd.WireName() + "\""
+		part := "form.param(" + key + ", String.valueOf(" + src + "));\n"
+		if multi {
+			part = "form.field(" + key + ", String.valueOf(" + src + "));\n"
+		}
+		switch gen.Model.BaseType(fd.Type) {
+		case "Bytes":
+			part = "form.bodyPart(new FormDataBodyPart(" + key + ", " + src + ", MediaType.APPLICATION_OCTET_STREAM_TYPE));\n"
+		case "Array":
+			item := "form.param(" + key + ", String.valueOf(item));\n"
+			if multi {
+				item = "form.field(" + key + ", String.valueOf(item));\n"
+			}
+			writer.WriteString("            if (" + src + " != null) {\n")
+			writer.WriteString("                for (Object item : " + src + ") {\n")
+			writer.WriteString("                    " + item)
+			writer.WriteString("                }\n            }\n")
+			continue
+		}
+		if tn, _, _ := gen.TypeName(nil, fd.Type, fd.Required); tn == strings.ToLower(tn) && tn != "byte[]" {
+			//a primitive type
+			writer.WriteString("            " + part)
+		} else {
+			writer.WriteString("            if (" + src + " != null) {\n                " + part + "            }\n")
+		}
+	}
+	writer.WriteString("        }\n")
+	if multi {
+		return "Entity.entity(form, form.getMediaType())"
+	}
+	return "Entity.form(form)"
+}
+
 func (gen *Generator) CreateClientConfig() {
 	if gen.Err != nil {
 		return
@@ -189,12 +399,13 @@ import javax.ws.rs.client.ClientBuilder;
 import javax.ws.rs.client.Entity;
 import javax.ws.rs.client.WebTarget;
 import javax.ws.rs.client.Invocation;
+import javax.ws.rs.core.Form;
 import javax.ws.rs.core.MediaType;
 import javax.ws.rs.core.Response;
 import javax.ws.rs.WebApplicationException;
-
+{{multipartImports}}
 public class {{.Name}} implements {{.InterfaceClass}} {
-    private static Client client = ClientBuilder.newClient();
+    private static Client client = {{newClient}};
     private static final String base = "{{.RootPath}}";
     private ClientConfig config;
 
@@ -204,6 +415,42 @@ public class {{.Name}} implements {{.InterfaceClass}} {
 {{range .Model.Http}}
     {{handlerSig .}} {{openBrace}}
 {{handlerBody .}}    }
-{{end}}
+{{if .Paginated}}
+    /**
+     * Calls {{methodName .}} for each page of its results, until fn returns false or there are no more pages.
+     */
+    {{pagesSig .}} {
+{{pageRequest .}}        while (true) {
+            {{pageCall .}}
+            if (!fn.test(res)) {
+                return;
+            }
+{{nextPage .}}        }
+    }
+{{end}}{{end}}{{$client := .Name}}{{range resources}}
+    public {{.Name}} {{.Accessor}}() {
+        return new {{.Name}}() {{openBrace}}{{range .Ops}}
+            public {{lifecycleSig .}} {
+                return {{$client}}.this.{{actionName .}}(req);
+            }
+{{end}}        };
+    }
+{{end}}{{paramHelpers}}
 }
 `
+
+const arrayParamMethods = `
+    private static Object[] queryValues(java.util.List<?> items, boolean csv) {
+        if (items == null || items.isEmpty()) {
+            return new Object[0];
+        }
+        return csv ? new Object[]{csv(items)} : items.toArray();
+    }
+
+    private static String csv(java.util.List<?> items) {
+        if (items == null) {
+            return null;
+        }
+        return items.stream().map(String::valueOf).collect(java.util.stream.Collectors.joining(","));
+    }
+`
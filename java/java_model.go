@@ -1,6 +1,8 @@
 package java
 
 import (
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/boynton/sadl"
@@ -16,6 +18,7 @@ func (gen *Generator) CreateModel() {
 		gen.CreatePojoFromDef(td, exceptions)
 	}
 	gen.CreateInterface()
+	gen.CreateConstants()
 	if gen.NeedTimestamp {
 		gen.CreateTimestamp()
 	} else if gen.NeedInstant {
@@ -32,7 +35,7 @@ func (gen *Generator) CreateModel() {
 
 func (gen *Generator) entityNameType(hact *sadl.HttpDef) (string, string) {
 	for _, out := range hact.Expected.Outputs {
-		if out.Header == "" {
+		if out.Header == "" && out.Cookie == "" {
 			tn, _, _ := gen.TypeName(nil, out.Type, true)
 			return out.Name, tn
 		}
@@ -54,11 +57,41 @@ func (gen *Generator) CreateInterface() {
 			reqType := gen.RequestType(name)
 			return "public " + resType + " " + name + "(" + reqType + " req)"
 		},
+		"lifecycleSig": func(op *resourceOp) string {
+			name := gen.ActionName(op.Http)
+			return gen.ResponseType(name) + " " + op.Method + "(" + gen.RequestType(name) + " req)"
+		},
+	}
+	//the interface of the model extends those of its services, if it declares any
+	model := &interfaceData{Name: gen.Name, Http: gen.Model.Http}
+	if len(gen.Model.Services) > 0 {
+		model.Http = nil
+		var extends []string
+		for _, sd := range gen.Model.Services {
+			name := gen.Capitalize(sd.Name)
+			if name == gen.Name {
+				model.Http = gen.Model.ServiceHttp(sd)
+				continue
+			}
+			extends = append(extends, name)
+			gen.CreateJavaFileFromTemplate(name, interfaceTemplate, &interfaceData{Name: name, Http: gen.Model.ServiceHttp(sd)}, funcMap, gen.ModelPackage)
+		}
+		model.Extends = strings.Join(extends, ", ")
+	}
+	gen.CreateJavaFileFromTemplate(gen.Name, interfaceTemplate, model, funcMap, gen.ModelPackage)
+	for _, rd := range gen.Model.Resources {
+		res := gen.resourceData(rd)
+		gen.CreateJavaFileFromTemplate(res.Name, resourceTemplate, res, funcMap, gen.ModelPackage)
+		gen.CreateJavaFileFromTemplate(res.Name+"Actions", resourceActionsTemplate, res, funcMap, gen.ModelPackage)
 	}
-	gen.CreateJavaFileFromTemplate(gen.Name, interfaceTemplate, gen, funcMap, gen.ModelPackage)
 	for _, hact := range gen.Model.Http {
 		gen.CreateRequestPojo(hact)
 		gen.CreateResponsePojo(hact)
+		for _, exc := range gen.Model.HttpExceptions(hact) {
+			if len(exc.Outputs) > 0 {
+				gen.CreateExceptionPojo(hact, exc)
+			}
+		}
 		_, etype := gen.entityNameType(hact)
 		if etype == "String" {
 			gen.NeedUtil = true
@@ -66,14 +99,131 @@ func (gen *Generator) CreateInterface() {
 	}
 }
 
+func (gen *Generator) CreateConstants() {
+	if gen.Err != nil || len(gen.Model.Constants) == 0 {
+		return
+	}
+	gen.imports = nil
+	gen.Begin()
+	gen.Emit("public final class Constants {\n")
+	for _, cd := range gen.Model.Constants {
+		if cd.Comment != "" {
+			gen.Emit(gen.FormatComment("    ", cd.Comment, 100, false))
+		}
+		tn, isPrimitive := primitiveType(gen.Model.BaseType(cd.Type))
+		if !isPrimitive {
+			tn, _, _ = gen.TypeName(nil, cd.Type, false)
+		}
+		gen.Emit("    public static final " + tn + " " + cd.Name + " = " + gen.constantValue(cd, tn) + ";\n")
+	}
+	gen.Emit("}\n")
+	result := gen.End()
+	if len(gen.imports) > 0 {
+		gen.Begin()
+		sort.Strings(gen.imports)
+		for _, pack := range gen.imports {
+			gen.Emit("import " + pack + ";\n")
+		}
+		gen.Emit("\n")
+		result = gen.End() + result
+	}
+	gen.WriteJavaFile("Constants", result, gen.ModelPackage)
+}
+
+func (gen *Generator) constantValue(cd *sadl.ConstantDef, tn string) string {
+	lit := sadl.ToString(cd.Value)
+	switch gen.Model.BaseType(cd.Type) {
+	case "Int64":
+		return lit + "L"
+	case "Float32":
+		return lit + "f"
+	case "Decimal":
+		return "new BigDecimal(\"" + lit + "\")"
+	case "UUID":
+		return "UUID.fromString(" + lit + ")"
+	case "Timestamp":
+		if tn == "Instant" {
+			return "Instant.parse(" + lit + ")"
+		}
+		return "new Timestamp(" + lit + ")"
+	case "Enum":
+		if s := sadl.AsString(cd.Value); s != "" {
+			return tn + "." + s
+		}
+	}
+	return lit
+}
+
+type interfaceData struct {
+	Name    string
+	Extends string
+	Http    []*sadl.HttpDef
+}
+
 const interfaceTemplate = `
-public interface {{.Name}} {
-{{range .Model.Http}}
+public interface {{.Name}}{{if .Extends}} extends {{.Extends}}{{end}} {
+{{range .Http}}
     {{handlerSig .}};
 {{end}}
 }
 `
 
+// resourceOp is an http action bound to a lifecycle operation of a resource, which is a method of its interface.
+type resourceOp struct {
+	Method string
+	Http   *sadl.HttpDef
+}
+
+func (gen *Generator) resourceOps(rd *sadl.ResourceDef) []*resourceOp {
+	var ops []*resourceOp
+	for _, op := range sadl.ResourceLifecycle {
+		if hd := gen.Model.FindHttp(rd.LifecycleAction(op)); hd != nil {
+			ops = append(ops, &resourceOp{Method: op, Http: hd})
+		}
+	}
+	return ops
+}
+
+type resourceData struct {
+	Name         string
+	Accessor     string
+	Resource     string
+	Model        string
+	ModelPackage string
+	Ops          []*resourceOp
+}
+
+func (gen *Generator) resourceData(rd *sadl.ResourceDef) *resourceData {
+	name := gen.Capitalize(rd.Name) + "Resource"
+	return &resourceData{Name: name, Accessor: gen.Uncapitalize(name), Resource: rd.Name, Model: gen.Name, ModelPackage: gen.ModelPackage, Ops: gen.resourceOps(rd)}
+}
+
+const resourceTemplate = `
+/**
+ * The lifecycle of the {{.Resource}} resource.
+ */
+public interface {{.Name}} {
+{{range .Ops}}
+    public {{lifecycleSig .}};
+{{end}}
+}
+`
+
+const resourceActionsTemplate = `
+/**
+ * Implements the http actions of the {{.Resource}} resource with its lifecycle.
+ */
+public interface {{.Name}}Actions extends {{.Model}} {
+
+    public {{.Name}} {{.Accessor}}();
+{{$accessor := .Accessor}}{{range .Ops}}
+    default {{handlerSig .Http}} {
+        return {{$accessor}}().{{.Method}}(req);
+    }
+{{end}}
+}
+`
+
 const exceptionTemplate = `
 import com.fasterxml.jackson.databind.annotation.JsonSerialize;
 import com.fasterxml.jackson.databind.annotation.JsonDeserialize;
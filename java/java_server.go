@@ -21,6 +21,7 @@ type ServerData struct {
 	ResourcesClass string
 	ImplClass      string
 	InterfaceClass string
+	AuthClass      string
 	RootPath       string
 	Http           []*sadl.HttpDef
 	Inputs         []*sadl.HttpParamSpec
@@ -31,6 +32,7 @@ type ServerData struct {
 	Funcs          template.FuncMap
 	Interfaces     map[string][]string
 	ExtraResources string
+	Resources      []*resourceData
 }
 
 //type ScopedHttpDef struct {
@@ -46,16 +48,25 @@ func (gen *Generator) CreateServer() {
 	}
 	gen.CreateServerDataAndFuncMap(src, rez)
 	gen.CreateJavaFileFromTemplate(gen.ServerData.ResourcesClass, resourcesTemplate, gen.ServerData, gen.ServerData.Funcs, gen.ServerPackage)
+	if len(gen.Model.Auth) > 0 {
+		gen.CreateJavaFileFromTemplate("Authenticator", authenticatorTemplate, gen.ServerData, gen.ServerData.Funcs, gen.ServerPackage)
+	}
 	if gen.ServerImpl {
 		gen.CreateJavaFileFromTemplate(gen.ServerData.MainClass, mainTemplate, gen.ServerData, gen.ServerData.Funcs, "")
 		gen.CreateJavaFileFromTemplate(gen.ServerData.ImplClass, implTemplate, gen.ServerData, gen.ServerData.Funcs, "")
+		for _, res := range gen.ServerData.Resources {
+			gen.CreateJavaFileFromTemplate(res.Name+"Controller", resourceImplTemplate, res, gen.ServerData.Funcs, "")
+		}
+		if len(gen.Model.Auth) > 0 {
+			gen.CreateJavaFileFromTemplate(gen.ServerData.AuthClass, authImplTemplate, gen.ServerData, gen.ServerData.Funcs, "")
+		}
 	}
 }
 
 func (gen *Generator) ExceptionTypes() map[string]string {
 	exceptions := make(map[string]string, 0)
 	for _, hact := range gen.Model.Http {
-		for _, resp := range hact.Exceptions {
+		for _, resp := range gen.Model.HttpExceptions(hact) {
 			tn, _, _ := gen.TypeName(nil, resp.Type, true)
 			exceptions[tn] = resp.Type
 		}
@@ -63,9 +74,9 @@ func (gen *Generator) ExceptionTypes() map[string]string {
 	return exceptions
 }
 
-func firstTag(annos map[string]string) string {
-	if csv, ok := annos["x_tags"]; ok {
-		return strings.Split(csv, ",")[0]
+func firstTag(annos map[string]interface{}) string {
+	if tags := sadl.GetAnnotationStrings(annos, "x_tags"); len(tags) > 0 {
+		return tags[0]
 	}
 	return ""
 }
@@ -98,9 +109,19 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 
 	gen.ServerData.InterfaceClass = serviceName
 	gen.ServerData.ImplClass = serviceName + "Controller" //add version here?
+	gen.ServerData.AuthClass = serviceName + "Authenticator"
+	//the actions bound to a resource lifecycle are implemented by its controller, not the service controller
+	resourceActions := make(map[*sadl.HttpDef]bool, 0)
+	for _, rd := range gen.Model.Resources {
+		res := gen.resourceData(rd)
+		for _, op := range res.Ops {
+			resourceActions[op.Http] = true
+		}
+		gen.ServerData.Resources = append(gen.ServerData.Resources, res)
+	}
 	entityNameType := func(hact *sadl.HttpDef) (string, string) {
 		for _, out := range hact.Expected.Outputs {
-			if out.Header == "" {
+			if out.Header == "" && out.Cookie == "" {
 				tn, _, _ := gen.TypeName(nil, out.Type, true)
 				return out.Name, tn
 			}
@@ -117,14 +138,23 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 		return base + "Controller"
 	}
 
+	//an Array header, or a csv query parameter, is bound to its raw values, which are then split and parsed
+	splitParam := func(in *sadl.HttpParamSpec) bool {
+		return gen.Model.BaseType(in.Type) == "Array" && (in.Csv || in.Header != "")
+	}
 	funcMap := template.FuncMap{
 		"openBrace": func() string { return "{" },
 		"methodPath": func(hact *sadl.HttpDef) string {
-			path := hact.Path
+			path := gen.Model.HttpBase(hact) + hact.Path
 			i := strings.Index(path, "?")
 			if i >= 0 {
 				path = path[0:i]
 			}
+			for _, in := range hact.Inputs {
+				if in.Greedy {
+					path = strings.Replace(path, "{"+in.Name+"+}", "{"+in.Name+": .*}", 1)
+				}
+			}
 			return path
 		},
 		"implTypeName": implTypeName,
@@ -166,6 +196,30 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 			}
 			return "config.register(Util.InstantConverterProvider.class);\n        "
 		},
+		"multipartFeature": func() string {
+			if !gen.usesMultipart() {
+				return ""
+			}
+			return "config.register(MultiPartFeature.class);\n        "
+		},
+		"multipartImport": func(class string) string {
+			if !gen.usesMultipart() {
+				return ""
+			}
+			return "import org.glassfish.jersey.media.multipart." + class + ";\n"
+		},
+		"mediaTypes": func(hact *sadl.HttpDef) string {
+			s := ""
+			if in := bodyInput(hact); in != nil && in.MediaType != "" {
+				s = fmt.Sprintf("@Consumes(%q)\n    ", in.MediaType)
+			}
+			for _, out := range hact.Expected.Outputs {
+				if out.Header == "" && out.Cookie == "" && out.MediaType != "" {
+					return s + fmt.Sprintf("@Produces(%q)", out.MediaType)
+				}
+			}
+			return s + "@Produces(MediaType.APPLICATION_JSON)"
+		},
 		"outname": func(hact *sadl.HttpDef) string {
 			n, _ := gen.ActionInfo(hact)
 			return n
@@ -187,16 +241,32 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 			resType := gen.ResponseType(gen.ActionName(hact))
 			return "public " + resType + " " + name + "(" + reqType(name) + " req)"
 		},
+		"lifecycleSig": func(op *resourceOp) string {
+			name := gen.ActionName(op.Http)
+			return gen.ResponseType(name) + " " + op.Method + "(" + reqType(name) + " req)"
+		},
+		"resourceAction": func(hact *sadl.HttpDef) bool {
+			return resourceActions[hact]
+		},
 		"resourceSig": func(hact *sadl.HttpDef) string {
 			name := gen.ActionName(hact) //i.e. "getFoo"
 			var params []string
 			for _, in := range hact.Inputs {
-				tn, _, _ := gen.TypeName(nil, in.Type, false)
+				if sadl.IsFormMediaType(in.MediaType) {
+					params = append(params, gen.formParams(in)...)
+					continue
+				}
+				tn, _, _ := gen.TypeName(&in.TypeSpec, in.Type, false)
+				if splitParam(in) {
+					tn = "List<String>"
+				}
 				param := tn + " " + in.Name
 				if in.Query != "" {
 					param = `@QueryParam("` + in.Query + `") ` + param
 				} else if in.Header != "" {
 					param = `@HeaderParam("` + in.Header + `") ` + param
+				} else if in.Cookie != "" {
+					param = `@CookieParam("` + in.Cookie + `") ` + param
 				} else if in.Path {
 					param = `@PathParam("` + in.Name + `") ` + param
 				}
@@ -226,18 +296,35 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 			resname := gen.ResponseType(gen.ActionName(hact))
 			var params []string
 			for _, in := range hact.Inputs {
-				params = append(params, in.Name)
+				if splitParam(in) {
+					params = append(params, in.Name+"(splitParam("+in.Name+", "+gen.paramParser(gen.arrayItemType(&in.TypeSpec))+"))")
+				} else if sadl.IsFormMediaType(in.MediaType) {
+					params = append(params, in.Name+"("+gen.formEntity(in)+")")
+				} else {
+					params = append(params, in.Name+"("+in.Name+")")
+				}
 			}
 			ename, etype := entityNameType(hact)
 			var b bytes.Buffer
 			writer := bufio.NewWriter(&b)
+			if schemes := gen.Model.HttpAuth(hact); len(schemes) > 0 {
+				var names []string
+				for _, ad := range schemes {
+					names = append(names, fmt.Sprintf("%q", ad.Name))
+				}
+				scopes := "Collections.emptyList()"
+				if len(hact.Scopes) > 0 {
+					scopes = fmt.Sprintf("Arrays.asList(\"%s\")", strings.Join(hact.Scopes, "\", \""))
+				}
+				writer.WriteString("        authenticate(Arrays.asList(" + strings.Join(names, ", ") + "), " + scopes + ");\n")
+			}
 			if gen.UseImmutable {
 				writer.WriteString("        " + reqname + " req = " + reqname + ".builder()")
 			} else {
 				writer.WriteString("        " + reqname + " req = new " + reqname + "()")
 			}
 			for _, p := range params {
-				writer.WriteString("." + p + "(" + p + ")")
+				writer.WriteString("." + p)
 			}
 			if gen.UseImmutable {
 				writer.WriteString(".build()")
@@ -247,7 +334,13 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 			if len(hact.Expected.Outputs) > 0 {
 				writer.WriteString("            " + resname + " res = " + implName(iname) + "." + name + "(req);\n")
 				wrappedResult := ""
-				if ename != "void" && ename != "" {
+				if ename != "void" && ename != "" && !sadl.IsJsonMediaType(gen.bodyMediaType(hact.Expected.Outputs)) {
+					if gen.UseImmutable {
+						wrappedResult = "res.get" + gen.Capitalize(ename) + "()"
+					} else {
+						wrappedResult = "res." + ename
+					}
+				} else if ename != "void" && ename != "" {
 					if gen.UseImmutable {
 						wrappedResult = gen.jsonWrapper(etype, "res.get"+gen.Capitalize(ename)+"()")
 					} else {
@@ -258,6 +351,8 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 				for _, out := range hact.Expected.Outputs {
 					if out.Header != "" {
 						ret = ret + ".header(\"" + out.Header + "\", res.get" + gen.Capitalize(out.Name) + "())"
+					} else if out.Cookie != "" {
+						ret = ret + ".cookie(new NewCookie(\"" + out.Cookie + "\", String.valueOf(res.get" + gen.Capitalize(out.Name) + "())))"
 					}
 				}
 				if wrappedResult != "" {
@@ -276,8 +371,11 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 				first := true
 				any := false
 				anyNull := false
-				for _, resp := range hact.Exceptions {
+				for _, resp := range gen.Model.HttpExceptions(hact) {
 					tn, _, _ := gen.TypeName(nil, resp.Type, true)
+					if len(resp.Outputs) > 0 {
+						tn = gen.ExceptionType(hact, resp)
+					}
 					if tn != "ServiceException" {
 						any = true
 						if first {
@@ -286,7 +384,11 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 						} else {
 							writer.WriteString("            } else if (entity instanceof " + tn + ") {\n")
 						}
-						writer.WriteString(fmt.Sprintf("                status = %d;\n", resp.Status))
+						if len(resp.Outputs) > 0 {
+							writer.WriteString("                throw new WebApplicationException(" + gen.exceptionResponse(resp, "(("+tn+") entity)") + ");\n")
+							continue
+						}
+						writer.WriteString(fmt.Sprintf("                status = %d;\n", resp.ResponseStatus()))
 						if resp.Status == 204 || resp.Status == 304 {
 							writer.WriteString("                entity = null;\n")
 							anyNull = true
@@ -303,9 +405,14 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 				}
 				writer.WriteString("            throw new WebApplicationException(Response.status(status).entity(entity).build());\n")
 			} else {
-				for _, resp := range hact.Exceptions {
-					status := fmt.Sprint(resp.Status)
+				for _, resp := range gen.Model.HttpExceptions(hact) {
+					status := fmt.Sprint(resp.ResponseStatus())
 					tn, _, _ := gen.TypeName(nil, resp.Type, true)
+					if len(resp.Outputs) > 0 {
+						writer.WriteString("        } catch (" + gen.ExceptionType(hact, resp) + " e) {\n")
+						writer.WriteString("                throw new WebApplicationException(" + gen.exceptionResponse(resp, "e") + ");\n")
+						continue
+					}
 					writer.WriteString("        } catch (" + tn + " e) {\n")
 					writer.WriteString("                throw new WebApplicationException(Response.status(" + status + ").entity(e).build());\n")
 				}
@@ -317,6 +424,16 @@ func (gen *Generator) CreateServerDataAndFuncMap(src, rez string) {
 			return b.String()
 		},
 		"extraResources": func() string { return gen.ServerData.ExtraResources },
+		"paramHelpers": func() string {
+			for _, hact := range gen.Model.Http {
+				for _, in := range hact.Inputs {
+					if splitParam(in) {
+						return splitParamMethod
+					}
+				}
+			}
+			return ""
+		},
 	}
 	if gen.ServerPackage != "" {
 		gen.ServerData.PackageLine = "package " + gen.ServerPackage + ";\n"
@@ -331,7 +448,7 @@ import org.glassfish.jersey.server.ResourceConfig;
 import org.glassfish.jersey.logging.LoggingFeature;
 import org.glassfish.hk2.utilities.binding.AbstractBinder;
 import org.glassfish.jersey.jackson.JacksonFeature;
-import javax.ws.rs.core.UriBuilder;
+{{multipartImport "MultiPartFeature"}}import javax.ws.rs.core.UriBuilder;
 import java.io.IOException;
 import java.net.URI;
 import java.util.logging.Logger;
@@ -355,10 +472,11 @@ public class {{.MainClass}} {
         ResourceConfig config = new ResourceConfig({{.ResourcesClass}}.class);
         config.register(new LoggingFeature(Logger.getLogger(LoggingFeature.DEFAULT_LOGGER_NAME),
                                            Level.INFO, LoggingFeature.Verbosity.PAYLOAD_ANY, 10000));
-        {{instantProvider}}config.registerInstances(new AbstractBinder() {
+        {{instantProvider}}{{multipartFeature}}config.registerInstances(new AbstractBinder() {
                 @Override
                 protected void configure() {
-                    bind({{.ImplClass}}.class).to({{.InterfaceClass}}.class);
+                    bind({{.ImplClass}}.class).to({{.InterfaceClass}}.class);{{if .Model.Auth}}
+                    bind({{.AuthClass}}.class).to(Authenticator.class);{{end}}
                 }
             });
         Server server = JettyHttpContainerFactory.createServer(baseUri, config);
@@ -383,32 +501,152 @@ import com.fasterxml.jackson.annotation.JsonInclude.Include;
 import com.fasterxml.jackson.databind.ObjectMapper;
 import com.fasterxml.jackson.databind.SerializationFeature;
 import com.fasterxml.jackson.databind.DeserializationFeature;
-
+{{multipartImport "FormDataParam"}}
 @Path("{{.RootPath}}")
 public class {{.ResourcesClass}} {
     @Inject
     private {{.InterfaceClass}} {{implName .Name}};
-{{range .Model.Http}}
+{{if .Model.Auth}}
+    @Inject
+    private Authenticator authenticator;
+    @Context
+    private HttpHeaders headers;
+    @Context
+    private UriInfo uriInfo;
+    @Context
+    private SecurityContext securityContext;
+
+    // the request must have the credentials of any one of the schemes
+    private void authenticate(List<String> schemes, List<String> scopes) {
+        RuntimeException failure = new NotAuthorizedException(Response.status(Status.UNAUTHORIZED).build());
+        for (String scheme : schemes) {
+            try {
+                authenticator.authenticate(scheme, scopes, headers, uriInfo, securityContext);
+                return;
+            } catch (RuntimeException e) {
+                failure = e;
+            }
+        }
+        throw failure;
+    }
+{{end}}{{range .Model.Http}}
     
     @{{.Method}}
     @Path("{{methodPath .}}")
-    @Produces(MediaType.APPLICATION_JSON)
+    {{mediaTypes .}}
     {{resourceSig .}} {{openBrace}}
 {{resourceBody .}}    }
 {{end}}
-{{extraResources}}
+{{extraResources}}{{paramHelpers}}
 }
 `
 
+const authenticatorTemplate = `
+import java.util.List;
+import javax.ws.rs.core.HttpHeaders;
+import javax.ws.rs.core.SecurityContext;
+import javax.ws.rs.core.UriInfo;
+
+// Checks the credentials of a request for one of the auth schemes of the service, as named in the model, and the
+// scopes that the action requires. A failure throws a NotAuthorizedException, or a ForbiddenException.
+public interface Authenticator {
+    void authenticate(String scheme, List<String> scopes, HttpHeaders headers, UriInfo uriInfo, SecurityContext securityContext);
+}
+`
+
+const authImplTemplate = `
+import java.util.List;
+import javax.ws.rs.NotAuthorizedException;
+import javax.ws.rs.core.HttpHeaders;
+import javax.ws.rs.core.Response;
+import javax.ws.rs.core.SecurityContext;
+import javax.ws.rs.core.UriInfo;
+{{if .ServerPackage}}import {{.ServerPackage}}.*;{{end}}
+
+// A placeholder authenticator for the service, which accepts nothing
+public class {{.AuthClass}} implements Authenticator {
+    public void authenticate(String scheme, List<String> scopes, HttpHeaders headers, UriInfo uriInfo, SecurityContext securityContext) {
+        //implement me!
+        throw new NotAuthorizedException(Response.status(Response.Status.UNAUTHORIZED).build());
+    }
+}
+`
+
+const splitParamMethod = `
+    private static <T> List<T> splitParam(List<String> values, java.util.function.Function<String, T> parse) {
+        List<T> items = new ArrayList<T>();
+        for (String value : values) {
+            for (String item : value.split(",")) {
+                item = item.trim();
+                if (!item.isEmpty()) {
+                    try {
+                        items.add(parse.apply(item));
+                    } catch (RuntimeException e) {
+                        throw new BadRequestException("Bad parameter value: " + item);
+                    }
+                }
+            }
+        }
+        return items;
+    }
+`
+
+// arrayItemType returns the Java type of the items of an Array type spec, which may also name an Array type.
+func (gen *Generator) arrayItemType(ts *sadl.TypeSpec) string {
+	items := ts.Items
+	if td := gen.Model.FindType(ts.Type); items == "" && td != nil {
+		items = td.Items
+	}
+	itd := gen.Model.FindType(items)
+	tn, _, _ := gen.TypeName(&itd.TypeSpec, items, false)
+	return tn
+}
+
+// paramParser returns a Java function that parses a parameter value of the given type.
+func (gen *Generator) paramParser(tn string) string {
+	switch tn {
+	case "String":
+		return "s -> s"
+	case "Instant":
+		return "Instant::parse"
+	case "Timestamp", "BigDecimal":
+		return tn + "::new"
+	case "Byte", "Short", "Integer", "Long", "Float", "Double", "Boolean":
+		return tn + "::valueOf"
+	default:
+		//UUID, and the enum types, which have a fromString method
+		return tn + "::fromString"
+	}
+}
+
 const implTemplate = `
 // Stubs for an implementation of the service follow
 {{if .ModelPackage}}import {{.ModelPackage}}.*;{{end}}
 
-public class {{.ImplClass}} implements {{.InterfaceClass}} {
-{{range .Model.Http}}
+public class {{.ImplClass}} implements {{.InterfaceClass}}{{range .Resources}}, {{.Name}}Actions{{end}} {
+{{range .Resources}}
+    private final {{.Name}} {{.Accessor}} = new {{.Name}}Controller();
+
+    public {{.Name}} {{.Accessor}}() {{openBrace}}
+        return {{.Accessor}};
+    }
+{{end}}{{range .Model.Http}}{{if not (resourceAction .)}}
     {{handlerSig .}} {{openBrace}}
         return {{resEntity .}}; //implement me!
     }
+{{end}}{{end}}
+}
+`
+
+const resourceImplTemplate = `
+// Stubs for an implementation of the {{.Resource}} resource follow
+{{if .ModelPackage}}import {{.ModelPackage}}.*;{{end}}
+
+public class {{.Name}}Controller implements {{.Name}} {
+{{range .Ops}}
+    public {{lifecycleSig .}} {{openBrace}}
+        return {{resEntity .Http}}; //implement me!
+    }
 {{end}}
 }
 `
@@ -429,7 +667,7 @@ func (gen *Generator) CreateRequestPojo(hact *sadl.HttpDef) {
 
 func (gen *Generator) responsePojoName(hact *sadl.HttpDef) (string, bool) {
 	for _, out := range hact.Expected.Outputs {
-		if out.Header == "" {
+		if out.Header == "" && out.Cookie == "" {
 			tn, _, _ := gen.TypeName(nil, out.Type, true)
 			return tn, false
 		}
@@ -451,17 +689,112 @@ func (gen *Generator) CreateResponsePojo(hact *sadl.HttpDef) {
 	gen.CreatePojo(ts, className, "", nil)
 }
 
+// ExceptionType returns the name of the exception class for an exception with headers or cookies, which holds its
+// body and those values.
+func (gen *Generator) ExceptionType(hact *sadl.HttpDef, exc *sadl.HttpExceptionSpec) string {
+	return gen.Capitalize(gen.ActionName(hact)) + "Except" + exc.Type
+}
+
+func (gen *Generator) CreateExceptionPojo(hact *sadl.HttpDef, exc *sadl.HttpExceptionSpec) {
+	if gen.Err != nil {
+		return
+	}
+	className := gen.ExceptionType(hact, exc)
+	ts := &sadl.TypeSpec{
+		Type: "Struct",
+	}
+	for _, spec := range exc.Outputs {
+		ts.Fields = append(ts.Fields, &spec.StructFieldDef)
+	}
+	var exceptions map[string]string
+	if !gen.Config.GetBool("service-exception") {
+		exceptions = map[string]string{className: exc.Type}
+	}
+	gen.CreatePojo(ts, className, "", exceptions)
+}
+
+// exceptionResponse returns the expression for the response of an exception with headers or cookies.
+func (gen *Generator) exceptionResponse(exc *sadl.HttpExceptionSpec, e string) string {
+	get := func(name string) string {
+		if gen.UseImmutable {
+			return e + ".get" + gen.Capitalize(name) + "()"
+		}
+		return e + "." + name
+	}
+	ret := fmt.Sprintf("Response.status(%d)", exc.ResponseStatus())
+	for _, out := range exc.Outputs {
+		if out.Header != "" {
+			ret = ret + ".header(\"" + out.Header + "\", " + get(out.Name) + ")"
+		} else if out.Cookie != "" {
+			ret = ret + ".cookie(new NewCookie(\"" + out.Cookie + "\", String.valueOf(" + get(out.Name) + ")))"
+		} else if out.MediaType != "" {
+			ret = ret + ".entity(" + get(out.Name) + ").type(\"" + out.MediaType + "\")"
+		} else {
+			ret = ret + ".entity(" + get(out.Name) + ")"
+		}
+	}
+	return ret + ".build()"
+}
+
+// bodyMediaType returns the media type of the output that is the body of a response, which is empty if it is JSON.
+func (gen *Generator) bodyMediaType(outputs []*sadl.HttpParamSpec) string {
+	for _, out := range outputs {
+		if out.Header == "" && out.Cookie == "" {
+			return out.MediaType
+		}
+	}
+	return ""
+}
+
+// formParams returns the resource method parameters for the parts of a form body, one for each field of its Struct.
+func (gen *Generator) formParams(in *sadl.HttpParamSpec) []string {
+	anno := "@FormParam"
+	if in.MediaType == "multipart/form-data" {
+		anno = "@FormDataParam"
+	}
+	var params []string
+	for _, fd := range gen.Model.FormFields(in) {
+		tn, _, _ := gen.TypeName(&fd.TypeSpec, fd.Type, false)
+		param := anno + "(\"" + fd.WireName() + "\") " + tn + " " + in.Name + gen.Capitalize(fd.Name)
+		if fd.Default != nil {
+			def := fmt.Sprint(fd.Default)
+			if str, ok := fd.Default.(*string); ok {
+				def = *str
+			}
+			param = fmt.Sprintf("@DefaultValue(%q) ", def) + param
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// formEntity returns the expression that builds the Struct of a form body from the parameters for its parts.
+func (gen *Generator) formEntity(in *sadl.HttpParamSpec) string {
+	tn, _, _ := gen.TypeName(nil, in.Type, true)
+	s := "new " + tn + "()"
+	if gen.UseImmutable {
+		s = tn + ".builder()"
+	}
+	for _, fd := range gen.Model.FormFields(in) {
+		s = s + "." + fd.Name + "(" + in.Name + gen.Capitalize(fd.Name) + ")"
+	}
+	if gen.UseImmutable {
+		s = s + ".build()"
+	}
+	return s
+}
+
 func (gen *Generator) ActionInfo(hact *sadl.HttpDef) (string, string) {
 	switch hact.Method {
 	case "POST", "PUT":
 		for _, in := range hact.Inputs {
-			if in.Query == "" && in.Header == "" && !in.Path {
+			if in.Query == "" && in.Header == "" && in.Cookie == "" && !in.Path {
 				return in.Name, in.Type
 			}
 		}
 	default:
 		for _, out := range hact.Expected.Outputs {
-			if out.Header == "" {
+			if out.Header == "" && out.Cookie == "" {
 				return out.Name, out.Type
 			}
 		}
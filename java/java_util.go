@@ -1,17 +1,35 @@
 package java
 
+import (
+	"strings"
+)
+
 func (gen *Generator) CreateUtil() {
 	if gen.Err != nil {
 		return
 	}
 	gen.Begin()
-	gen.Emit(javaUtil)
+	if gen.NeedNullable {
+		gen.Emit(strings.Replace(strings.Replace(javaUtil, nullableImportsMarker, nullableImports, 1), nullableModulesMarker, nullableModules, 1))
+	} else {
+		gen.Emit(strings.Replace(strings.Replace(javaUtil, nullableImportsMarker, "", 1), nullableModulesMarker, "", 1))
+	}
 	result := gen.End()
 	if gen.Err == nil {
 		gen.WriteJavaFile("Util", result, gen.ModelPackage)
 	}
 }
 
+const nullableImportsMarker = "//NULLABLE-IMPORTS\n"
+const nullableImports = `import com.fasterxml.jackson.datatype.jdk8.Jdk8Module;
+import org.openapitools.jackson.nullable.JsonNullableModule;
+`
+
+const nullableModulesMarker = "//NULLABLE-MODULES\n"
+const nullableModules = `        om.registerModule(new Jdk8Module());
+        om.registerModule(new JsonNullableModule());
+`
+
 var javaUtil = `
 import com.fasterxml.jackson.annotation.JsonInclude;
 import com.fasterxml.jackson.annotation.JsonInclude.Include;
@@ -33,6 +51,7 @@ import java.lang.reflect.Type;
 import java.time.Instant;
 import java.util.UUID;
 import java.io.IOException;
+//NULLABLE-IMPORTS
 
 public class Util {
 
@@ -41,6 +60,7 @@ public class Util {
         ObjectMapper om = new ObjectMapper();
         om.setDefaultPropertyInclusion(JsonInclude.Value.construct(Include.ALWAYS, Include.NON_NULL));
         om.configure(DeserializationFeature.FAIL_ON_UNKNOWN_PROPERTIES, false);
+//NULLABLE-MODULES
         return om;
     }
 
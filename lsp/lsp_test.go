@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testUri = "file:///tmp/test.sadl"
+
+const testSource = `name test
+
+// A point on the plane
+type Point Struct {
+   x Int32 (min=0)
+   y Int32 (required)
+}
+
+type Line Struct {
+   from Point
+   to Point
+   color Colour
+}
+`
+
+func request(id int, method string, params interface{}) map[string]interface{} {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if id > 0 {
+		msg["id"] = id
+	}
+	return msg
+}
+
+func position(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testUri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+		"context":      map[string]interface{}{"includeDeclaration": false},
+	}
+}
+
+func TestServer(test *testing.T) {
+	var in bytes.Buffer
+	for _, msg := range []map[string]interface{}{
+		request(1, "initialize", map[string]interface{}{}),
+		request(0, "initialized", map[string]interface{}{}),
+		request(0, "textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testUri, "version": 1, "text": testSource},
+		}),
+		request(2, "textDocument/definition", position(9, 10)),
+		request(3, "textDocument/references", position(3, 7)),
+		request(4, "textDocument/hover", position(10, 9)),
+		request(5, "textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]interface{}{"uri": testUri}}),
+		request(6, "textDocument/completion", position(5, 15)),
+		request(7, "shutdown", nil),
+		request(0, "exit", nil),
+	} {
+		writeMessage(&in, msg)
+	}
+	var out bytes.Buffer
+	err := NewServer(&in, &out).Serve()
+	if err != nil {
+		test.Fatalf("Serve failed: %v", err)
+	}
+	//responses are keyed by request id, notifications by method
+	results := make(map[string]json.RawMessage)
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readBody(r)
+		if err != nil {
+			break
+		}
+		var msg struct {
+			Id     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+		}
+		err = json.Unmarshal(body, &msg)
+		if err != nil {
+			test.Fatalf("Bad message from server: %v", err)
+		}
+		if msg.Method != "" {
+			results[msg.Method] = msg.Params
+		} else {
+			results[string(msg.Id)] = msg.Result
+		}
+	}
+
+	var diags PublishDiagnosticsParams
+	json.Unmarshal(results["textDocument/publishDiagnostics"], &diags)
+	if len(diags.Diagnostics) != 1 || diags.Diagnostics[0].Range.Start.Line != 11 || !strings.Contains(diags.Diagnostics[0].Message, "Colour") {
+		test.Errorf("Expected a diagnostic for the undefined type, got: %s", results["textDocument/publishDiagnostics"])
+	}
+	var locs []Location
+	json.Unmarshal(results["2"], &locs)
+	if len(locs) != 1 || locs[0].Range.Start.Line != 3 || locs[0].Uri != testUri {
+		test.Errorf("Bad definition of Point: %s", results["2"])
+	}
+	locs = nil
+	json.Unmarshal(results["3"], &locs)
+	if len(locs) != 2 || locs[0].Range.Start.Line != 9 || locs[1].Range.Start.Line != 10 {
+		test.Errorf("Bad references to Point: %s", results["3"])
+	}
+	var hover Hover
+	json.Unmarshal(results["4"], &hover)
+	if !strings.Contains(hover.Contents.Value, "type Point Struct") || !strings.Contains(hover.Contents.Value, "A point on the plane") {
+		test.Errorf("Bad hover for Point: %s", results["4"])
+	}
+	var symbols []DocumentSymbol
+	json.Unmarshal(results["5"], &symbols)
+	if len(symbols) != 2 || symbols[0].Name != "Point" || len(symbols[0].Children) != 2 || symbols[1].Name != "Line" {
+		test.Errorf("Bad document symbols: %s", results["5"])
+	}
+	var items []CompletionItem
+	json.Unmarshal(results["6"], &items)
+	labels := make(map[string]bool)
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+	if !labels["required"] || !labels["x_tags"] || labels["Int32"] {
+		test.Errorf("Bad completions inside options: %s", results["6"])
+	}
+}
+
+func TestPositions(test *testing.T) {
+	line := "x é😀 Point"
+	if n := utf16Column(line, 5); n != 6 {
+		test.Errorf("Expected rune column 5 to be at UTF-16 offset 6, got %d", n)
+	}
+	if n := byteOffset(line, 6); line[n:] != "Point" {
+		test.Errorf("Expected UTF-16 offset 6 to be at byte %d, got %d", strings.Index(line, "Point"), n)
+	}
+	if n := byteOffset(line, -1); n != 0 {
+		test.Errorf("Expected a negative offset to clamp to 0, got %d", n)
+	}
+	if n := byteOffset(line, 100); n != len(line) {
+		test.Errorf("Expected a large offset to clamp to the end of the line, got %d", n)
+	}
+	name, r := wordAt(line, Position{Line: 0, Character: 8})
+	if name != "Point" || r.Start.Character != 6 || r.End.Character != 11 {
+		test.Errorf("Bad word at UTF-16 offset 8: %q %v", name, r)
+	}
+	if name, _ := wordAt(line, Position{Line: 0, Character: -5}); name != "x" {
+		test.Errorf("Expected a negative character to clamp to the start of the line, got %q", name)
+	}
+	for _, pos := range []Position{{Line: -1, Character: 0}, {Line: 100, Character: 100}} {
+		if name, _ := wordAt(line, pos); name != "" {
+			test.Errorf("Expected no word at %v, got %q", pos, name)
+		}
+	}
+}
@@ -0,0 +1,583 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/boynton/sadl"
+	"github.com/boynton/scanner"
+)
+
+// Server is a Language Server Protocol server for SADL source files. It communicates over a pair of streams,
+// normally stdin and stdout, and keeps the open documents parsed as they change.
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+type document struct {
+	uri         string
+	path        string
+	text        string
+	model       *sadl.Model
+	diagnostics sadl.Diagnostics
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client sends the exit notification, or closes the input stream.
+func (s *Server) Serve() error {
+	for {
+		msg, err := readMessage(s.in)
+		if msg == nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err != nil {
+			err = s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()})
+		} else if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("Exit without shutdown")
+			}
+			return nil
+		} else {
+			result, rerr := s.handle(msg)
+			if msg.Id != nil {
+				err = s.reply(msg.Id, result, rerr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) error {
+	response := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+	if rerr != nil {
+		response["error"] = rerr
+	} else {
+		response["result"] = result
+	}
+	return writeMessage(s.out, response)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (s *Server) handle(msg *message) (interface{}, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return nil, s.publish(s.update(params.TextDocument.Uri, params.TextDocument.Text))
+	case "textDocument/didChange":
+		var params DidChangeParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			//full sync: the last change has the entire text
+			return nil, s.publish(s.update(params.TextDocument.Uri, params.ContentChanges[n-1].Text))
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		delete(s.docs, params.TextDocument.Uri)
+		return nil, s.publish(&document{uri: params.TextDocument.Uri})
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.definition(s.docs[params.TextDocument.Uri], params.Position), nil
+	case "textDocument/references":
+		var params ReferenceParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.references(s.docs[params.TextDocument.Uri], params.Position, params.Context.IncludeDeclaration), nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.hover(s.docs[params.TextDocument.Uri], params.Position), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.completion(s.docs[params.TextDocument.Uri], params.Position), nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if rerr := decodeParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.documentSymbols(s.docs[params.TextDocument.Uri]), nil
+	}
+	if msg.Id == nil {
+		return nil, nil //notifications we don't handle are ignored
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "Method not found: " + msg.Method}
+}
+
+func decodeParams(msg *message, params interface{}) *responseError {
+	err := json.Unmarshal(msg.Params, params)
+	if err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize() interface{} {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    1, //full
+			},
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"documentSymbolProvider": true,
+			"completionProvider": map[string]interface{}{
+				"triggerCharacters": []string{"(", "<"},
+			},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "sadl",
+			"version": sadl.Version,
+		},
+	}
+}
+
+// parse the text of the document, keeping the previous model if the new text cannot be made into one.
+func (s *Server) update(uri string, text string) *document {
+	doc := &document{uri: uri, path: uriToPath(uri), text: text}
+	p := sadl.NewParser(doc.path, text, sadl.NewData())
+	_, err := p.Parse(nil)
+	doc.model = p.Model()
+	doc.diagnostics = p.Diagnostics()
+	if err != nil && len(doc.diagnostics) == 0 {
+		doc.diagnostics = sadl.AsDiagnostics(err)
+	}
+	if doc.model == nil {
+		if prev, ok := s.docs[uri]; ok {
+			doc.model = prev.model
+		}
+	}
+	s.docs[uri] = doc
+	return doc
+}
+
+func (s *Server) publish(doc *document) *responseError {
+	diags := make([]Diagnostic, 0)
+	for _, d := range doc.diagnostics {
+		ld := Diagnostic{Severity: severityError, Source: "sadl", Message: d.Message}
+		if d.Severity == sadl.SeverityWarning {
+			ld.Severity = severityWarning
+		}
+		if d.Span != nil {
+			if d.Span.File == "" || d.Span.File == doc.path {
+				ld.Range = spanRange(doc.text, d.Span)
+			} else {
+				//a problem in an included file
+				ld.Message = fmt.Sprintf("%s:%d:%d: %s", d.Span.File, d.Span.Line, d.Span.Col, d.Message)
+			}
+		}
+		diags = append(diags, ld)
+	}
+	err := s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{Uri: doc.uri, Diagnostics: diags})
+	if err != nil {
+		return &responseError{Code: codeInternalError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) definition(doc *document, pos Position) []Location {
+	if doc == nil || doc.model == nil {
+		return nil
+	}
+	name, _ := wordAt(doc.text, pos)
+	if span := definitionSpan(doc.model, name); span != nil {
+		text := ""
+		if span.File == "" || span.File == doc.path {
+			text = doc.text
+		}
+		return []Location{{Uri: doc.uriFor(span.File), Range: spanRange(text, span)}}
+	}
+	return nil
+}
+
+func definitionSpan(model *sadl.Model, name string) *sadl.Span {
+	if td := model.FindType(name); td != nil {
+		return td.Span
+	}
+	if cd := model.FindConstant(name); cd != nil {
+		return cd.Span
+	}
+	if ad := model.FindAnnotationDef(name); ad != nil {
+		return ad.Span
+	}
+	return nil
+}
+
+// references are found lexically, as symbols with the same name in any of the open documents.
+func (s *Server) references(doc *document, pos Position, includeDeclaration bool) []Location {
+	if doc == nil || doc.model == nil {
+		return nil
+	}
+	name, _ := wordAt(doc.text, pos)
+	if name == "" || (doc.model.FindType(name) == nil && doc.model.FindConstant(name) == nil && doc.model.FindAnnotationDef(name) == nil) {
+		return nil
+	}
+	var uris []string
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	locs := make([]Location, 0)
+	for _, uri := range uris {
+		text := s.docs[uri].text
+		for _, tok := range symbolTokens(text, name, includeDeclaration) {
+			line := lineAt(text, tok.Line-1)
+			start := Position{Line: tok.Line - 1, Character: utf16Column(line, tok.Start-1)}
+			end := Position{Line: tok.Line - 1, Character: utf16Column(line, tok.Start-1+utf8.RuneCountInString(tok.Text))}
+			locs = append(locs, Location{Uri: uri, Range: Range{Start: start, End: end}})
+		}
+	}
+	return locs
+}
+
+func symbolTokens(text string, name string, includeDeclaration bool) []scanner.Token {
+	var toks []scanner.Token
+	var prev scanner.Token
+	scan := scanner.NewScanner(strings.NewReader(text))
+	for {
+		tok := scan.Scan()
+		if tok.Type == scanner.EOF {
+			return toks
+		}
+		if tok.Type == scanner.SYMBOL && tok.Text == name {
+			if includeDeclaration || !isDeclaration(prev) {
+				toks = append(toks, tok)
+			}
+		}
+		if tok.Type != scanner.BLOCK_COMMENT {
+			prev = tok
+		}
+	}
+}
+
+// a directive that names the symbol following it
+func isDeclaration(directive scanner.Token) bool {
+	if directive.Type != scanner.SYMBOL || directive.Start != 1 {
+		return false
+	}
+	switch directive.Text {
+	case "type", "const", "annotation":
+		return true
+	}
+	return false
+}
+
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	if doc == nil || doc.model == nil {
+		return nil
+	}
+	name, r := wordAt(doc.text, pos)
+	if name == "" {
+		return nil
+	}
+	var src, comment string
+	if td := doc.model.FindType(name); td != nil {
+		if td.Span == nil && td.Type == td.Name {
+			src = "base type " + name
+		} else {
+			src = sadl.DecompileTypeDef(doc.model, td)
+		}
+		comment = td.Comment
+	} else if cd := doc.model.FindConstant(name); cd != nil {
+		src = fmt.Sprintf("const %s %s = %s", cd.Name, cd.Type, sadl.ToString(cd.Value))
+		comment = cd.Comment
+	} else if ad := doc.model.FindAnnotationDef(name); ad != nil {
+		src = fmt.Sprintf("annotation %s %s", ad.Name, ad.Type)
+		if len(ad.Targets) > 0 {
+			src += fmt.Sprintf(" (targets=%s)", sadl.ToString(ad.Targets))
+		}
+		comment = ad.Comment
+	} else {
+		return nil
+	}
+	value := "```sadl\n" + strings.TrimRight(src, "\n") + "\n```"
+	if comment != "" {
+		value += "\n\n" + comment
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &r}
+}
+
+func (s *Server) completion(doc *document, pos Position) []CompletionItem {
+	items := make([]CompletionItem, 0)
+	if doc == nil {
+		return items
+	}
+	lines := strings.Split(doc.text, "\n")
+	prefix := ""
+	if pos.Line >= 0 && pos.Line < len(lines) {
+		prefix = lines[pos.Line]
+		prefix = prefix[:byteOffset(prefix, pos.Character)]
+	}
+	word := prefix[len(strings.TrimRightFunc(prefix, isWordChar)):]
+	inOptions := strings.LastIndex(prefix, "(") > strings.LastIndex(prefix, ")")
+	if inOptions || strings.HasPrefix(word, "x_") {
+		items = append(items, annotationCompletions(doc.model)...)
+		if !strings.HasPrefix(word, "x_") {
+			for _, name := range sadl.OptionNames {
+				items = append(items, CompletionItem{Label: name, Kind: completionKindProperty, Detail: "option"})
+			}
+		}
+		return items
+	}
+	for _, name := range sadl.BaseTypes {
+		items = append(items, CompletionItem{Label: name, Kind: completionKindKeyword, Detail: "base type"})
+	}
+	if doc.model != nil {
+		for _, td := range doc.model.Types {
+			kind := completionKindClass
+			if td.Type == "Struct" {
+				kind = completionKindStruct
+			}
+			items = append(items, CompletionItem{Label: td.Name, Kind: kind, Detail: td.Type})
+		}
+	}
+	return items
+}
+
+func annotationCompletions(model *sadl.Model) []CompletionItem {
+	var items []CompletionItem
+	declared := make(map[string]bool)
+	if model != nil {
+		for _, ad := range model.AnnotationDefs {
+			declared[ad.Name] = true
+			items = append(items, CompletionItem{Label: ad.Name, Kind: completionKindProperty, Detail: ad.Type})
+		}
+	}
+	var builtins []string
+	for name := range sadl.BuiltinAnnotations {
+		if !declared[name] {
+			builtins = append(builtins, name)
+		}
+	}
+	sort.Strings(builtins)
+	for _, name := range builtins {
+		items = append(items, CompletionItem{Label: name, Kind: completionKindProperty, Detail: "annotation"})
+	}
+	return items
+}
+
+func (s *Server) documentSymbols(doc *document) []DocumentSymbol {
+	symbols := make([]DocumentSymbol, 0)
+	if doc == nil || doc.model == nil {
+		return symbols
+	}
+	inDoc := func(span *sadl.Span) bool {
+		return span != nil && (span.File == "" || span.File == doc.path)
+	}
+	for _, td := range doc.model.Types {
+		if !inDoc(td.Span) {
+			continue
+		}
+		sym := newSymbol(doc.text, td.Name, td.Type, symbolKindClass, td.Span)
+		switch td.Type {
+		case "Struct":
+			sym.Kind = symbolKindStruct
+		case "Enum":
+			sym.Kind = symbolKindEnum
+		}
+		for _, fd := range td.Fields {
+			if inDoc(fd.Span) {
+				sym.Children = append(sym.Children, newSymbol(doc.text, fd.Name, fd.Type, symbolKindField, fd.Span))
+			}
+		}
+		for _, vd := range td.Variants {
+			if inDoc(vd.Span) {
+				sym.Children = append(sym.Children, newSymbol(doc.text, vd.Name, vd.Type, symbolKindField, vd.Span))
+			}
+		}
+		for _, el := range td.Elements {
+			if inDoc(el.Span) {
+				sym.Children = append(sym.Children, newSymbol(doc.text, el.Symbol, "", symbolKindEnumMember, el.Span))
+			}
+		}
+		symbols = append(symbols, sym)
+	}
+	for _, cd := range doc.model.Constants {
+		if inDoc(cd.Span) {
+			symbols = append(symbols, newSymbol(doc.text, cd.Name, cd.Type, symbolKindConstant, cd.Span))
+		}
+	}
+	for _, op := range doc.model.Operations {
+		if inDoc(op.Span) {
+			symbols = append(symbols, newSymbol(doc.text, op.Name, "operation", symbolKindMethod, op.Span))
+		}
+	}
+	for _, hd := range doc.model.Http {
+		if inDoc(hd.Span) {
+			name := hd.Name
+			if name == "" {
+				name = hd.Method + " " + hd.Path
+			}
+			symbols = append(symbols, newSymbol(doc.text, name, hd.Method+" "+hd.Path, symbolKindMethod, hd.Span))
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Range.Start.Line < symbols[j].Range.Start.Line
+	})
+	return symbols
+}
+
+func newSymbol(text string, name string, detail string, kind int, span *sadl.Span) DocumentSymbol {
+	r := spanRange(text, span)
+	return DocumentSymbol{Name: name, Detail: detail, Kind: kind, Range: r, SelectionRange: r}
+}
+
+func (doc *document) uriFor(path string) string {
+	if path == "" || path == doc.path {
+		return doc.uri
+	}
+	return pathToUri(path)
+}
+
+// spans are 1-based and inclusive of the end column, ranges are 0-based and exclusive of the end. The text is that of
+// the file the span is in, if it is open, to convert its columns to UTF-16.
+func spanRange(text string, span *sadl.Span) Range {
+	r := Range{
+		Start: Position{Line: span.Line - 1, Character: utf16Column(lineAt(text, span.Line-1), span.Col-1)},
+		End:   Position{Line: span.EndLine - 1, Character: utf16Column(lineAt(text, span.EndLine-1), span.EndCol)},
+	}
+	if r.Start.Line < 0 {
+		r.Start = Position{}
+	}
+	if r.End.Line < r.Start.Line {
+		r.End = r.Start
+	}
+	return r
+}
+
+func isWordChar(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// the identifier at the position, and its range
+func wordAt(text string, pos Position) (string, Range) {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return "", Range{}
+	}
+	line := lines[pos.Line]
+	start := byteOffset(line, pos.Character)
+	end := start
+	for start > 0 && isWordChar(rune(line[start-1])) {
+		start--
+	}
+	for end < len(line) && isWordChar(rune(line[end])) {
+		end++
+	}
+	r := Range{Start: Position{Line: pos.Line, Character: utf16Len(line[:start])}, End: Position{Line: pos.Line, Character: utf16Len(line[:end])}}
+	return line[start:end], r
+}
+
+// LSP positions count the UTF-16 code units of a line, where the scanner counts runes, and strings are indexed by
+// bytes. Positions outside of a line are clamped to it.
+
+// lineAt returns the 0-based line of the text, or "" if there is no such line.
+func lineAt(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, c := range s {
+		n += len(utf16.Encode([]rune{c}))
+	}
+	return n
+}
+
+// utf16Column returns the UTF-16 offset of a rune column of the line. Columns past its end are counted as one unit each,
+// as for a line that is not known.
+func utf16Column(line string, col int) int {
+	if col <= 0 {
+		return 0
+	}
+	runes := []rune(line)
+	if col > len(runes) {
+		return utf16Len(line) + col - len(runes)
+	}
+	return utf16Len(string(runes[:col]))
+}
+
+// byteOffset returns the byte offset of a UTF-16 offset of the line.
+func byteOffset(line string, character int) int {
+	n := 0
+	for i, c := range line {
+		if n >= character {
+			return i
+		}
+		n += len(utf16.Encode([]rune{c}))
+	}
+	return len(line)
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
+
+func pathToUri(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,240 @@
+package sadl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkSchemaVersion rejects a JSON AST written by a newer major version of sadl than this one, which may use
+// features this version would silently drop. Development builds, on either side, are not checked.
+func checkSchemaVersion(schema *Schema) error {
+	if schema.Sadl == "" {
+		return fmt.Errorf("Not a SADL model: no 'sadl' version")
+	}
+	major, ok := releaseMajorVersion(schema.Sadl)
+	if !ok {
+		return nil
+	}
+	current, ok := releaseMajorVersion(Version)
+	if ok && major > current {
+		return fmt.Errorf("The model was written by sadl %s, which is newer than this version (%s)", schema.Sadl, Version)
+	}
+	return nil
+}
+
+// releaseMajorVersion returns the major number of a release version.
+func releaseMajorVersion(v string) (int, bool) {
+	nums, ok := releaseNumbers(v)
+	return nums[0], ok
+}
+
+// A migration brings the JSON AST of a release before the given one up to the form that release introduced.
+type migration struct {
+	release string
+	migrate func(schema *Schema) error
+}
+
+var migrations = []migration{
+	{"v1.0.0", migrateHttpActionNames},
+	{"v1.9.0", migrateGreedyParams},
+}
+
+// migrateSchema brings a JSON AST written by an older version of sadl, or by another tool, up to what the parser
+// produces now, so that it validates and generates the same way, and stamps it with the current version. The AST is
+// expected to have been decoded with json.Number for numbers, which become Decimals, as in parsed source.
+func migrateSchema(schema *Schema) error {
+	normalizeSchema(schema)
+	for _, m := range migrations {
+		if releaseBefore(schema.Sadl, m.release) {
+			err := m.migrate(schema)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	schema.Sadl = migratedVersion()
+	return nil
+}
+
+// migratedVersion returns the version a migrated AST is stamped with, which is this version if it is a release. A
+// development build has no release number, so it stamps that of the latest migration, whose form the AST now has, so
+// that loading it again does not migrate it again.
+func migratedVersion() string {
+	if _, ok := releaseNumbers(Version); ok {
+		return Version
+	}
+	return migrations[len(migrations)-1].release
+}
+
+// releaseBefore returns true if the version is a release before the given one. Any other version, i.e. a development
+// build or another tool, is assumed to need every migration, which are all harmless to an AST already in the new form.
+func releaseBefore(v string, release string) bool {
+	vn, ok := releaseNumbers(v)
+	if !ok {
+		return true
+	}
+	rn, _ := releaseNumbers(release)
+	for i := range rn {
+		if vn[i] != rn[i] {
+			return vn[i] < rn[i]
+		}
+	}
+	return false
+}
+
+// releaseNumbers returns the major, minor, and patch numbers of a release version, as produced by 'git describe
+// --tag', i.e. "v1.8.3" or "v1.8.3-2-g1234567".
+func releaseNumbers(v string) ([3]int, bool) {
+	var nums [3]int
+	v = strings.SplitN(strings.TrimPrefix(v, "v"), "-", 2)[0]
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nums, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nums, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// older versions did not always name the http actions, or mark the params that must be present
+func migrateHttpActionNames(schema *Schema) error {
+	for _, hd := range schema.Http {
+		err := ensureActionName(hd)
+		if err != nil {
+			return err
+		}
+		ensureRequiredParams(hd)
+	}
+	return nil
+}
+
+// older versions did not mark the path params of greedy labels
+func migrateGreedyParams(schema *Schema) error {
+	for _, hd := range schema.Http {
+		for _, in := range hd.Inputs {
+			if in.Path && strings.Contains(hd.Path, "{"+in.Name+"+}") {
+				in.Greedy = true
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeSchema converts the numbers of literals to Decimals, and the aliases other tools may use to their types,
+// regardless of version.
+func normalizeSchema(schema *Schema) {
+	migrateAnnotations(schema.Annotations)
+	for _, cd := range schema.Constants {
+		migrateAnnotations(cd.Annotations)
+		cd.Value = migrateLiteral(cd.Value)
+	}
+	for _, td := range schema.Types {
+		migrateAnnotations(td.Annotations)
+		migrateTypeSpec(&td.TypeSpec)
+	}
+	for _, ad := range schema.AnnotationDefs {
+		migrateTypeSpec(&ad.TypeSpec)
+	}
+	for _, op := range schema.Operations {
+		migrateAnnotations(op.Annotations)
+		for _, in := range op.Inputs {
+			migrateField(&in.StructFieldDef)
+		}
+		for _, out := range op.Outputs {
+			migrateAnnotations(out.Annotations)
+			migrateTypeSpec(&out.TypeSpec)
+		}
+	}
+	for _, hd := range schema.Http {
+		migrateAnnotations(hd.Annotations)
+		migrateAnnotations(hd.Statements)
+		for _, in := range hd.Inputs {
+			migrateField(&in.StructFieldDef)
+		}
+		if hd.Expected != nil {
+			migrateAnnotations(hd.Expected.Annotations)
+			for _, out := range hd.Expected.Outputs {
+				migrateField(&out.StructFieldDef)
+			}
+		}
+		for _, exc := range hd.Exceptions {
+			migrateAnnotations(exc.Annotations)
+			for _, out := range exc.Outputs {
+				migrateField(&out.StructFieldDef)
+			}
+		}
+	}
+	for _, ad := range schema.Auth {
+		migrateAnnotations(ad.Annotations)
+	}
+	for _, sd := range schema.Services {
+		migrateAnnotations(sd.Annotations)
+	}
+	for _, rd := range schema.Resources {
+		migrateAnnotations(rd.Annotations)
+	}
+	for _, exc := range schema.Exceptions {
+		migrateAnnotations(exc.Annotations)
+		for _, out := range exc.Outputs {
+			migrateField(&out.StructFieldDef)
+		}
+	}
+	for _, ex := range schema.Examples {
+		migrateAnnotations(ex.Annotations)
+		ex.Example = migrateLiteral(ex.Example)
+	}
+}
+
+// List is an alias that the parser reads as Array, but other tools may write it
+func migrateTypeSpec(ts *TypeSpec) {
+	if ts.Type == "List" {
+		ts.Type = "Array"
+	}
+	for _, fd := range ts.Fields {
+		migrateField(fd)
+	}
+	for _, vd := range ts.Variants {
+		migrateAnnotations(vd.Annotations)
+		migrateTypeSpec(&vd.TypeSpec)
+	}
+	for _, el := range ts.Elements {
+		migrateAnnotations(el.Annotations)
+	}
+}
+
+func migrateField(fd *StructFieldDef) {
+	migrateAnnotations(fd.Annotations)
+	fd.Default = migrateLiteral(fd.Default)
+	migrateTypeSpec(&fd.TypeSpec)
+}
+
+func migrateAnnotations(annos map[string]interface{}) {
+	for k, v := range annos {
+		annos[k] = migrateLiteral(v)
+	}
+}
+
+func migrateLiteral(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if d, err := ParseDecimal(val.String()); err == nil {
+			return d
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = migrateLiteral(item)
+		}
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = migrateLiteral(item)
+		}
+	}
+	return v
+}
@@ -4,11 +4,21 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/fs"
+	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"strings"
 )
 
+// ReadFile reads the named file from the file system, or from the OS file system if fsys is nil.
+func ReadFile(fsys fs.FS, path string) ([]byte, error) {
+	if fsys == nil {
+		return ioutil.ReadFile(path)
+	}
+	return fs.ReadFile(fsys, path)
+}
+
 func Capitalize(s string) string {
 	if s == "" {
 		return s
@@ -163,9 +173,10 @@ func FormatComment(indent, prefix, comment string, maxcol int, extraPad bool) st
 	return pad + buf.String() + pad
 }
 
-func GetAnnotation(annos map[string]string, key string) string {
+func GetAnnotation(annos map[string]interface{}, key string) string {
 	if annos != nil {
-		if s, ok := annos[key]; ok {
+		if v, ok := annos[key]; ok {
+			s := AnnotationValueString(v)
 			if s == "" {
 				s = "true"
 			}
@@ -175,11 +186,52 @@ func GetAnnotation(annos map[string]string, key string) string {
 	return ""
 }
 
-func AnnotationsAsString(annos map[string]string) string {
+// GetAnnotationStrings returns the value of an array annotation as a slice of strings. For compatibility with
+// models that predate typed annotation values, a string value is split on commas.
+func GetAnnotationStrings(annos map[string]interface{}, key string) []string {
+	if annos != nil {
+		if v, ok := annos[key]; ok {
+			if sa := AsStringArray(v); sa != nil {
+				return sa
+			}
+			if s := AsString(v); s != "" {
+				return strings.Split(s, ",")
+			}
+		}
+	}
+	return nil
+}
+
+// AnnotationValueString returns strings unquoted, and any other value as JSON
+func AnnotationValueString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case *string:
+		return *s
+	}
+	return ToString(v)
+}
+
+// AnnotationOption formats an annotation as an option in SADL source, i.e. x_foo="bar" or x_tags=["a","b"]
+func AnnotationOption(name string, v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		if s == "" {
+			return name
+		}
+		return fmt.Sprintf("%s=%q", name, s)
+	case *string:
+		return fmt.Sprintf("%s=%q", name, *s)
+	}
+	return name + "=" + ToString(v)
+}
+
+func AnnotationsAsString(annos map[string]interface{}) string {
 	var opts []string
 	if len(annos) > 0 {
 		for k, v := range annos {
-			opts = append(opts, fmt.Sprintf("%s=%q", k, v))
+			opts = append(opts, AnnotationOption(k, v))
 		}
 	}
 	if len(opts) > 0 {
@@ -14,13 +14,15 @@ type Model struct {
 	Extensions map[string]interface{} `json:"extensions,omitempty"`
 	typeIndex  map[string]*TypeDef
 	httpIndex  map[string]*HttpDef
+	constIndex map[string]*ConstantDef
 }
 
 func NewModel(schema *Schema) (*Model, error) {
 	model := &Model{
-		Schema:    *schema,
-		typeIndex: make(map[string]*TypeDef, 0),
-		httpIndex: make(map[string]*HttpDef, 0),
+		Schema:     *schema,
+		typeIndex:  make(map[string]*TypeDef, 0),
+		httpIndex:  make(map[string]*HttpDef, 0),
+		constIndex: make(map[string]*ConstantDef, 0),
 	}
 	for _, name := range BaseTypes {
 		model.typeIndex[name] = &TypeDef{Name: name, TypeSpec: TypeSpec{Type: name}}
@@ -37,6 +39,12 @@ func NewModel(schema *Schema) (*Model, error) {
 		}
 		model.httpIndex[hd.Name] = hd
 	}
+	for _, cd := range schema.Constants {
+		if _, ok := model.constIndex[cd.Name]; ok {
+			return nil, fmt.Errorf("Duplicate constant: %s", cd.Name)
+		}
+		model.constIndex[cd.Name] = cd
+	}
 	return model, nil
 }
 
@@ -58,6 +66,367 @@ func (model *Model) FindHttp(name string) *HttpDef {
 	return nil
 }
 
+// FindHttpException returns the http action and exception that an example target like "GetFooExceptNotFound" refers
+// to, i.e. the response of the exception as a whole, rather than just its body.
+func (model *Model) FindHttpException(target string) (*HttpDef, *HttpExceptionSpec) {
+	for _, hd := range model.Http {
+		for _, exc := range model.HttpExceptions(hd) {
+			if ExceptionExampleTarget(hd, exc) == target {
+				return hd, exc
+			}
+		}
+	}
+	return nil, nil
+}
+
+// HttpExceptions returns the exceptions of an http action: its own, followed by those common to all actions of the
+// service that it does not override with one of the same status or type.
+func (model *Model) HttpExceptions(hd *HttpDef) []*HttpExceptionSpec {
+	if len(model.Exceptions) == 0 {
+		return hd.Exceptions
+	}
+	exceptions := hd.Exceptions
+	for _, common := range model.Exceptions {
+		overridden := false
+		for _, exc := range hd.Exceptions {
+			if exc.Status == common.Status || exc.Type == common.Type {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			exceptions = append(exceptions[:len(exceptions):len(exceptions)], common)
+		}
+	}
+	return exceptions
+}
+
+// IsCommonException returns true if the exception is one of those common to all http actions of the service.
+func (model *Model) IsCommonException(exc *HttpExceptionSpec) bool {
+	for _, common := range model.Exceptions {
+		if common == exc {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAuth returns the auth scheme of the service with the given name, or nil if there is none.
+func (model *Model) FindAuth(name string) *AuthDef {
+	for _, ad := range model.Auth {
+		if ad.Name == name {
+			return ad
+		}
+	}
+	return nil
+}
+
+// HttpAuth returns the auth schemes of an http action, any one of which it requires: those it names, or else all those
+// of the service. An anonymous action has none.
+func (model *Model) HttpAuth(hd *HttpDef) []*AuthDef {
+	if hd.Anonymous {
+		return nil
+	}
+	if len(hd.Auth) == 0 {
+		return model.Auth
+	}
+	var schemes []*AuthDef
+	for _, name := range hd.Auth {
+		if ad := model.FindAuth(name); ad != nil {
+			schemes = append(schemes, ad)
+		}
+	}
+	return schemes
+}
+
+// FindService returns the service with the given name, or nil if there is none.
+func (model *Model) FindService(name string) *ServiceDef {
+	for _, sd := range model.Services {
+		if sd.Name == name {
+			return sd
+		}
+	}
+	return nil
+}
+
+// HttpService returns the service of an http action: the one it names, or else the only one of the model. It is nil
+// if the model declares no services.
+func (model *Model) HttpService(hd *HttpDef) *ServiceDef {
+	if hd.Service != "" {
+		return model.FindService(hd.Service)
+	}
+	if len(model.Services) == 1 {
+		return model.Services[0]
+	}
+	return nil
+}
+
+// ServiceHttp returns the http actions of a service.
+func (model *Model) ServiceHttp(sd *ServiceDef) []*HttpDef {
+	var actions []*HttpDef
+	for _, hd := range model.Http {
+		if model.HttpService(hd) == sd {
+			actions = append(actions, hd)
+		}
+	}
+	return actions
+}
+
+// SelectService narrows a model that declares several services to one of them, which becomes its only service, with
+// only its http actions, and the resources bound to them. A model that declares no services is left as is.
+func (model *Model) SelectService(name string) error {
+	if len(model.Services) == 0 {
+		return nil
+	}
+	sd := model.FindService(name)
+	if sd == nil {
+		return fmt.Errorf("Service not found: %s", name)
+	}
+	actions := model.ServiceHttp(sd)
+	for _, hd := range model.Http {
+		if model.HttpService(hd) != sd {
+			delete(model.httpIndex, hd.Name)
+		}
+	}
+	model.Http = actions
+	model.Services = []*ServiceDef{sd}
+	var resources []*ResourceDef
+	for _, rd := range model.Resources {
+		for _, op := range ResourceLifecycle {
+			if model.FindHttp(rd.LifecycleAction(op)) != nil {
+				resources = append(resources, rd)
+				break
+			}
+		}
+	}
+	model.Resources = resources
+	return nil
+}
+
+// HttpBase returns the base path of the service of an http action, that precedes its path.
+func (model *Model) HttpBase(hd *HttpDef) string {
+	if sd := model.HttpService(hd); sd != nil {
+		return sd.Base
+	}
+	return ""
+}
+
+// ResourceLifecycle is the order of the lifecycle operations of a resource.
+var ResourceLifecycle = []string{"create", "read", "update", "delete", "list"}
+
+// LifecycleAction returns the name of the http action bound to a lifecycle operation of the resource, or "" if there
+// is none.
+func (rd *ResourceDef) LifecycleAction(op string) string {
+	switch op {
+	case "create":
+		return rd.Create
+	case "read":
+		return rd.Read
+	case "update":
+		return rd.Update
+	case "delete":
+		return rd.Delete
+	case "list":
+		return rd.List
+	}
+	return ""
+}
+
+// FindResource returns the resource with the given name, or nil if there is none.
+func (model *Model) FindResource(name string) *ResourceDef {
+	for _, rd := range model.Resources {
+		if rd.Name == name {
+			return rd
+		}
+	}
+	return nil
+}
+
+// HttpLifecycle returns the first resource that binds an http action, and the lifecycle operation it binds it to. The
+// resource is nil if there is none.
+func (model *Model) HttpLifecycle(hd *HttpDef) (*ResourceDef, string) {
+	for _, rd := range model.Resources {
+		for _, op := range ResourceLifecycle {
+			if rd.LifecycleAction(op) == hd.Name {
+				return rd, op
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Input returns the input of an http action with the given name, or nil if there is none.
+func (hd *HttpDef) Input(name string) *HttpParamSpec {
+	for _, in := range hd.Inputs {
+		if in.Name == name {
+			return in
+		}
+	}
+	return nil
+}
+
+// HttpOutputPath returns the output of an http action that a dotted path names, followed by the fields of the Structs
+// the rest of the path names in turn, or nil if the path does not resolve.
+func (model *Model) HttpOutputPath(hd *HttpDef, path string) []*StructFieldDef {
+	if hd.Expected == nil || path == "" {
+		return nil
+	}
+	var result []*StructFieldDef
+	var fields []*StructFieldDef
+	for _, out := range hd.Expected.Outputs {
+		fields = append(fields, &out.StructFieldDef)
+	}
+	for _, name := range strings.Split(path, ".") {
+		var found *StructFieldDef
+		for _, fd := range fields {
+			if fd.Name == name {
+				found = fd
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		result = append(result, found)
+		fields = found.Fields
+		if td := model.FindType(found.Type); found.Type != "Struct" && td != nil {
+			fields = td.Fields
+		}
+	}
+	return result
+}
+
+// ResponseStatus returns the status of the response for an exception, which is 500 for the default exception, that
+// is declared without a status.
+func (exc *HttpExceptionSpec) ResponseStatus() int32 {
+	if exc.Status == 0 {
+		return 500
+	}
+	return exc.Status
+}
+
+// ExceptionExampleTarget returns the example target for the response of an exception of an http action.
+func ExceptionExampleTarget(hd *HttpDef, exc *HttpExceptionSpec) string {
+	return Capitalize(hd.Name) + "Except" + exc.Type
+}
+
+// BodyMediaType returns the media type of the body of an http request or response, which is JSON unless the body param
+// gives another one.
+func (spec *HttpParamSpec) BodyMediaType() string {
+	if spec.MediaType == "" {
+		return "application/json"
+	}
+	return spec.MediaType
+}
+
+// IsJsonMediaType returns true if the media type is that of JSON, including those like "application/problem+json".
+func IsJsonMediaType(mediaType string) bool {
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsFormMediaType returns true if the media type is that of an HTML form, of which each field of a Struct is a part.
+func IsFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// FormFields returns the fields of a form body, each of which is a part of the form.
+func (model *Model) FormFields(spec *HttpParamSpec) []*StructFieldDef {
+	if td := model.FindType(spec.Type); spec.Type != "Struct" && td != nil {
+		return td.Fields
+	}
+	return spec.Fields
+}
+
+func (model *Model) FindConstant(name string) *ConstantDef {
+	if model.constIndex != nil {
+		if c, ok := model.constIndex[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func (model *Model) BaseType(name string) string {
+	//bounded by the number of types, in case of a cycle in the type references
+	for i := 0; i < len(model.typeIndex); i++ {
+		td := model.FindType(name)
+		if td == nil || td.Type == name {
+			break
+		}
+		name = td.Type
+	}
+	return name
+}
+
+// ClearSpans removes the source positions from all definitions, i.e. before serializing the model.
+func (model *Model) ClearSpans() {
+	for _, cd := range model.Constants {
+		cd.Span = nil
+	}
+	for _, ad := range model.AnnotationDefs {
+		ad.Span = nil
+	}
+	for _, td := range model.Types {
+		td.Span = nil
+		clearTypeSpecSpans(&td.TypeSpec)
+	}
+	for _, op := range model.Operations {
+		op.Span = nil
+		for _, in := range op.Inputs {
+			in.Span = nil
+		}
+	}
+	for _, hd := range model.Http {
+		hd.Span = nil
+		for _, in := range hd.Inputs {
+			in.Span = nil
+		}
+		if hd.Expected != nil {
+			hd.Expected.Span = nil
+			for _, out := range hd.Expected.Outputs {
+				out.Span = nil
+			}
+		}
+		for _, exc := range hd.Exceptions {
+			exc.Span = nil
+			for _, out := range exc.Outputs {
+				out.Span = nil
+			}
+		}
+	}
+	for _, exc := range model.Exceptions {
+		exc.Span = nil
+		for _, out := range exc.Outputs {
+			out.Span = nil
+		}
+	}
+	for _, ad := range model.Auth {
+		ad.Span = nil
+	}
+	for _, sd := range model.Services {
+		sd.Span = nil
+	}
+	for _, rd := range model.Resources {
+		rd.Span = nil
+	}
+	for _, ex := range model.Examples {
+		ex.Span = nil
+	}
+}
+
+func clearTypeSpecSpans(ts *TypeSpec) {
+	for _, fd := range ts.Fields {
+		fd.Span = nil
+		clearTypeSpecSpans(&fd.TypeSpec)
+	}
+	for _, vd := range ts.Variants {
+		vd.Span = nil
+	}
+	for _, el := range ts.Elements {
+		el.Span = nil
+	}
+}
+
 func (model *Model) EquivalentTypesByName(tname1, tname2 string) bool {
 	if tname1 == tname2 {
 		return true
@@ -167,6 +536,79 @@ func (model *Model) Validate(context string, typename string, value interface{})
 	return model.ValidateAgainstTypeSpec(context, &td.TypeSpec, value)
 }
 
+// the definition of a type, with the options of the spec that referred to it taking precedence
+func inlinedTypeSpec(def *TypeSpec, ref *TypeSpec) TypeSpec {
+	ts := *def
+	if ref.Pattern != "" {
+		ts.Pattern = ref.Pattern
+	}
+	if ref.Values != nil {
+		ts.Values = ref.Values
+	}
+	if ref.MinSize != nil {
+		ts.MinSize = ref.MinSize
+	}
+	if ref.MaxSize != nil {
+		ts.MaxSize = ref.MaxSize
+	}
+	if ref.Min != nil {
+		ts.Min = ref.Min
+	}
+	if ref.Max != nil {
+		ts.Max = ref.Max
+	}
+	if ref.Reference != "" {
+		ts.Reference = ref.Reference
+	}
+	if ref.Unit != "" {
+		ts.Unit = ref.Unit
+	}
+	return ts
+}
+
+// WireName returns the key of the field in JSON, which is its name unless it has an x_wire_name annotation.
+func (fd *StructFieldDef) WireName() string {
+	return wireName(fd.Name, fd.Annotations)
+}
+
+// WireName returns the key of the variant in JSON, which is its name unless it has an x_wire_name annotation.
+func (vd *UnionVariantDef) WireName() string {
+	return wireName(vd.Name, vd.Annotations)
+}
+
+// WireName returns the value of the enum symbol in JSON, which is the symbol unless it has an x_wire_name, or the
+// older x_enumValue, annotation.
+func (el *EnumElementDef) WireName() string {
+	if val := GetAnnotation(el.Annotations, "x_enumValue"); val != "" {
+		return wireName(val, el.Annotations)
+	}
+	return wireName(el.Symbol, el.Annotations)
+}
+
+func wireName(name string, annos map[string]interface{}) string {
+	if val := GetAnnotation(annos, "x_wire_name"); val != "" {
+		return val
+	}
+	return name
+}
+
+// HasConstraints returns true if any of the options that constrain values are set on the type spec.
+func (ts *TypeSpec) HasConstraints() bool {
+	return ts.Pattern != "" || ts.Values != nil || ts.MinSize != nil || ts.MaxSize != nil || ts.Min != nil || ts.Max != nil || ts.Reference != "" || ts.Unit != ""
+}
+
+// ConstrainedTypeSpec returns the type spec of a field, param, or variant with the definition of its named type
+// resolved and the constraints given inline applied to it, i.e. as if a type had been defined for it. Specs that
+// refer to base types are returned as is.
+func (model *Model) ConstrainedTypeSpec(ts *TypeSpec) *TypeSpec {
+	td := model.FindType(ts.Type)
+	if td == nil || td.Type == ts.Type {
+		return ts
+	}
+	merged := inlinedTypeSpec(model.ConstrainedTypeSpec(&td.TypeSpec), ts)
+	return &merged
+}
+
 func (model *Model) ValidateAgainstTypeSpec(context string, td *TypeSpec, value interface{}) error {
 	if context == "" {
 		context = td.Type
@@ -198,12 +640,18 @@ func (model *Model) ValidateAgainstTypeSpec(context string, td *TypeSpec, value
 	case "Any":
 		//must be ok
 		return nil
+	case "Union":
+		return model.ValidateUnion(context, td, value)
 	default:
 		t := model.FindType(td.Type)
 		if t == nil {
 			return fmt.Errorf("%s: no such type '%s'", context, td.Type)
 		}
-		return model.ValidateAgainstTypeSpec(context, &t.TypeSpec, value)
+		err := model.ValidateAgainstTypeSpec(context, &t.TypeSpec, value)
+		if err == nil && td.HasConstraints() {
+			err = model.ValidateAgainstTypeSpec(context, model.ConstrainedTypeSpec(td), value)
+		}
+		return err
 	}
 }
 
@@ -235,9 +683,14 @@ func (model *Model) ValidateUUID(context string, td *TypeSpec, value interface{}
 }
 
 func (model *Model) ValidateUnitValue(context string, td *TypeSpec, value interface{}) error {
+	var s string
 	switch sp := value.(type) {
 	case *string:
-		s := *sp
+		s = *sp
+	case string:
+		s = sp
+	}
+	if s != "" {
 		n := strings.Index(s, " ")
 		if n >= 3 {
 			val := s[:n]
@@ -265,7 +718,7 @@ func (model *Model) ValidateEnum(context string, td *TypeSpec, value interface{}
 	}
 	if s != "" {
 		for _, el := range td.Elements {
-			if el.Symbol == s {
+			if el.WireName() == s {
 				return nil
 			}
 		}
@@ -320,7 +773,7 @@ func (model *Model) ValidateNumber(context string, td *TypeSpec, value interface
 
 func (model *Model) IsStructField(ts *TypeSpec, name string) bool {
 	for _, field := range ts.Fields {
-		if name == field.Name {
+		if name == field.WireName() {
 			return true
 		}
 	}
@@ -336,14 +789,20 @@ func (model *Model) ValidateStruct(context string, td *TypeSpec, value interface
 			}
 		}
 		for _, field := range td.Fields {
-			if v, ok := m[field.Name]; ok {
-				err := model.ValidateAgainstTypeSpec(context+"."+field.Name, &field.TypeSpec, v)
+			if v, ok := m[field.WireName()]; ok {
+				if v == nil {
+					if !field.Nullable {
+						return fmt.Errorf("%s.%s: null value for a field that is not nullable", context, field.WireName())
+					}
+					continue
+				}
+				err := model.ValidateAgainstTypeSpec(context+"."+field.WireName(), &field.TypeSpec, v)
 				if err != nil {
 					return err
 				}
 			} else {
 				if field.Required {
-					return fmt.Errorf("%s missing required field '%s': %s", context, field.Name, Pretty(value))
+					return fmt.Errorf("%s missing required field '%s': %s", context, field.WireName(), Pretty(value))
 				}
 			}
 		}
@@ -353,6 +812,26 @@ func (model *Model) ValidateStruct(context string, td *TypeSpec, value interface
 	return nil
 }
 
+// ValidateUnion checks a union value, which is an object with exactly one of the variants as its key.
+func (model *Model) ValidateUnion(context string, td *TypeSpec, value interface{}) error {
+	if len(td.Variants) == 0 {
+		return nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return fmt.Errorf("%s: Not a valid Union, expected an object with one variant: %s", context, Pretty(value))
+	}
+	for k, v := range m {
+		for _, vd := range td.Variants {
+			if vd.WireName() == k {
+				return model.ValidateAgainstTypeSpec(context+"."+k, &vd.TypeSpec, v)
+			}
+		}
+		return fmt.Errorf("Undefined variant in %s: '%s'", context, k)
+	}
+	return nil
+}
+
 func (model *Model) ValidateArray(context string, td *TypeSpec, value interface{}) error {
 	switch a := value.(type) {
 	case []interface{}:
@@ -50,19 +50,35 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 	}
 	if model.Annotations != nil {
 		if url, ok := model.Annotations["x_server"]; ok {
-			oas.Servers = append(oas.Servers, &Server{URL: url})
+			oas.Servers = append(oas.Servers, &Server{URL: sadl.AsString(url)})
 		}
 		var license License
 		if lname, ok := model.Annotations["x_license_name"]; ok {
-			license.Name = lname
+			license.Name = sadl.AsString(lname)
 		}
 		if lurl, ok := model.Annotations["x_license_url"]; ok {
-			license.URL = lurl
+			license.URL = sadl.AsString(lurl)
 		}
 		if license.URL != "" || license.Name != "" {
 			oas.Info.License = &license
 		}
 	}
+	if len(model.Constants) > 0 {
+		constants := make(map[string]interface{}, 0)
+		for _, cd := range model.Constants {
+			c := map[string]interface{}{
+				"type":  cd.Type,
+				"value": cd.Value,
+			}
+			if cd.Comment != "" {
+				c["description"] = cd.Comment
+			}
+			constants[cd.Name] = c
+		}
+		oas.Extensions = map[string]interface{}{
+			"x-sadl-constants": constants,
+		}
+	}
 	oas.Components = &Components{}
 	oas.Components.Schemas = make(map[string]*Schema, 0)
 	for _, td := range model.Types {
@@ -72,6 +88,26 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 		}
 		oas.Components.Schemas[td.Name] = otd
 	}
+	//the auth schemes of the service are the alternatives of its security requirement
+	for _, ad := range model.Auth {
+		if oas.Components.SecuritySchemes == nil {
+			oas.Components.SecuritySchemes = make(map[string]*SecurityScheme, 0)
+		}
+		oas.Components.SecuritySchemes[ad.Name] = exportAuthDef(ad)
+		oas.Security = append(oas.Security, SecurityRequirement{ad.Name: []string{}})
+	}
+	//each service is a tag of its operations, marked as a service so that its version is kept
+	for _, sd := range model.Services {
+		svc := make(map[string]interface{}, 0)
+		if sd.Version != "" {
+			svc["version"] = sd.Version
+		}
+		oas.Tags = append(oas.Tags, &Tag{
+			Name:        sd.Name,
+			Description: sd.Comment,
+			Extensions:  map[string]interface{}{"x-sadl-service": svc},
+		})
+	}
 	//Paths
 	oas.Paths = make(map[string]*PathItem, 0)
 	for _, hdef := range model.Http {
@@ -81,6 +117,12 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 		if i >= 0 {
 			p = p[:i]
 		}
+		for _, in := range hdef.Inputs {
+			if in.Greedy {
+				//OpenAPI has no greedy path parameters, the variable is just named, and its parameter marked
+				p = strings.Replace(p, "{"+in.Name+"+}", "{"+in.Name+"}", 1)
+			}
+		}
 		if prev, ok := oas.Paths[p]; ok {
 			pi = prev
 		} else {
@@ -102,19 +144,33 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 			//Callbacks
 			//Security
 		}
+		if hdef.Anonymous {
+			//an empty requirement makes authentication optional
+			op.Security = []SecurityRequirement{{}}
+		} else if len(hdef.Auth) > 0 || len(hdef.Scopes) > 0 {
+			op.Security = securityRequirements(model.HttpAuth(hdef), hdef.Scopes)
+		}
 		if hdef.Resource != "" {
 			//note: the first tag is always the resource name for the action
 			op.Tags = append(op.Tags, hdef.Resource)
 		}
-		if len(hdef.Annotations) > 0 {
-			for k, v := range hdef.Annotations {
-				if k == "x_tags" {
-					for _, t := range strings.Split(v, ",") {
-						op.Tags = append(op.Tags, t)
-					}
+		if sd := model.HttpService(hdef); sd != nil {
+			op.Tags = append(op.Tags, sd.Name)
+			if sd.Base != "" {
+				//the base path of the service follows the URL of each server
+				for _, server := range oas.Servers {
+					op.Servers = append(op.Servers, &Server{URL: server.URL + sd.Base})
 				}
 			}
 		}
+		if hdef.Paginated != nil {
+			op.Extensions = map[string]interface{}{"x-pagination": exportPagination(hdef)}
+		}
+		if len(hdef.Annotations) > 0 {
+			for _, t := range sadl.GetAnnotationStrings(hdef.Annotations, "x_tags") {
+				op.Tags = append(op.Tags, t)
+			}
+		}
 		switch hdef.Method {
 		case "GET":
 			if pi.Get != nil {
@@ -147,23 +203,33 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 				param.In = "path"
 				param.Name = in.Name
 				r = true
+				if in.Greedy {
+					param.Extensions = map[string]interface{}{"x-sadl-greedy": true}
+				}
 			} else if in.Query != "" {
 				param.In = "query"
 				param.Name = in.Query
+				if in.Csv {
+					explode := false
+					param.Explode = &explode
+				}
 			} else if in.Header != "" {
 				param.In = "header"
 				param.Name = in.Header
+			} else if in.Cookie != "" {
+				param.In = "cookie"
+				param.Name = in.Cookie
 			} else { //body
 				body := &RequestBody{
 					Description: in.Comment,
 					Required:    true,
 					Content:     make(map[string]*MediaType, 0),
 				}
-				tr, err := gen.oasSchema(&in.TypeSpec, "")
+				tr, err := gen.bodySchema(in)
 				if err != nil {
 					return nil, err
 				}
-				body.Content["application/json"] = &MediaType{
+				body.Content[in.BodyMediaType()] = &MediaType{
 					Schema: tr,
 				}
 				op.RequestBody = body
@@ -188,61 +254,54 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 			Description: comment,
 			Content:     content,
 		}
-		var headers map[string]*Header
+		headers, err := gen.responseHeaders(hdef.Expected.Outputs)
+		if err != nil {
+			return nil, err
+		}
+		resp.Headers = headers
 		for _, param := range hdef.Expected.Outputs {
-			if param.Header != "" {
-				pschema, err := gen.oasSchema(&param.TypeSpec, "")
-				if err != nil {
-					return nil, err
-				}
-				header := &Header{
-					Description: param.Comment,
-					Schema:      pschema,
-				}
-				if headers == nil {
-					headers = make(map[string]*Header, 0)
-				}
-				headers[param.Header] = header
-			} else { //body
-				tr, err := gen.oasSchema(&param.TypeSpec, "")
+			if param.Header == "" && param.Cookie == "" {
+				tr, err := gen.bodySchema(param)
 				if err != nil {
 					return nil, err
 				}
 				mt := &MediaType{
 					Schema: tr,
 				}
-				content["application/json"] = mt
+				content[param.BodyMediaType()] = mt
 			}
 		}
-		if len(headers) > 0 {
-			resp.Headers = headers
-		}
 		key := fmt.Sprint(hdef.Expected.Status)
 		responses[key] = resp
-		for _, out := range hdef.Exceptions {
-			content := make(map[string]*MediaType)
-			comment := out.Comment
-			if comment == "" {
-				comment = "Exceptional response"
-			}
-			resp := &Response{
-				Description: comment,
-				Content:     content,
-			}
-			tr := &Schema{
-				Ref: "#/components/schemas/" + out.Type,
-			}
-			mt := &MediaType{
-				Schema: tr,
-			}
-			content["application/json"] = mt
+		for _, out := range model.HttpExceptions(hdef) {
 			key := "default"
 			if out.Status != 0 {
 				key = fmt.Sprint(out.Status)
 			}
+			if model.IsCommonException(out) {
+				responses[key] = &Response{
+					Ref: "#/components/responses/" + out.Type,
+				}
+				continue
+			}
+			resp, err := gen.exceptionResponse(out)
+			if err != nil {
+				return nil, err
+			}
 			responses[key] = resp
 		}
 	}
+	//the exceptions common to all actions are shared responses
+	for _, out := range model.Exceptions {
+		resp, err := gen.exceptionResponse(out)
+		if err != nil {
+			return nil, err
+		}
+		if oas.Components.Responses == nil {
+			oas.Components.Responses = make(map[string]*Response, 0)
+		}
+		oas.Components.Responses[out.Type] = resp
+	}
 	//Examples
 	for _, ed := range model.Examples {
 		//ok, for now: just "example", not "examples", i.e. no name. And, parameter examples override the matching type example
@@ -259,13 +318,41 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 			// I like the idea of have a complete request object (headers, path, query, and payload) all encapsulated nicely.
 			//   -> seems like I could use it in the API somehow. It really is what you need to abstract from the transport. Like RPC.
 			//todo: walk the compound name, install at the element if supported.
-			if strings.HasSuffix(ed.Target, "Request") {
+			if hact, exc := model.FindHttpException(ed.Target); exc != nil {
+				//only the body of an exceptional response can have an example
+				op := gen.FindOperation(oas, hact.Name)
+				key := "default"
+				if exc.Status != 0 {
+					key = fmt.Sprint(exc.Status)
+				}
+				for _, out := range exc.Outputs {
+					v, ok := ed.Example.(map[string]interface{})[out.Name]
+					if op == nil || !ok || out.Header != "" || out.Cookie != "" {
+						continue
+					}
+					resp := op.Responses[key]
+					if resp.Ref != "" {
+						resp = oas.Components.Responses[exc.Type]
+					}
+					tmp := bodyContent(resp.Content)
+					if ed.Name == "" {
+						tmp.Example = v
+					} else {
+						if tmp.Examples == nil {
+							tmp.Examples = make(map[string]*Example, 0)
+						}
+						tmp.Examples[ed.Name] = &Example{
+							Value: v,
+						}
+					}
+				}
+			} else if strings.HasSuffix(ed.Target, "Request") {
 				hdefName := sadl.Uncapitalize(ed.Target[:len(ed.Target)-7])
 				op := gen.FindOperation(oas, hdefName)
 				if op != nil {
 					for k, v := range ed.Example.(map[string]interface{}) {
 						if k == "body" {
-							tmp := op.RequestBody.Content["application/json"]
+							tmp := bodyContent(op.RequestBody.Content)
 							if ed.Name == "" {
 								tmp.Example = v
 							} else {
@@ -302,9 +389,8 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 						if k == "body" {
 							hact := model.FindHttp(hdefName)
 							//FIXME: somehow the example name must include the status to use here
-							//FIXME: currently the parser only handles Request and Response, not Exceptxxx
 							sstatus := fmt.Sprintf("%v", hact.Expected.Status)
-							tmp := op.Responses[sstatus].Content["application/json"]
+							tmp := bodyContent(op.Responses[sstatus].Content)
 							if ed.Name == "" {
 								tmp.Example = v
 							} else {
@@ -344,6 +430,159 @@ func (gen *Generator) ExportToOAS3() (*Model, error) {
 	return oas, nil
 }
 
+func exportAuthDef(ad *sadl.AuthDef) *SecurityScheme {
+	ss := &SecurityScheme{
+		Description: ad.Comment,
+	}
+	switch ad.Scheme {
+	case "apiKey":
+		ss.Type = "apiKey"
+		switch {
+		case ad.Header != "":
+			ss.In, ss.Name = "header", ad.Header
+		case ad.Query != "":
+			ss.In, ss.Name = "query", ad.Query
+		default:
+			ss.In, ss.Name = "cookie", ad.Cookie
+		}
+	case "bearer", "basic":
+		ss.Type = "http"
+		ss.Scheme = ad.Scheme
+		ss.BearerFormat = ad.Format
+	case "oauth2":
+		ss.Type = "oauth2"
+		scopes := make(map[string]string, 0)
+		for _, scope := range ad.Scopes {
+			scopes[scope] = ""
+		}
+		flow := &OAuthFlow{
+			AuthorizationURL: ad.AuthorizationUrl,
+			TokenURL:         ad.TokenUrl,
+			Scopes:           scopes,
+		}
+		ss.Flows = &OAuthFlows{}
+		switch {
+		case ad.AuthorizationUrl != "" && ad.TokenUrl != "":
+			ss.Flows.AuthorizationCode = flow
+		case ad.TokenUrl != "":
+			ss.Flows.ClientCredentials = flow
+		default:
+			ss.Flows.Implicit = flow
+		}
+	default:
+		ss.Type = ad.Scheme
+	}
+	return ss
+}
+
+// securityRequirements returns the alternative requirements of an operation. The scopes apply to the oauth2 schemes,
+// or to all of them if there are none of those.
+func securityRequirements(schemes []*sadl.AuthDef, scopes []string) []SecurityRequirement {
+	oauth2 := false
+	for _, ad := range schemes {
+		if ad.Scheme == "oauth2" {
+			oauth2 = true
+		}
+	}
+	var reqs []SecurityRequirement
+	for _, ad := range schemes {
+		if scopes != nil && (!oauth2 || ad.Scheme == "oauth2") {
+			reqs = append(reqs, SecurityRequirement{ad.Name: scopes})
+		} else {
+			reqs = append(reqs, SecurityRequirement{ad.Name: []string{}})
+		}
+	}
+	return reqs
+}
+
+// exceptionResponse returns the response for an exception, its body and any headers.
+func (gen *Generator) exceptionResponse(out *sadl.HttpExceptionSpec) (*Response, error) {
+	content := make(map[string]*MediaType)
+	comment := out.Comment
+	if comment == "" {
+		comment = "Exceptional response"
+	}
+	headers, err := gen.responseHeaders(out.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	resp := &Response{
+		Description: comment,
+		Headers:     headers,
+		Content:     content,
+	}
+	tr := &Schema{
+		Ref: "#/components/schemas/" + out.Type,
+	}
+	mt := &MediaType{
+		Schema: tr,
+	}
+	mediaType := "application/json"
+	for _, param := range out.Outputs {
+		if param.Header == "" && param.Cookie == "" {
+			mediaType = param.BodyMediaType()
+		}
+	}
+	content[mediaType] = mt
+	return resp, nil
+}
+
+// responseHeaders returns the headers of a response, or nil if it has none.
+func (gen *Generator) responseHeaders(outputs []*sadl.HttpParamSpec) (map[string]*Header, error) {
+	var headers map[string]*Header
+	for _, param := range outputs {
+		if param.Header != "" {
+			pschema, err := gen.oasSchema(&param.TypeSpec, "")
+			if err != nil {
+				return nil, err
+			}
+			header := &Header{
+				Description: param.Comment,
+				Schema:      pschema,
+			}
+			if headers == nil {
+				headers = make(map[string]*Header, 0)
+			}
+			headers[param.Header] = header
+		} else if param.Cookie != "" {
+			//OpenAPI cannot describe response cookies, only the header that sets them
+			if headers == nil {
+				headers = make(map[string]*Header, 0)
+			}
+			header, ok := headers["Set-Cookie"]
+			if !ok {
+				header = &Header{Schema: &Schema{Type: "string"}}
+				headers["Set-Cookie"] = header
+			}
+			desc := fmt.Sprintf("Sets the '%s' cookie", param.Cookie)
+			if param.Comment != "" {
+				desc = desc + ": " + param.Comment
+			}
+			if header.Description != "" {
+				desc = header.Description + ". " + desc
+			}
+			header.Description = desc
+		}
+	}
+	return headers, nil
+}
+
+// bodySchema returns the schema of a request or response body. Raw bytes, rather than JSON, are binary.
+func (gen *Generator) bodySchema(spec *sadl.HttpParamSpec) (*Schema, error) {
+	if !sadl.IsJsonMediaType(spec.MediaType) && !sadl.IsFormMediaType(spec.MediaType) && gen.Model.BaseType(spec.Type) == "Bytes" {
+		return &Schema{Type: "string", Format: "binary"}, nil
+	}
+	return gen.oasSchema(&spec.TypeSpec, "")
+}
+
+// bodyContent returns the media type of a body, of which SADL has only one.
+func bodyContent(content map[string]*MediaType) *MediaType {
+	for _, mt := range content {
+		return mt
+	}
+	return nil
+}
+
 func (gen *Generator) FindOperation(model *Model, opId string) *Operation {
 	for _, pathItem := range model.Paths {
 		var op *Operation
@@ -413,6 +652,13 @@ func (gen *Generator) exportTypeDef(td *sadl.TypeDef) (*Schema, error) {
 		return tmp, nil
 	case "Union":
 		return gen.exportUnionTypeDef(td)
+	case "Bytes":
+		otd := &Schema{
+			Description: td.Comment,
+			Type:        "string",
+			Format:      "byte",
+		}
+		return otd, nil
 	case "Timestamp":
 		tmp, err := gen.exportStringTypeDef(td)
 		if err != nil {
@@ -434,13 +680,13 @@ func (gen *Generator) exportStructTypeDef(td *sadl.TypeDef) (*Schema, error) {
 	properties := make(map[string]*Schema, 0)
 	for _, fd := range td.Fields {
 		if fd.Required {
-			required = append(required, fd.Name)
+			required = append(required, fd.WireName())
 		}
 		tr, err := gen.oasSchema(&fd.TypeSpec, "")
 		if err != nil {
 			return nil, err
 		}
-		properties[fd.Name] = tr
+		properties[fd.WireName()] = nullableSchema(tr, fd.Nullable)
 
 	}
 	schema.Required = required
@@ -448,13 +694,29 @@ func (gen *Generator) exportStructTypeDef(td *sadl.TypeDef) (*Schema, error) {
 	return schema, nil
 }
 
+func nullableSchema(schema *Schema, nullable bool) *Schema {
+	if !nullable {
+		return schema
+	}
+	if schema.Ref != "" {
+		//siblings of a $ref are ignored in OAS v3, so wrap it
+		return &Schema{
+			AllOf:    []*Schema{schema},
+			Nullable: true,
+		}
+	}
+	schema.Nullable = true
+	return schema
+}
+
 func (gen *Generator) exportUnionTypeDef(td *sadl.TypeDef) (*Schema, error) {
 	schema := &Schema{
 		Description: td.Comment,
 	}
 	for _, vd := range td.Variants {
-		v := &Schema{
-			Ref: "#/components/schemas/" + vd.Type,
+		v, err := gen.oasSchema(&vd.TypeSpec, "")
+		if err != nil {
+			return nil, err
 		}
 		schema.OneOf = append(schema.OneOf, v)
 	}
@@ -494,13 +756,6 @@ func (gen *Generator) exportStringTypeDef(td *sadl.TypeDef) (*Schema, error) {
 		return nil, err
 	}
 	otd.Description = td.Comment
-	if len(td.Values) > 0 {
-		e := make([]interface{}, 0)
-		for _, s := range td.Values {
-			e = append(e, s)
-		}
-		otd.Enum = e
-	}
 	return otd, nil
 }
 
@@ -511,7 +766,7 @@ func (gen *Generator) exportEnumTypeDef(td *sadl.TypeDef) (*Schema, error) {
 	}
 	e := make([]interface{}, 0)
 	for _, el := range td.Elements {
-		e = append(e, el.Symbol)
+		e = append(e, el.WireName())
 	}
 	otd.Enum = e
 	return otd, nil
@@ -594,6 +849,13 @@ func (gen *Generator) oasSchema(td *sadl.TypeSpec, name string) (*Schema, error)
 			tmp := uint64(*td.MaxSize)
 			tr.MaxLength = &tmp
 		}
+		if len(td.Values) > 0 {
+			e := make([]interface{}, 0)
+			for _, s := range td.Values {
+				e = append(e, s)
+			}
+			tr.Enum = e
+		}
 		return tr, nil
 	case "Timestamp":
 		tr := &Schema{
@@ -658,12 +920,16 @@ func (gen *Generator) oasSchema(td *sadl.TypeSpec, name string) (*Schema, error)
 				if err != nil {
 					return nil, err
 				}
-				f[fd.Name] = fieldSchema
+				f[fd.WireName()] = nullableSchema(fieldSchema, fd.Nullable)
 			}
 			sch.Properties = f
 			return sch, nil
 		}
 	default:
+		if td.HasConstraints() {
+			//a $ref cannot be further constrained, so the schema is that of the type, with the constraints applied
+			return gen.oasSchema(gen.Model.ConstrainedTypeSpec(td), "")
+		}
 		return &Schema{
 			Ref: "#/components/schemas/" + td.Type,
 		}, nil
@@ -679,3 +945,25 @@ func (gen *Generator) ExportToOAS2() (*oas2.OpenAPI, error) {
 	return oas2.ConvertFromV3(v3)
 }
 */
+
+// exportPagination returns the "x-pagination" extension of an action. Its output paths are relative to the body of the
+// response, which has no name in OpenAPI.
+func exportPagination(hdef *sadl.HttpDef) map[string]interface{} {
+	pg := hdef.Paginated
+	bodyPath := func(path string) string {
+		for _, out := range hdef.Expected.Outputs {
+			if out.Header == "" && out.Cookie == "" && strings.HasPrefix(path, out.Name+".") {
+				return path[len(out.Name)+1:]
+			}
+		}
+		return path
+	}
+	ext := map[string]interface{}{"inputToken": pg.InputToken, "outputToken": bodyPath(pg.OutputToken)}
+	if pg.PageSize != "" {
+		ext["pageSize"] = pg.PageSize
+	}
+	if pg.Items != "" {
+		ext["items"] = bodyPath(pg.Items)
+	}
+	return ext
+}
@@ -3,11 +3,15 @@ package openapi
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
+	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/boynton/sadl"
 	"github.com/ghodss/yaml"
@@ -21,10 +25,21 @@ func IsValidFile(path string) bool {
 }
 
 func Load(path string) (*Model, error) {
-	data, err := ioutil.ReadFile(path)
+	return LoadFS(nil, path)
+}
+
+// LoadFS loads the OpenAPI file at the path within the file system, or the OS file system if fsys is nil.
+func LoadFS(fsys fs.FS, path string) (*Model, error) {
+	data, err := sadl.ReadFile(fsys, path)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot read OpenAPI file: %v\n", err)
 	}
+	return decode(data, path)
+}
+
+// the path's extension determines whether the data is YAML or JSON
+func decode(data []byte, path string) (*Model, error) {
+	var err error
 	v3 := &Model{}
 	ext := filepath.Ext(path)
 	if ext == ".yaml" {
@@ -39,26 +54,36 @@ func Load(path string) (*Model, error) {
 }
 
 func Import(paths []string, conf *sadl.Data) (*sadl.Model, error) {
+	return ImportFS(nil, paths, conf)
+}
+
+// ImportFS imports the OpenAPI file from the file system, or the OS file system if fsys is nil.
+func ImportFS(fsys fs.FS, paths []string, conf *sadl.Data) (*sadl.Model, error) {
 	if len(paths) != 1 {
 		return nil, fmt.Errorf("Cannot merge multiple OpenAPI files")
 	}
-	path := paths[0]
-	name := path
-	n := strings.LastIndex(name, "/")
-	//	format := ""
-	if n >= 0 {
-		name = name[n+1:]
+	oas3, err := LoadFS(fsys, paths[0])
+	if err != nil {
+		return nil, err
 	}
-	n = strings.LastIndex(name, ".")
-	if n >= 0 {
-		//		format = name[n+1:]
-		name = name[:n]
-		name = strings.Replace(name, ".", "_", -1)
+	return toSadl(oas3, modelName(paths[0]))
+}
+
+// ImportReader imports OpenAPI read from r. The path's extension determines the format, and its base name the name
+// of the model.
+func ImportReader(r io.Reader, path string, conf *sadl.Data) (*sadl.Model, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
 	}
-	oas3, err := Load(path)
+	oas3, err := decode(data, path)
 	if err != nil {
 		return nil, err
 	}
+	return toSadl(oas3, modelName(path))
+}
+
+func toSadl(oas3 *Model, name string) (*sadl.Model, error) {
 	model, err := oas3.ToSadl(name)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot convert to SADL: %v\n", err)
@@ -67,6 +92,20 @@ func Import(paths []string, conf *sadl.Data) (*sadl.Model, error) {
 	return model, err
 }
 
+func modelName(path string) string {
+	name := path
+	n := strings.LastIndex(name, "/")
+	if n >= 0 {
+		name = name[n+1:]
+	}
+	n = strings.LastIndex(name, ".")
+	if n >= 0 {
+		name = name[:n]
+		name = strings.Replace(name, ".", "_", -1)
+	}
+	return name
+}
+
 /*
 func DetermineVersion(data []byte, format string) (string, error) {
 	var raw map[string]interface{}
@@ -124,7 +163,7 @@ var examples []*sadl.ExampleDef
 var methods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"} //to do: "PATCH", "OPTIONS", "TRACE"
 
 func (model *Model) ToSadl(name string) (*sadl.Model, error) {
-	annotations := make(map[string]string, 0)
+	annotations := make(map[string]interface{}, 0)
 	examples = nil
 	annotations["x_openapi_version"] = model.OpenAPI
 
@@ -141,6 +180,7 @@ func (model *Model) ToSadl(name string) (*sadl.Model, error) {
 		Comment: comment,
 		Version: model.Info.Version,
 	}
+	schema.Constants = importConstants(model.Extensions["x-sadl-constants"])
 	for name, oasSchema := range model.Components.Schemas {
 		name = validSadlName(name, oasSchema)
 		if name == "" {
@@ -173,7 +213,45 @@ func (model *Model) ToSadl(name string) (*sadl.Model, error) {
 		schema.Types = append(schema.Types, td)
 	}
 
+	if model.Components != nil {
+		var names []string
+		for name := range model.Components.SecuritySchemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ad := importSecurityScheme(name, model.Components.SecuritySchemes[name]); ad != nil {
+				schema.Auth = append(schema.Auth, ad)
+			}
+		}
+	}
+	for _, tag := range model.Tags {
+		if svc, ok := tag.Extensions["x-sadl-service"]; ok {
+			schema.Services = append(schema.Services, &sadl.ServiceDef{
+				Name:    tag.Name,
+				Comment: tag.Description,
+				Version: sadl.GetString(sadl.AsMap(svc), "version"),
+			})
+		}
+	}
 	httpBindings := true
+	common := model.commonResponses()
+	var statuses []string
+	for status := range common {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		resp := model.Components.Responses[common[status]]
+		if resp == nil {
+			return nil, fmt.Errorf("Unresolved response reference: %q", common[status])
+		}
+		exc, err := convertOasException("", status, resp)
+		if err != nil {
+			return nil, err
+		}
+		schema.Exceptions = append(schema.Exceptions, exc)
+	}
 	for tmpl, path := range model.Paths {
 		path2 := *path
 		for _, method := range methods {
@@ -183,10 +261,16 @@ func (model *Model) ToSadl(name string) (*sadl.Model, error) {
 					continue
 				}
 				if httpBindings {
-					hact, err := convertOasPath(tmpl, op, method)
+					op, sd := model.resolveService(op, schema.Services)
+					hact, err := convertOasPath(tmpl, model.resolveResponses(op, common), method)
 					if err != nil {
 						return nil, err
 					}
+					if sd != nil && len(schema.Services) > 1 {
+						//the actions of the only service need not name it
+						hact.Service = sd.Name
+					}
+					importSecurity(hact, op.Security, model.Security, schema.Auth)
 					schema.Http = append(schema.Http, hact)
 				}
 			}
@@ -254,23 +338,31 @@ func convertOasType(name string, oasSchema *Schema) (sadl.TypeSpec, error) {
 			//so we look for the case where all values look like identifiers, and call that an enum. Else a strings with accepted "values"
 			//perhaps the spirit of JSON Schema enums are just values, not what I think of as "enums", i.e. "a set of named values", per wikipedia.
 			//still, with symbolic values, perhaps the intent is to use proper enums, if only JSON Schema had them.
+			//values that are not symbols, i.e. "in-progress", get one made from them, with the value as the wire name,
+			//unless that makes two symbols the same.
 			isEnum := EnumTypes
 			var values []string
+			symbols := make(map[string]bool, 0)
 			for _, val := range oasSchema.Enum {
 				if s, ok := val.(string); ok {
 					values = append(values, s)
-					if !sadl.IsSymbol(s) {
+					sym := makeIdentifier(s)
+					if s == "" || symbols[sym] {
 						isEnum = false
 					}
+					symbols[sym] = true
 				} else {
 					return ts, fmt.Errorf("Error in OAS source: string enum value is not a string: %v", val)
 				}
 			}
 			if isEnum {
 				ts.Type = "Enum"
-				for _, sym := range values {
+				for _, val := range values {
 					el := &sadl.EnumElementDef{
-						Symbol: sym,
+						Symbol: makeIdentifier(val),
+					}
+					if el.Symbol != val {
+						el.Annotations = map[string]interface{}{"x_wire_name": val}
 					}
 					ts.Elements = append(ts.Elements, el)
 				}
@@ -284,6 +376,8 @@ func convertOasType(name string, oasSchema *Schema) (sadl.TypeSpec, error) {
 		if ts.Type == "String" {
 			if oasSchema.Format == "uuid" {
 				ts.Type = "UUID"
+			} else if oasSchema.Format == "byte" || oasSchema.Format == "binary" {
+				ts.Type = "Bytes"
 			} else if oasSchema.Format == "date-time" {
 				ts.Type = "Timestamp"
 			} else {
@@ -346,14 +440,23 @@ func convertOasType(name string, oasSchema *Schema) (sadl.TypeSpec, error) {
 		ts.Type = "Struct"
 		if oasSchema.Properties != nil {
 			req := oasSchema.Required
+			fnames := fieldNames(oasSchema.Properties)
 			for fname, fschema := range oasSchema.Properties {
 				fd := &sadl.StructFieldDef{
-					Name:    fname,
+					Name:    fnames[fname],
 					Comment: fschema.Description,
 				}
+				if fd.Name != fname {
+					//the JSON key is not a SADL symbol, i.e. "content-type" or "@id"
+					fd.Annotations = map[string]interface{}{"x_wire_name": fname}
+				}
 				if containsString(req, fname) {
 					fd.Required = true
 				}
+				fd.Nullable = fschema.Nullable
+				if fschema.Nullable && len(fschema.AllOf) == 1 && fschema.Type == "" {
+					fschema = fschema.AllOf[0]
+				}
 				fd.Type = oasTypeRef(fschema)
 				if fd.Type == "" {
 					fd.TypeSpec, err = convertOasType(name+"."+fname, fschema)
@@ -385,9 +488,81 @@ func uncapitalize(s string) string {
 	return strings.ToLower(s[0:1]) + s[1:]
 }
 
+// makeIdentifier makes a SADL symbol from a name that is not one, i.e. "content-type" becomes "contentType".
 func makeIdentifier(text string) string {
-	reg, _ := regexp.Compile("[^a-zA-Z_][^a-zA-Z_0-9]*")
-	return reg.ReplaceAllString(text, "")
+	var sb strings.Builder
+	upper := false
+	for _, ch := range text {
+		if sadl.IsSymbolChar(ch, sb.Len() == 0) {
+			if upper {
+				ch = unicode.ToUpper(ch)
+			}
+			sb.WriteRune(ch)
+			upper = false
+		} else if sb.Len() > 0 {
+			upper = true
+		}
+	}
+	if sb.Len() == 0 {
+		return "value"
+	}
+	return sb.String()
+}
+
+// importConstants returns the constants of a SADL model, as the exporter writes them in the x-sadl-constants extension.
+func importConstants(ext interface{}) []*sadl.ConstantDef {
+	constants := sadl.AsMap(ext)
+	var names []string
+	for name := range constants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []*sadl.ConstantDef
+	for _, name := range names {
+		c := sadl.AsMap(constants[name])
+		cd := &sadl.ConstantDef{
+			Name:    name,
+			Type:    sadl.GetString(c, "type"),
+			Comment: sadl.GetString(c, "description"),
+		}
+		switch val := c["value"].(type) {
+		case float64:
+			cd.Value, _ = sadl.ParseDecimal(strconv.FormatFloat(val, 'f', -1, 64))
+		case string:
+			cd.Value = &val
+		default:
+			cd.Value = val
+		}
+		result = append(result, cd)
+	}
+	return result
+}
+
+// fieldNames makes a SADL field name for each property of a schema. Properties that are not symbols get one made from
+// them, as enum values do, but made names cannot be the same as another: a suffix keeps them apart, i.e. "content-type"
+// and "content.type" become "contentType" and "contentType2".
+func fieldNames(props map[string]*Schema) map[string]string {
+	names := make(map[string]string, len(props))
+	taken := make(map[string]bool, len(props))
+	var made []string
+	for fname := range props {
+		if makeIdentifier(fname) == fname {
+			names[fname] = fname
+			taken[fname] = true
+		} else {
+			made = append(made, fname)
+		}
+	}
+	sort.Strings(made)
+	for _, fname := range made {
+		name := makeIdentifier(fname)
+		for i := 2; taken[name]; i++ {
+			name = makeIdentifier(fname) + strconv.Itoa(i)
+		}
+		names[fname] = name
+		taken[name] = true
+	}
+	return names
 }
 
 func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, error) {
@@ -398,17 +573,15 @@ func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, e
 		Comment: op.Summary,
 	}
 	if len(op.Tags) > 0 {
-		hact.Annotations = make(map[string]string, 0)
+		hact.Annotations = make(map[string]interface{}, 0)
 		//note: first tag is used as the "resource" name in SADL.
-		tmp := ""
+		var tmp []interface{}
 		rez := ""
 		for _, tag := range op.Tags {
 			if rez == "" {
 				rez = tag
-			} else if tmp == "" {
-				tmp = tag
 			} else {
-				tmp = tmp + "," + tag
+				tmp = append(tmp, tag)
 			}
 		}
 		hact.Resource = rez
@@ -433,14 +606,28 @@ func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, e
 			queries = append(queries, param.Name+"={"+name+"}")
 		case "path":
 			spec.Path = true
-			if strings.Index(path, "{"+name+"}") < 0 {
+			if strings.Index(path, "{"+param.Name+"+}") >= 0 {
+				//the API Gateway convention for a greedy path parameter
+				spec.Greedy = true
+				path = strings.Replace(path, "{"+param.Name+"+}", "{"+param.Name+"}", -1)
+			} else if greedy, _ := param.Extensions["x-sadl-greedy"].(bool); greedy {
+				spec.Greedy = true
+			}
+			if name != param.Name {
+				path = strings.Replace(path, "{"+param.Name+"}", "{"+name+"}", -1)
+			}
+			if spec.Greedy {
+				path = strings.Replace(path, "{"+name+"}", "{"+name+"+}", -1)
+			}
+			hact.Path = path
+			if strings.Index(path, "{"+name+"}") < 0 && !spec.Greedy {
 				fmt.Println("WARNING: path param is not in path template:", path, name)
 				panic("here")
 			}
 		case "header":
 			spec.Header = param.Name
 		case "cookie":
-			return nil, fmt.Errorf("Cookie params NYI: %v", sadl.AsString(param))
+			spec.Cookie = param.Name
 		}
 		spec.Type = oasTypeRef(param.Schema)
 		if spec.Type == "" {
@@ -453,10 +640,13 @@ func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, e
 				} else {
 					schref := param.Schema.Items
 					switch schref.Type {
-					case "string":
-						spec.Items = "String"
+					case "string", "integer", "number", "boolean":
+						spec.Items = sadlPrimitiveType(schref.Type)
 					default:
-						spec.Items = "Any"
+						spec.Items = oasTypeRef(schref)
+						if spec.Items == "" {
+							spec.Items = "Any"
+						}
 					}
 				}
 			}
@@ -474,6 +664,8 @@ func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, e
 			}
 		} else {
 		}
+		//an exploded array, i.e. "?tag=a&tag=b", is the default for query parameters
+		spec.Csv = param.In == "query" && param.Explode != nil && !*param.Explode
 		hact.Inputs = append(hact.Inputs, spec)
 	}
 	if len(queries) > 0 {
@@ -481,22 +673,32 @@ func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, e
 	}
 	if hact.Method == "POST" || hact.Method == "PUT" || hact.Method == "PATCH" {
 		if op.RequestBody != nil {
-			for contentType, mediadef := range op.RequestBody.Content {
-				if contentType == "application/json" { //hack
-					bodyType := oasTypeRef(mediadef.Schema)
-					if bodyType != "" {
-						spec := &sadl.HttpParamSpec{
-							StructFieldDef: sadl.StructFieldDef{
-								TypeSpec: sadl.TypeSpec{
-									Type: bodyType,
-								},
-								Comment:  op.RequestBody.Description,
-								Name:     "body",
-								Required: op.RequestBody.Required,
+			contentType, mediadef := bodyMediaType(op.RequestBody.Content)
+			if mediadef != nil && mediadef.Schema != nil {
+				bodyType := oasTypeRef(mediadef.Schema)
+				if bodyType == "" && contentType != "application/json" {
+					//raw content, i.e. an image, is an inline string schema
+					ts, err := convertOasType(hact.Name+".body", mediadef.Schema)
+					if err != nil {
+						return nil, err
+					}
+					bodyType = ts.Type
+				}
+				if bodyType != "" {
+					spec := &sadl.HttpParamSpec{
+						StructFieldDef: sadl.StructFieldDef{
+							TypeSpec: sadl.TypeSpec{
+								Type: bodyType,
 							},
-						}
-						hact.Inputs = append(hact.Inputs, spec)
+							Comment:  op.RequestBody.Description,
+							Name:     "body",
+							Required: op.RequestBody.Required,
+						},
+					}
+					if contentType != "application/json" {
+						spec.MediaType = contentType
 					}
+					hact.Inputs = append(hact.Inputs, spec)
 				}
 			}
 		}
@@ -531,81 +733,281 @@ func convertOasPath(path string, op *Operation, method string) (*sadl.HttpDef, e
 			Status:  int32(code),
 			Comment: eparam.Description,
 		}
-		for header, def := range eparam.Headers {
-			param := &sadl.HttpParamSpec{}
-			param.Header = header
-			param.Comment = def.Description
-			s := param.Header
-			//most app-defined headers start with "x-" or "X-". Strip that off for a more reasonable variable name.
-			if strings.HasPrefix(param.Header, "x-") || strings.HasPrefix(param.Header, "X-") {
-				s = s[2:]
-			}
-			param.Name = makeIdentifier(s)
-			schref := def.Schema
+		ex.Outputs, err = responseHeaders(hact.Name+".Expected.", eparam.Headers)
+		if err != nil {
+			return nil, err
+		}
+		if contentType, mediadef := bodyMediaType(eparam.Content); mediadef != nil {
+			result := &sadl.HttpParamSpec{}
+			result.Name = "body"
+			if contentType != "application/json" {
+				result.MediaType = contentType
+			}
+			schref := mediadef.Schema
 			if schref != nil {
 				if schref.Ref != "" {
-					param.Type = oasTypeRef(schref)
-				} else {
-					param.TypeSpec, err = convertOasType(hact.Name+".Expected."+param.Name, schref) //fix: example
-				}
-				ex.Outputs = append(ex.Outputs, param)
-			}
-		}
-		for contentType, mediadef := range eparam.Content {
-			if contentType == "application/json" { //hack
-				result := &sadl.HttpParamSpec{}
-				result.Name = "body"
-				schref := mediadef.Schema
-				if schref != nil {
-					if schref.Ref != "" {
-						result.Type = oasTypeRef(schref)
-					} else {
-						result.TypeSpec, err = convertOasType(hact.Name+".Expected.payload", schref) //fix: example
-					}
-					ex.Outputs = append(ex.Outputs, result)
+					result.Type = oasTypeRef(schref)
 				} else {
-					fmt.Println("HTTP Action has no expected result type:", sadl.Pretty(eparam))
+					result.TypeSpec, err = convertOasType(hact.Name+".Expected.payload", schref) //fix: example
 				}
+				ex.Outputs = append(ex.Outputs, result)
+			} else {
+				fmt.Println("HTTP Action has no expected result type:", sadl.Pretty(eparam))
 			}
 		}
 		hact.Expected = ex
 	}
 	for status, param := range op.Responses {
 		if status != expectedStatus {
-			//the status can be "default", or "4XX" (where 'X' is a wildcard) or "404". If the latter, it takes precedence.
-			//for SADL, not specifying the response is a bug. So "default" will be turned into "500". The wildcards
-			if status == "default" {
-				status = "0"
-			} else if strings.Index(status, "X") >= 0 {
-				panic("wildcard response codes not supported")
-			}
-			code, err := strconv.Atoi(status)
+			ex, err := convertOasException(hact.Name+".", status, param)
 			if err != nil {
-				return nil, fmt.Errorf("Invalid status code: %q", status)
-			}
-			ex := &sadl.HttpExceptionSpec{
-				Status:  int32(code),
-				Comment: param.Description,
-			}
-			//FIXME: sadl should allow response headers for exceptions, also.
-			for contentType, mediadef := range param.Content {
-				if contentType == "application/json" { //hack
-					schref := mediadef.Schema
-					if schref != nil {
-						if schref.Ref != "" {
-							ex.Type = oasTypeRef(schref)
-						} else {
-							panic("inline response types not yet supported")
+				return nil, err
+			}
+			hact.Exceptions = append(hact.Exceptions, ex)
+		}
+	}
+	hact.Paginated = importPagination(hact, op.Extensions["x-pagination"])
+	//tags: add `x_tags=["one","two"]` annotation
+	return hact, nil
+}
+
+// importPagination returns the pagination of an action from its "x-pagination" extension. The output paths in it are
+// relative to the body of the response, unless they name a header output.
+func importPagination(hact *sadl.HttpDef, ext interface{}) *sadl.HttpPaginatedSpec {
+	m := sadl.AsMap(ext)
+	if m == nil {
+		return nil
+	}
+	pg := &sadl.HttpPaginatedSpec{
+		InputToken:  sadl.GetString(m, "inputToken"),
+		OutputToken: sadl.GetString(m, "outputToken"),
+		PageSize:    sadl.GetString(m, "pageSize"),
+		Items:       sadl.GetString(m, "items"),
+	}
+	if hact.Expected != nil {
+		body := ""
+		outputs := make(map[string]bool)
+		for _, out := range hact.Expected.Outputs {
+			outputs[out.Name] = true
+			if out.Header == "" && out.Cookie == "" {
+				body = out.Name
+			}
+		}
+		bodyPath := func(path string) string {
+			if path == "" || body == "" || outputs[strings.Split(path, ".")[0]] {
+				return path
+			}
+			return body + "." + path
+		}
+		pg.OutputToken = bodyPath(pg.OutputToken)
+		pg.Items = bodyPath(pg.Items)
+	}
+	return pg
+}
+
+func importSecurityScheme(name string, ss *SecurityScheme) *sadl.AuthDef {
+	ad := &sadl.AuthDef{
+		Name:    name,
+		Comment: ss.Description,
+	}
+	switch ss.Type {
+	case "apiKey":
+		ad.Scheme = "apiKey"
+		switch ss.In {
+		case "query":
+			ad.Query = ss.Name
+		case "cookie":
+			ad.Cookie = ss.Name
+		default:
+			ad.Header = ss.Name
+		}
+	case "http":
+		ad.Scheme = strings.ToLower(ss.Scheme)
+		ad.Format = ss.BearerFormat
+		if ad.Scheme != "bearer" && ad.Scheme != "basic" {
+			fmt.Fprintf(os.Stderr, "[warning: http auth scheme %q of %q not supported]\n", ss.Scheme, name)
+			return nil
+		}
+	case "oauth2":
+		ad.Scheme = "oauth2"
+		if ss.Flows == nil {
+			return nil
+		}
+		for _, flow := range []*OAuthFlow{ss.Flows.AuthorizationCode, ss.Flows.ClientCredentials, ss.Flows.Implicit, ss.Flows.Password} {
+			if flow != nil {
+				ad.AuthorizationUrl = flow.AuthorizationURL
+				ad.TokenUrl = flow.TokenURL
+				for scope := range flow.Scopes {
+					ad.Scopes = append(ad.Scopes, scope)
+				}
+				sort.Strings(ad.Scopes)
+				break
+			}
+		}
+	case "mutualTLS":
+		ad.Scheme = "mutualTLS"
+	default:
+		fmt.Fprintf(os.Stderr, "[warning: security scheme %q of type %q not supported]\n", name, ss.Type)
+		return nil
+	}
+	return ad
+}
+
+// importSecurity sets the auth schemes and scopes of an action from the security requirements of its operation, or
+// else those of the document. Those of the service as a whole are not named, and an empty requirement makes the action
+// anonymous, as does the lack of any.
+func importSecurity(hact *sadl.HttpDef, security []SecurityRequirement, global []SecurityRequirement, schemes []*sadl.AuthDef) {
+	if security == nil {
+		security = global
+	}
+	if len(security) == 0 {
+		hact.Anonymous = len(schemes) > 0
+		return
+	}
+	var names []string
+	for _, req := range security {
+		if len(req) == 0 {
+			hact.Anonymous = true
+			return
+		}
+		for name, scopes := range req {
+			if !containsString(names, name) {
+				names = append(names, name)
+			}
+			for _, scope := range scopes {
+				if !containsString(hact.Scopes, scope) {
+					hact.Scopes = append(hact.Scopes, scope)
+				}
+			}
+		}
+	}
+	if len(names) == len(schemes) {
+		all := true
+		for _, ad := range schemes {
+			all = all && containsString(names, ad.Name)
+		}
+		if all {
+			return
+		}
+	}
+	hact.Auth = names
+}
+
+func convertOasException(context string, status string, param *Response) (*sadl.HttpExceptionSpec, error) {
+	//the status can be "default", or "4XX" (where 'X' is a wildcard) or "404". If the latter, it takes precedence.
+	//for SADL, not specifying the response is a bug. So "default" will be turned into "500". The wildcards
+	if status == "default" {
+		status = "0"
+	} else if strings.Index(status, "X") >= 0 {
+		panic("wildcard response codes not supported")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid status code: %q", status)
+	}
+	ex := &sadl.HttpExceptionSpec{
+		Status:  int32(code),
+		Comment: param.Description,
+	}
+	contentType, mediadef := bodyMediaType(param.Content)
+	if mediadef != nil && mediadef.Schema != nil {
+		if mediadef.Schema.Ref != "" {
+			ex.Type = oasTypeRef(mediadef.Schema)
+		} else {
+			panic("inline response types not yet supported")
+		}
+	}
+	if (len(param.Headers) > 0 || contentType != "application/json") && ex.Type != "" {
+		//the body and the headers are the outputs, as with the expected response
+		body := &sadl.HttpParamSpec{}
+		body.Name = "body"
+		body.Type = ex.Type
+		if contentType != "application/json" {
+			body.MediaType = contentType
+		}
+		headers, err := responseHeaders(context+"Except"+ex.Type+".", param.Headers)
+		if err != nil {
+			return nil, err
+		}
+		ex.Outputs = append([]*sadl.HttpParamSpec{body}, headers...)
+	}
+	return ex, nil
+}
+
+// commonResponses returns the shared responses, by status, that every operation refers to. These are the
+// exceptions common to all actions.
+func (model *Model) commonResponses() map[string]string {
+	var common map[string]string
+	for tmpl, path := range model.Paths {
+		if strings.HasPrefix(tmpl, "x-") {
+			continue
+		}
+		for _, method := range methods {
+			op := getPathOperation(path, method)
+			if op == nil {
+				continue
+			}
+			refs := make(map[string]string, 0)
+			for status, resp := range op.Responses {
+				if name := responseRefName(resp); name != "" && (common == nil || common[status] == name) {
+					refs[status] = name
+				}
+			}
+			common = refs
+		}
+	}
+	return common
+}
+
+func responseRefName(resp *Response) string {
+	if resp != nil && strings.HasPrefix(resp.Ref, "#/components/responses/") {
+		return resp.Ref[len("#/components/responses/"):]
+	}
+	return ""
+}
+
+// resolveResponses returns a copy of the operation with its shared responses resolved, and without the common ones.
+func (model *Model) resolveResponses(op *Operation, common map[string]string) *Operation {
+	resolved := *op
+	resolved.Responses = make(map[string]*Response, 0)
+	for status, resp := range op.Responses {
+		if name := responseRefName(resp); name != "" {
+			if common[status] == name {
+				continue
+			}
+			if shared, ok := model.Components.Responses[name]; ok {
+				resp = shared
+			}
+		}
+		resolved.Responses[status] = resp
+	}
+	return &resolved
+}
+
+// resolveService returns the service that an operation is tagged with, and a copy of the operation without that tag.
+// The base path of the service is what follows the URL of a server of the model in the URL of a server of the
+// operation.
+func (model *Model) resolveService(op *Operation, services []*sadl.ServiceDef) (*Operation, *sadl.ServiceDef) {
+	for i, tag := range op.Tags {
+		for _, sd := range services {
+			if sd.Name != tag {
+				continue
+			}
+			resolved := *op
+			resolved.Tags = append(append([]string{}, op.Tags[:i]...), op.Tags[i+1:]...)
+			if sd.Base == "" {
+				for _, server := range op.Servers {
+					for _, common := range model.Servers {
+						if strings.HasPrefix(server.URL, common.URL+"/") {
+							sd.Base = server.URL[len(common.URL):]
 						}
-						break
 					}
 				}
 			}
-			hact.Exceptions = append(hact.Exceptions, ex)
+			return &resolved, sd
 		}
 	}
-	//tags: add `x-tags="one,two"` annotation
-	return hact, nil
+	return op, nil
 }
 
 func getPathOperation(oasPathItem *PathItem, method string) *Operation {
@@ -729,3 +1131,53 @@ func responseTypeName(resp *Response) string {
 	}
 	return ""
 }
+
+// bodyMediaType returns the media type of a body to use, which is JSON if it is offered, or else the first of the others
+// that SADL can represent. A named schema in some other format, i.e. XML, cannot be.
+func bodyMediaType(content map[string]*MediaType) (string, *MediaType) {
+	if mediadef, ok := content["application/json"]; ok {
+		return "application/json", mediadef
+	}
+	var contentTypes []string
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	for _, contentType := range contentTypes {
+		mediadef := content[contentType]
+		if sadl.IsJsonMediaType(contentType) || sadl.IsFormMediaType(contentType) || mediadef.Schema == nil || mediadef.Schema.Ref == "" {
+			return contentType, mediadef
+		}
+	}
+	return "", nil
+}
+
+// responseHeaders returns the outputs for the headers of a response.
+func responseHeaders(context string, headers map[string]*Header) ([]*sadl.HttpParamSpec, error) {
+	var outputs []*sadl.HttpParamSpec
+	for header, def := range headers {
+		param := &sadl.HttpParamSpec{}
+		param.Header = header
+		param.Comment = def.Description
+		s := param.Header
+		//most app-defined headers start with "x-" or "X-". Strip that off for a more reasonable variable name.
+		if strings.HasPrefix(param.Header, "x-") || strings.HasPrefix(param.Header, "X-") {
+			s = s[2:]
+		}
+		param.Name = makeIdentifier(s)
+		schref := def.Schema
+		if schref != nil {
+			if schref.Ref != "" {
+				param.Type = oasTypeRef(schref)
+			} else {
+				var err error
+				param.TypeSpec, err = convertOasType(context+param.Name, schref) //fix: example
+				if err != nil {
+					return nil, err
+				}
+			}
+			outputs = append(outputs, param)
+		}
+	}
+	return outputs, nil
+}
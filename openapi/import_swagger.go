@@ -3,6 +3,8 @@ package openapi
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
@@ -17,10 +19,21 @@ func IsValidSwaggerFile(path string) bool {
 }
 
 func LoadSwagger(path string) (*Model, error) {
-	data, err := ioutil.ReadFile(path)
+	return LoadSwaggerFS(nil, path)
+}
+
+// LoadSwaggerFS loads the Swagger file at the path within the file system, or the OS file system if fsys is nil, and
+// converts it to OpenAPI v3.
+func LoadSwaggerFS(fsys fs.FS, path string) (*Model, error) {
+	data, err := sadl.ReadFile(fsys, path)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot read OpenAPI file: %v\n", err)
 	}
+	return decodeSwagger(data, path)
+}
+
+func decodeSwagger(data []byte, path string) (*Model, error) {
+	var err error
 	var v2 map[string]interface{}
 	ext := filepath.Ext(path)
 	if ext == ".yaml" {
@@ -288,31 +301,31 @@ func toV3License(v2 *sadl.Data) *License {
 }
 
 func ImportSwagger(paths []string, conf *sadl.Data) (*sadl.Model, error) {
+	return ImportSwaggerFS(nil, paths, conf)
+}
+
+// ImportSwaggerFS imports the Swagger file from the file system, or the OS file system if fsys is nil.
+func ImportSwaggerFS(fsys fs.FS, paths []string, conf *sadl.Data) (*sadl.Model, error) {
 	if len(paths) != 1 {
 		return nil, fmt.Errorf("Cannot merge multiple Swagger files")
 	}
-	path := paths[0]
-	name := path
-	n := strings.LastIndex(name, "/")
-	//	format := ""
-	if n >= 0 {
-		name = name[n+1:]
-	}
-	n = strings.LastIndex(name, ".")
-	if n >= 0 {
-		//		format = name[n+1:]
-		name = name[:n]
-		name = strings.Replace(name, ".", "_", -1)
+	oas3, err := LoadSwaggerFS(fsys, paths[0])
+	if err != nil {
+		return nil, err
 	}
-	name = strings.Replace(name, "-", "_", -1)
-	oas3, err := LoadSwagger(path)
+	return toSadl(oas3, strings.Replace(modelName(paths[0]), "-", "_", -1))
+}
+
+// ImportSwaggerReader imports Swagger read from r. The path's extension determines the format, and its base name the
+// name of the model.
+func ImportSwaggerReader(r io.Reader, path string, conf *sadl.Data) (*sadl.Model, error) {
+	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	model, err := oas3.ToSadl(name)
+	oas3, err := decodeSwagger(data, path)
 	if err != nil {
-		return nil, fmt.Errorf("Cannot convert to SADL: %v\n", err)
+		return nil, err
 	}
-	//err = model.ConvertInlineEnums()
-	return model, err
+	return toSadl(oas3, strings.Replace(modelName(path), "-", "_", -1))
 }
@@ -83,6 +83,7 @@ type Model struct {
 	Paths        map[string]*PathItem   `json:"paths,omitempty"`   //?change
 	Components   *Components            `json:"components,omitempty"`
 	Security     []SecurityRequirement  `json:"security,omitempty"`
+	Tags         []*Tag                 `json:"tags,omitempty"`
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty"`
 }
 
@@ -181,6 +182,7 @@ type Parameter struct {
 
 type Response struct {
 	Extensions  map[string]interface{} `json:"-"`
+	Ref         string                 `json:"$ref,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Headers     map[string]*Header     `json:"headers,omitempty"`
 	Content     Content                `json:"content,omitempty"`
@@ -337,6 +339,7 @@ type RequestBody struct {
 }
 
 type Tag struct {
+	Extensions   map[string]interface{} `json:"-"`
 	Name         string                 `json:"name,omitempty"`
 	Description  string                 `json:"description,omitempty"`
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty"`
@@ -411,8 +414,160 @@ func (model Model) MarshalJSON() ([]byte, error) {
 	if model.Security != nil {
 		tmp["security"] = model.Security
 	}
+	if model.Tags != nil {
+		tmp["tags"] = model.Tags
+	}
 	if model.ExternalDocs != nil {
 		tmp["externalDocs"] = model.ExternalDocs
 	}
 	return json.Marshal(tmp)
 }
+
+// the extensions of a model are preserved on unmarshal, they may have the constants of a SADL model
+func (model *Model) UnmarshalJSON(data []byte) error {
+	type plainModel Model
+	var m plainModel
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	for k, v := range raw {
+		if strings.HasPrefix(k, "x-") {
+			if m.Extensions == nil {
+				m.Extensions = make(map[string]interface{}, 0)
+			}
+			m.Extensions[k] = v
+		}
+	}
+	*model = Model(m)
+	return nil
+}
+
+// the extensions of a parameter are marshalled with its fields, they may mark it as a greedy path parameter
+func (param Parameter) MarshalJSON() ([]byte, error) {
+	type plainParameter Parameter
+	data, err := json.Marshal(plainParameter(param))
+	if err != nil || len(param.Extensions) == 0 {
+		return data, err
+	}
+	var tmp map[string]interface{}
+	err = json.Unmarshal(data, &tmp)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range param.Extensions {
+		tmp[k] = v
+	}
+	return json.Marshal(tmp)
+}
+
+func (param *Parameter) UnmarshalJSON(data []byte) error {
+	type plainParameter Parameter
+	var p plainParameter
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	for k, v := range raw {
+		if strings.HasPrefix(k, "x-") {
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]interface{}, 0)
+			}
+			p.Extensions[k] = v
+		}
+	}
+	*param = Parameter(p)
+	return nil
+}
+
+// the extensions of an operation are marshalled with its fields, they may describe its pagination
+func (op Operation) MarshalJSON() ([]byte, error) {
+	type plainOperation Operation
+	data, err := json.Marshal(plainOperation(op))
+	if err != nil || len(op.Extensions) == 0 {
+		return data, err
+	}
+	var tmp map[string]interface{}
+	err = json.Unmarshal(data, &tmp)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range op.Extensions {
+		tmp[k] = v
+	}
+	return json.Marshal(tmp)
+}
+
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	type plainOperation Operation
+	var o plainOperation
+	err := json.Unmarshal(data, &o)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	for k, v := range raw {
+		if strings.HasPrefix(k, "x-") {
+			if o.Extensions == nil {
+				o.Extensions = make(map[string]interface{}, 0)
+			}
+			o.Extensions[k] = v
+		}
+	}
+	*op = Operation(o)
+	return nil
+}
+
+func (tag Tag) MarshalJSON() ([]byte, error) {
+	tmp := make(map[string]interface{}, 0)
+	for k, v := range tag.Extensions {
+		tmp[k] = v
+	}
+	tmp["name"] = tag.Name
+	if tag.Description != "" {
+		tmp["description"] = tag.Description
+	}
+	if tag.ExternalDocs != nil {
+		tmp["externalDocs"] = tag.ExternalDocs
+	}
+	return json.Marshal(tmp)
+}
+
+// the extensions of a tag are preserved on unmarshal, they may mark it as a SADL service
+func (tag *Tag) UnmarshalJSON(data []byte) error {
+	type plainTag Tag
+	var t plainTag
+	err := json.Unmarshal(data, &t)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	for k, v := range raw {
+		if strings.HasPrefix(k, "x-") {
+			if t.Extensions == nil {
+				t.Extensions = make(map[string]interface{}, 0)
+			}
+			t.Extensions[k] = v
+		}
+	}
+	*tag = Tag(t)
+	return nil
+}
@@ -3,6 +3,7 @@ package openapi
 import (
 	"fmt"
 	"testing"
+	"testing/fstest"
 
 	"github.com/boynton/sadl"
 	"github.com/ghodss/yaml"
@@ -74,3 +75,440 @@ func ToYAML(obj interface{}) string {
 	}
 	return string(b)
 }
+
+func TestNullableRoundTrip(test *testing.T) {
+	src := `
+type Bar Struct {
+   x Int32
+}
+type Foo Struct {
+   s String (nullable)
+   b Bar (required, nullable)
+   n Int32
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	td := model2.FindType("Foo")
+	if td == nil {
+		test.Fatalf("Type Foo not preserved: %s", sadl.Pretty(model2))
+	}
+	for _, fd := range td.Fields {
+		expected := fd.Name != "n"
+		if fd.Nullable != expected {
+			test.Errorf("Field %s nullable should be %v: %s", fd.Name, expected, sadl.Pretty(fd))
+		}
+		if fd.Name == "b" && fd.Type != "Bar" {
+			test.Errorf("Field b type not preserved: %s", sadl.Pretty(fd))
+		}
+	}
+}
+
+func TestImportFS(test *testing.T) {
+	fsys := fstest.MapFS{
+		"specs/tiny.yaml": &fstest.MapFile{Data: []byte(`openapi: "3.0.0"
+info:
+  title: Tiny
+  version: "1"
+paths: {}
+components:
+  schemas:
+    Thing:
+      type: object
+      properties:
+        name:
+          type: string
+`)},
+	}
+	model, err := ImportFS(fsys, []string{"specs/tiny.yaml"}, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if model.Name != "Tiny" || model.FindType("Thing") == nil {
+		test.Errorf("Bad import from file system: %s", sadl.Pretty(model))
+	}
+}
+
+func TestConstantsRoundTrip(test *testing.T) {
+	src := `
+const MaxLimit Int32 = 100 // the largest page
+const Greeting String = "hello"
+type Page Struct {
+   limit Int32 (max=MaxLimit)
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	data, err := yaml.Marshal(oas)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas = &Model{}
+	err = yaml.Unmarshal(data, oas)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	max := model2.FindConstant("MaxLimit")
+	if max == nil || max.Type != "Int32" || sadl.ToString(max.Value) != "100" || max.Comment != "the largest page" {
+		test.Errorf("Number constant not imported: %s", sadl.Pretty(max))
+	}
+	if greeting := model2.FindConstant("Greeting"); greeting == nil || sadl.ToString(greeting.Value) != `"hello"` {
+		test.Errorf("String constant not imported: %s", sadl.Pretty(greeting))
+	}
+}
+
+func TestWireNameRoundTrip(test *testing.T) {
+	src := `
+type Doc Struct {
+   contentType String (json="content-type", required)
+   id String (json="@id")
+   legacy_name String
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	schema := oas.Components.Schemas["Doc"]
+	if _, ok := schema.Properties["content-type"]; !ok || schema.Required[0] != "content-type" {
+		test.Errorf("Expected the wire names as properties: %s", sadl.Pretty(schema))
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	for _, fd := range model2.FindType("Doc").Fields {
+		if fd.WireName() == "content-type" && fd.Name != "contentType" || fd.WireName() == "@id" && fd.Name != "id" {
+			test.Errorf("Wire name not mapped back to a field name: %s", sadl.Pretty(fd))
+		}
+	}
+}
+
+func TestWireNameCollision(test *testing.T) {
+	src := `
+type Doc Struct {
+   contentType String
+   dashed String (json="content-type")
+   dotted String (json="content.type")
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	names := make(map[string]string, 0)
+	for _, fd := range model2.FindType("Doc").Fields {
+		if prev, ok := names[fd.Name]; ok {
+			test.Errorf("Properties %q and %q imported as the same field %q", prev, fd.WireName(), fd.Name)
+		}
+		names[fd.Name] = fd.WireName()
+	}
+	if names["contentType"] != "contentType" || names["contentType2"] != "content-type" || names["contentType3"] != "content.type" {
+		test.Errorf("Unexpected field names for the properties: %v", names)
+	}
+}
+
+func TestEnumWireNameRoundTrip(test *testing.T) {
+	src := `
+type Status Enum {
+   inProgress (json="in-progress")
+   done
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if e := oas.Components.Schemas["Status"].Enum; len(e) != 2 || e[0] != "in-progress" {
+		test.Errorf("Expected the wire names as enum values: %v", e)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	td := model2.FindType("Status")
+	if td.Type != "Enum" || td.Elements[0].Symbol != "inProgress" || td.Elements[0].WireName() != "in-progress" || td.Elements[1].WireName() != "done" {
+		test.Errorf("Enum wire names not imported: %s", sadl.Pretty(td))
+	}
+}
+
+func TestGreedyRoundTrip(test *testing.T) {
+	src := `
+http GET "/files/{key+}" (action=getFile) {
+   key String
+   expect 200 {
+      body String
+   }
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if oas.Paths["/files/{key}"] == nil {
+		test.Fatalf("Expected the greedy label to be exported as a path variable: %s", sadl.Pretty(oas.Paths))
+	}
+	data, err := yaml.Marshal(oas)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err = decode(data, "greedy.yaml")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	hact := model2.FindHttp("getFile")
+	if hact.Path != "/files/{key+}" || !hact.Inputs[0].Greedy {
+		test.Errorf("Greedy path parameter not imported: %s", sadl.Pretty(hact))
+	}
+}
+
+func TestCookieRoundTrip(test *testing.T) {
+	src := `
+type Profile Struct {
+   name String
+}
+http GET "/profile" (action=getProfile) {
+   session String (cookie="sid")
+   expect 200 {
+      profile Profile
+   }
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	param := oas.Paths["/profile"].Get.Parameters[0]
+	if param.In != "cookie" || param.Name != "sid" {
+		test.Errorf("Expected a cookie parameter: %s", sadl.Pretty(param))
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if in := model2.FindHttp("getProfile").Inputs[0]; in.Cookie != "sid" {
+		test.Errorf("Cookie parameter not imported: %s", sadl.Pretty(in))
+	}
+}
+
+func TestCommonExceptionRoundTrip(test *testing.T) {
+	src := `
+type BadRequest Struct {
+   message String
+}
+type Item Struct {
+   id String
+}
+except 400 BadRequest
+http GET "/items/{id}" (action=getItem) {
+   id String
+   expect 200 {
+      item Item
+   }
+}
+http DELETE "/items/{id}" (action=deleteItem) {
+   id String
+   expect 204
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if resp := oas.Paths["/items/{id}"].Get.Responses["400"]; resp.Ref != "#/components/responses/BadRequest" {
+		test.Errorf("Expected a reference to a shared response: %s", sadl.Pretty(resp))
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if len(model2.Exceptions) != 1 || model2.Exceptions[0].Type != "BadRequest" || len(model2.FindHttp("getItem").Exceptions) != 0 {
+		test.Errorf("Common exception not imported: %s", sadl.Pretty(model2.Exceptions))
+	}
+}
+
+func TestAuthRoundTrip(test *testing.T) {
+	src := `
+auth Jwt bearer (format="JWT")
+auth OAuth oauth2 (tokenUrl="https://example.com/token", scopes=["read"])
+http GET "/items" (action=listItems, scopes=["read"]) {
+   expect 204
+}
+http GET "/health" (action=health, auth=[]) {
+   expect 204
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if ss := oas.Components.SecuritySchemes["Jwt"]; ss == nil || ss.Type != "http" || ss.Scheme != "bearer" {
+		test.Errorf("Expected a bearer security scheme: %s", sadl.Pretty(oas.Components.SecuritySchemes))
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if len(model2.Auth) != 2 || model2.FindAuth("OAuth").TokenUrl != "https://example.com/token" {
+		test.Errorf("Auth schemes not imported: %s", sadl.Pretty(model2.Auth))
+	}
+	if hact := model2.FindHttp("listItems"); len(hact.Auth) != 0 || len(hact.Scopes) != 1 {
+		test.Errorf("Action scopes not imported: %s", sadl.Pretty(hact))
+	}
+	if hact := model2.FindHttp("health"); !hact.Anonymous {
+		test.Errorf("Anonymous action not imported: %s", sadl.Pretty(hact))
+	}
+	//an operation without its own security has that of the document, which requires nothing if it is missing
+	oas.Paths["/items"].Get.Security = nil
+	oas.Security = []SecurityRequirement{{"Jwt": []string{}}}
+	model2, err = oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if hact := model2.FindHttp("listItems"); len(hact.Auth) != 1 || hact.Auth[0] != "Jwt" {
+		test.Errorf("Document security not imported: %s", sadl.Pretty(hact))
+	}
+	oas.Security = nil
+	model2, err = oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if hact := model2.FindHttp("listItems"); !hact.Anonymous {
+		test.Errorf("Action without any security should be anonymous: %s", sadl.Pretty(hact))
+	}
+}
+
+func TestServiceRoundTrip(test *testing.T) {
+	src := `
+service Store (version="2.1", base="/store")
+service Admin
+http GET "/items" (action=listItems, service=Store) {
+   expect 204
+}
+http DELETE "/items" (action=clearItems, service=Admin) {
+   expect 204
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	data, err := yaml.Marshal(oas)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err = decode(data, "services.yaml")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if sd := model2.FindService("Store"); sd == nil || sd.Version != "2.1" || sd.Base != "/store" {
+		test.Errorf("Service not imported: %s", sadl.Pretty(model2.Services))
+	}
+	if hact := model2.FindHttp("clearItems"); hact.Service != "Admin" || hact.Resource != "" {
+		test.Errorf("Action service not imported: %s", sadl.Pretty(hact))
+	}
+}
+
+func TestPaginationRoundTrip(test *testing.T) {
+	src := `
+type ItemListing Struct {
+   items Array<String>
+   next String
+}
+http GET "/items?limit={limit}&after={after}" (action=listItems, paginated={"inputToken": "after", "outputToken": "listing.next", "pageSize": "limit", "items": "listing.items"}) {
+   limit Int32
+   after String
+   expect 200 {
+      listing ItemListing
+   }
+}
+`
+	model, err := sadl.ParseSadlString(src, emptyConfig)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err := NewGenerator(model, emptyConfig).ExportToOAS3()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	data, err := yaml.Marshal(oas)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	oas, err = decode(data, "pagination.yaml")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model2, err := oas.ToSadl("test")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	pg := model2.FindHttp("listItems").Paginated
+	if pg == nil || pg.InputToken != "after" || pg.OutputToken != "body.next" || pg.PageSize != "limit" || pg.Items != "body.items" {
+		test.Errorf("Pagination not imported: %s", sadl.Pretty(pg))
+	}
+}
@@ -1,8 +1,12 @@
 package sadl
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"strconv"
 	"strings"
@@ -11,27 +15,67 @@ import (
 )
 
 func ParseSadlFile(path string, conf *Data, extensions ...Extension) (*Model, error) {
-	p, err := parseFileNoValidate(path, conf, extensions)
+	return ParseSadl(nil, path, conf, extensions...)
+}
+
+// ParseSadl parses the SADL file at the path within the file system, i.e. an embed.FS. Included files are read from
+// the same file system. If fsys is nil, the OS file system is used.
+func ParseSadl(fsys fs.FS, path string, conf *Data, extensions ...Extension) (*Model, error) {
+	p, err := parseFileNoValidate(fsys, path, conf, extensions)
 	if err != nil {
 		return nil, err
 	}
 	return p.Validate()
 }
 
+// ParseSadlReader parses the SADL source read from r. The path is used when reporting errors, it need not exist.
+func ParseSadlReader(r io.Reader, path string, conf *Data, extensions ...Extension) (*Model, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewParser(path, string(src), conf).Parse(extensions)
+}
+
 func LoadModel(path string) (*Model, error) {
-	data, err := ioutil.ReadFile(path)
+	return LoadModelFS(nil, path)
+}
+
+// LoadModelFS loads a model in its JSON representation from the file system, or the OS file system if fsys is nil.
+func LoadModelFS(fsys fs.FS, path string) (*Model, error) {
+	data, err := ReadFile(fsys, path)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot read file %q: %v\n", path, err)
 	}
 	var schema Schema
-	err = json.Unmarshal(data, &schema)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&schema)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot parse file %q: %v\n", path, err)
 	}
-	if schema.Sadl == "" {
+	err = checkSchemaVersion(&schema)
+	if err == nil {
+		err = migrateSchema(&schema)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("Cannot load file %q: %v\n", path, err)
 	}
-	return NewModel(&schema)
+	//JSON ASTs from other tools are held to the same checks as parsed source
+	return validateSchema(&schema, path, fsys)
+}
+
+// validateSchema makes a model of a schema that was not parsed, and validates it as the parser would.
+func validateSchema(schema *Schema, path string, fsys fs.FS) (*Model, error) {
+	model, err := NewModel(schema)
+	if err != nil {
+		return nil, err
+	}
+	p := NewParser(path, "", NewData())
+	p.fsys = fsys
+	p.schema = &model.Schema
+	p.model = model
+	return p.Validate()
 }
 
 func IsValidFile(path string) bool {
@@ -39,19 +83,30 @@ func IsValidFile(path string) bool {
 		return true
 	}
 	if strings.HasSuffix(path, ".json") {
-		_, err := LoadModel(path)
-		return err == nil
+		//only check that it is a SADL AST, so that loading it reports any problems with the model
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		var schema Schema
+		return json.Unmarshal(data, &schema) == nil && schema.Sadl != ""
 	}
 	return false
 }
 
 func ParseSadlString(src string, conf *Data, extensions ...Extension) (*Model, error) {
-	p := &Parser{
+	return NewParser("", src, conf).Parse(extensions)
+}
+
+// NewParser returns a parser for the source text. The path is where the source came from, it is used when reporting
+// errors and need not exist.
+func NewParser(path string, src string, conf *Data) *Parser {
+	return &Parser{
 		scanner: scanner.NewScanner(strings.NewReader(src)),
+		path:    path,
 		source:  src,
 		conf:    conf,
 	}
-	return p.Parse(extensions)
 }
 
 //----------------
@@ -61,13 +116,19 @@ type Parser struct {
 	source         string
 	conf           *Data
 	scanner        *scanner.Scanner
+	fsys           fs.FS
 	model          *Model
 	schema         *Schema
 	lastToken      *scanner.Token
 	prevLastToken  *scanner.Token
 	ungottenToken  *scanner.Token
+	directiveToken *scanner.Token
+	atEOF          bool
+	diagnostics    Diagnostics
 	currentComment string
 	extensions     map[string]Extension
+	extensionOrder []Extension
+	declared       []*ConstantDef
 }
 
 type Extension interface {
@@ -77,18 +138,41 @@ type Extension interface {
 	Validate(p *Parser) error
 }
 
-func parseFileNoValidate(path string, conf *Data, extensions []Extension) (*Parser, error) {
-	b, err := ioutil.ReadFile(path)
+// An OptionExtension also accepts options of its own in the parenthesized options of definitions. Options returns the
+// names accepted for the target, which is one of "type", "field", or "http". ParseOption is called just after the
+// option name, and returns the value of the option, which is stored in the annotations of the definition under that
+// name.
+type OptionExtension interface {
+	Extension
+	Options(target string) []string
+	ParseOption(p *Parser, target string, name string) (interface{}, error)
+}
+
+// An HttpExtension also accepts statements of its own inside http blocks. Statements returns the keywords that begin
+// them. ParseStatement is called just after the keyword, and returns the value of the statement, which is stored in
+// the statements of the http definition under the keyword. The statement is ended by the parser. UnparseStatement is
+// its inverse, and returns the source that follows the keyword for the value, so that the statement can be decompiled.
+type HttpExtension interface {
+	Extension
+	Statements() []string
+	ParseStatement(p *Parser, op *HttpDef, keyword string) (interface{}, error)
+	UnparseStatement(keyword string, value interface{}) string
+}
+
+// A TransformExtension also rewrites the model after it has been validated. Transforms are run in the order the
+// extensions were given.
+type TransformExtension interface {
+	Extension
+	Transform(model *Model) error
+}
+
+func parseFileNoValidate(fsys fs.FS, path string, conf *Data, extensions []Extension) (*Parser, error) {
+	b, err := ReadFile(fsys, path)
 	if err != nil {
 		return nil, err
 	}
-	src := string(b)
-	p := &Parser{
-		scanner: scanner.NewScanner(strings.NewReader(src)),
-		path:    path,
-		source:  src,
-		conf:    conf,
-	}
+	p := NewParser(path, string(b), conf)
+	p.fsys = fsys
 	err = p.ParseNoValidate(extensions)
 	if err != nil {
 		return nil, err
@@ -121,6 +205,7 @@ func (p *Parser) GetToken() *scanner.Token {
 	tok := p.scanner.Scan()
 	for {
 		if tok.Type == scanner.EOF {
+			p.atEOF = true
 			return nil //fixme
 		} else if tok.Type != scanner.BLOCK_COMMENT {
 			break
@@ -135,7 +220,7 @@ func (p *Parser) GetToken() *scanner.Token {
 func (p *Parser) Source() string {
 	source := p.source
 	if p.path != "" && source == "" {
-		data, err := ioutil.ReadFile(p.path)
+		data, err := ReadFile(p.fsys, p.path)
 		if err == nil {
 			source = string(data)
 		}
@@ -172,6 +257,7 @@ func (p *Parser) ParseNoValidate(extensions []Extension) error {
 	if p.schema.Namespace == "" {
 		p.schema.Namespace = p.conf.GetString("namespace")
 	}
+	p.declareConstants()
 	comment := ""
 	for {
 		var err error
@@ -181,6 +267,7 @@ func (p *Parser) ParseNoValidate(extensions []Extension) error {
 		}
 		switch tok.Type {
 		case scanner.SYMBOL:
+			p.directiveToken = tok
 			switch tok.Text {
 			case "name":
 				err = p.parseNameDirective(comment)
@@ -190,10 +277,22 @@ func (p *Parser) ParseNoValidate(extensions []Extension) error {
 				err = p.parseVersionDirective(comment)
 			case "type":
 				err = p.parseTypeDirective(comment)
+			case "const":
+				err = p.parseConstDirective(comment)
+			case "annotation":
+				err = p.parseAnnotationDirective(comment)
 			case "example":
 				err = p.parseExampleDirective(comment)
 			case "base":
 				err = p.parseBaseDirective(comment)
+			case "except":
+				err = p.parseExceptDirective(comment)
+			case "auth":
+				err = p.parseAuthDirective(comment)
+			case "service":
+				err = p.parseServiceDirective(comment)
+			case "resource":
+				err = p.parseResourceDirective(comment)
 			case "operation":
 				err = p.parseOperationDirective(comment)
 			case "http":
@@ -222,15 +321,20 @@ func (p *Parser) ParseNoValidate(extensions []Extension) error {
 		case scanner.NEWLINE:
 			/* ignore */
 		default:
-			return p.expectedDirectiveError()
+			err = p.expectedDirectiveError()
 		}
 		if err != nil {
-			return err
+			p.report(p.ErrorAt(p.tokenSpan(p.directiveToken), err))
+			p.skipToNextDirective()
+			comment = ""
 		}
 	}
 	var err error
 	p.model, err = NewModel(p.schema)
 	p.schema = nil
+	if len(p.diagnostics) > 0 {
+		return p.diagnostics
+	}
 	if err != nil {
 		return err
 	}
@@ -243,6 +347,55 @@ func (p *Parser) ParseNoValidate(extensions []Extension) error {
 	return nil
 }
 
+// after an error, skip ahead to the next top-level directive, i.e. a directive name at the start of a line, so that
+// parsing can continue and report any further errors.
+func (p *Parser) skipToNextDirective() {
+	if p.atEOF {
+		return
+	}
+	tok := p.lastToken
+	atLineStart := p.prevLastToken == nil || p.prevLastToken.Type == scanner.NEWLINE
+	for tok != nil {
+		if atLineStart && tok != p.directiveToken && tok.Type == scanner.SYMBOL && tok.Start == 1 && p.isDirective(tok.Text) {
+			p.UngetToken()
+			return
+		}
+		atLineStart = tok.Type == scanner.NEWLINE
+		tok = p.GetToken()
+	}
+}
+
+func (p *Parser) isDirective(name string) bool {
+	switch name {
+	case "name", "namespace", "version", "type", "const", "annotation", "example", "base", "except", "auth", "service", "resource", "operation", "http", "include":
+		return true
+	}
+	if strings.HasPrefix(name, "x_") {
+		return true
+	}
+	_, ok := p.extensions[name]
+	return ok
+}
+
+// after an error in a struct field, skip the rest of its line so that the following fields can still be parsed.
+// Returns false if the error cannot be recovered from.
+func (p *Parser) recoverField(err error) bool {
+	if p.atEOF {
+		return false
+	}
+	p.report(err)
+	for tok := p.lastToken; tok != nil; tok = p.GetToken() {
+		switch tok.Type {
+		case scanner.NEWLINE:
+			return true
+		case scanner.CLOSE_BRACE:
+			p.UngetToken()
+			return true
+		}
+	}
+	return true
+}
+
 func (p *Parser) parseNamespaceDirective(comment string) error {
 	p.schema.Comment = p.MergeComment(p.schema.Comment, comment)
 	ns := ""
@@ -295,6 +448,150 @@ func (p *Parser) parseVersionDirective(comment string) error {
 	}
 }
 
+// an exception common to all http actions, unless an action has its own exception of that status or type. Without a
+// status, it is the default exception, i.e. the error envelope of any other error response.
+func (p *Parser) parseExceptDirective(comment string) error {
+	exc, err := p.parseHttpExceptionSpec(p.schema.Exceptions, comment)
+	if err != nil {
+		return err
+	}
+	for _, other := range p.schema.Exceptions {
+		if other.Status == exc.Status {
+			return p.Error(fmt.Sprintf("Duplicate common exception status: %d", exc.Status))
+		}
+	}
+	for _, out := range exc.Outputs {
+		if out.Header == "" && out.Cookie == "" {
+			out.Required = true
+		}
+	}
+	p.schema.Exceptions = append(p.schema.Exceptions, exc)
+	return nil
+}
+
+func (p *Parser) parseAuthDirective(comment string) error {
+	name, err := p.ExpectIdentifier()
+	if err != nil {
+		return err
+	}
+	if p.findAuth(name) != nil {
+		return p.Error("Duplicate auth scheme: " + name)
+	}
+	scheme, err := p.ExpectIdentifier()
+	if err != nil {
+		return err
+	}
+	var acceptable []string
+	switch scheme {
+	case "apiKey":
+		acceptable = []string{"header", "query", "cookie"}
+	case "bearer":
+		acceptable = []string{"format"}
+	case "oauth2":
+		acceptable = []string{"authorizationurl", "tokenurl", "scopes"}
+	case "basic", "mutualTLS":
+	default:
+		return p.Error("Unknown auth scheme: " + scheme)
+	}
+	options, err := p.parseOptions("auth", "auth", acceptable)
+	if err != nil {
+		return err
+	}
+	ad := &AuthDef{
+		Name:             name,
+		Scheme:           scheme,
+		Header:           options.Header,
+		Query:            options.Query,
+		Cookie:           options.Cookie,
+		Format:           options.Format,
+		AuthorizationUrl: options.AuthorizationUrl,
+		TokenUrl:         options.TokenUrl,
+		Scopes:           options.Scopes,
+		Annotations:      options.Annotations,
+	}
+	ad.Span = p.spanFrom(p.directiveToken)
+	ad.Comment, err = p.EndOfStatement(comment)
+	p.schema.Auth = append(p.schema.Auth, ad)
+	return err
+}
+
+func (p *Parser) findAuth(name string) *AuthDef {
+	for _, ad := range p.schema.Auth {
+		if ad.Name == name {
+			return ad
+		}
+	}
+	return nil
+}
+
+// a service groups the http actions that name it, with its own version and base path
+func (p *Parser) parseServiceDirective(comment string) error {
+	name, err := p.ExpectIdentifier()
+	if err != nil {
+		return err
+	}
+	if p.findService(name) != nil {
+		return p.Error("Duplicate service: " + name)
+	}
+	options, err := p.parseOptions("service", "service", []string{"version", "base"})
+	if err != nil {
+		return err
+	}
+	if options.Base != "" && !strings.HasPrefix(options.Base, "/") {
+		return p.Error("Bad base path value: " + options.Base)
+	}
+	sd := &ServiceDef{
+		Name:        name,
+		Version:     options.Version,
+		Base:        options.Base,
+		Annotations: options.Annotations,
+	}
+	sd.Span = p.spanFrom(p.directiveToken)
+	sd.Comment, err = p.EndOfStatement(comment)
+	p.schema.Services = append(p.schema.Services, sd)
+	return err
+}
+
+func (p *Parser) findService(name string) *ServiceDef {
+	for _, sd := range p.schema.Services {
+		if sd.Name == name {
+			return sd
+		}
+	}
+	return nil
+}
+
+// a resource binds http actions to its lifecycle, they are identified by the path variables named as its identifiers
+func (p *Parser) parseResourceDirective(comment string) error {
+	name, err := p.ExpectIdentifier()
+	if err != nil {
+		return err
+	}
+	for _, rd := range p.schema.Resources {
+		if rd.Name == name {
+			return p.Error("Duplicate resource: " + name)
+		}
+	}
+	options, err := p.parseOptions("resource", "resource", []string{"identifiers", "create", "read", "update", "delete", "list"})
+	if err != nil {
+		return err
+	}
+	rd := &ResourceDef{
+		Name:        name,
+		Identifiers: options.Identifiers,
+		Create:      options.Create,
+		Read:        options.Read,
+		Update:      options.Update,
+		Delete:      options.Delete,
+		List:        options.List,
+		Annotations: options.Annotations,
+	}
+	rd.Span = p.spanFrom(p.directiveToken)
+	rd.Comment, err = p.EndOfStatement(comment)
+	p.schema.Resources = append(p.schema.Resources, rd)
+	return err
+}
+
 func (p *Parser) parseBaseDirective(comment string) error {
 	p.schema.Comment = p.MergeComment(p.schema.Comment, comment)
 	base, err := p.ExpectString()
@@ -308,16 +605,12 @@ func (p *Parser) parseBaseDirective(comment string) error {
 }
 
 func (p *Parser) extensionList() []Extension {
-	var ext []Extension
-	for _, e := range p.extensions {
-		ext = append(ext, e)
-	}
-	return ext
+	return p.extensionOrder
 }
 
-func (p *Parser) addAnnotation(annos map[string]string, name, val string) map[string]string {
+func (p *Parser) addAnnotation(annos map[string]interface{}, name string, val interface{}) map[string]interface{} {
 	if annos == nil {
-		annos = make(map[string]string, 0)
+		annos = make(map[string]interface{}, 0)
 	}
 	annos[name] = val
 	return annos
@@ -328,7 +621,7 @@ func (p *Parser) parseIncludeDirective(comment string) error {
 	fname, err := p.ExpectString()
 	if err == nil {
 		var incparser *Parser
-		incparser, err = parseFileNoValidate(fname, p.conf, p.extensionList())
+		incparser, err = parseFileNoValidate(p.fsys, fname, p.conf, p.extensionList())
 		if err == nil {
 			inc := incparser.model
 			tmpModel, err := NewModel(p.schema)
@@ -342,6 +635,19 @@ func (p *Parser) parseIncludeDirective(comment string) error {
 				td.Annotations = p.addAnnotation(td.Annotations, "x_include", fname)
 				p.schema.Types = append(p.schema.Types, td)
 			}
+			for _, cd := range inc.Constants {
+				if p.findConstant(cd.Name) != nil {
+					return p.Error("Duplicate constant definition in included file (" + fname + "): " + cd.Name)
+				}
+				cd.Annotations = p.addAnnotation(cd.Annotations, "x_include", fname)
+				p.schema.Constants = append(p.schema.Constants, cd)
+			}
+			for _, ad := range inc.AnnotationDefs {
+				if p.findAnnotationDef(ad.Name) != nil {
+					return p.Error("Duplicate annotation definition in included file (" + fname + "): " + ad.Name)
+				}
+				p.schema.AnnotationDefs = append(p.schema.AnnotationDefs, ad)
+			}
 			for _, op := range inc.Http {
 				op.Annotations = p.addAnnotation(op.Annotations, "x_include", fname)
 				p.schema.Http = append(p.schema.Http, op)
@@ -355,6 +661,179 @@ func (p *Parser) parseIncludeDirective(comment string) error {
 	return err
 }
 
+func (p *Parser) parseConstDirective(comment string) error {
+	name, err := p.ExpectIdentifier()
+	if err != nil {
+		return err
+	}
+	if p.findConstant(name) != nil {
+		return p.Error("Duplicate constant: " + name)
+	}
+	ctype, err := p.ExpectCompoundIdentifier()
+	if err != nil {
+		return err
+	}
+	val, err := p.parseEqualsLiteral()
+	if err != nil {
+		return err
+	}
+	cd := &ConstantDef{
+		Name:    name,
+		Comment: comment,
+		Type:    ctype,
+		Value:   val,
+	}
+	cd.Span = p.spanFrom(p.directiveToken)
+	cd.Comment, err = p.EndOfStatement(cd.Comment)
+	p.schema.Constants = append(p.schema.Constants, cd)
+	return err
+}
+
+func (p *Parser) parseAnnotationDirective(comment string) error {
+	name, err := p.ExpectIdentifier()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(name, "x_") {
+		return p.Error("Annotation names must start with 'x_': " + name)
+	}
+	if p.findAnnotationDef(name) != nil {
+		return p.Error("Duplicate annotation: " + name)
+	}
+	ts, _, tcomment, err := p.ParseTypeSpec(comment)
+	if err != nil {
+		return err
+	}
+	options, err := p.ParseOptions(ts.Type, append(typeSpecOptions(ts.Type), "targets"))
+	if err != nil {
+		return err
+	}
+	ad := &AnnotationDef{
+		Name:     name,
+		Comment:  tcomment,
+		Targets:  options.Targets,
+		TypeSpec: *ts,
+	}
+	ad.Pattern = options.Pattern
+	ad.Values = options.Values
+	ad.MinSize = options.MinSize
+	ad.MaxSize = options.MaxSize
+	ad.Min = options.Min
+	ad.Max = options.Max
+	ad.Reference = options.Reference
+	ad.Span = p.spanFrom(p.directiveToken)
+	ad.Comment, err = p.EndOfStatement(ad.Comment)
+	p.schema.AnnotationDefs = append(p.schema.AnnotationDefs, ad)
+	return err
+}
+
+func (p *Parser) findAnnotationDef(name string) *AnnotationDef {
+	if p.schema != nil {
+		for _, ad := range p.schema.AnnotationDefs {
+			if ad.Name == name {
+				return ad
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Parser) findConstant(name string) *ConstantDef {
+	if p.schema != nil {
+		for _, cd := range p.schema.Constants {
+			if cd.Name == name {
+				return cd
+			}
+		}
+	}
+	return nil
+}
+
+// declareConstants finds the values of the constants of the source before it is parsed, so that a constant can be used
+// before its definition, including in the value of another constant. The constants are defined, and checked, when
+// their directives are parsed in turn.
+func (p *Parser) declareConstants() {
+	pre := NewParser(p.path, p.source, p.conf)
+	pre.schema = &Schema{}
+	refs := make(map[string]string, 0)
+	depth := 0
+	start := true
+	for tok := pre.GetToken(); tok != nil; tok = pre.GetToken() {
+		switch tok.Type {
+		case scanner.OPEN_BRACE, scanner.OPEN_PAREN, scanner.OPEN_BRACKET:
+			depth++
+		case scanner.CLOSE_BRACE, scanner.CLOSE_PAREN, scanner.CLOSE_BRACKET:
+			depth--
+		case scanner.SYMBOL:
+			if start && depth == 0 && tok.Text == "const" {
+				pre.declareConstant(refs)
+			}
+		}
+		start = tok.Type == scanner.NEWLINE
+	}
+	//constants whose values are later constants are resolved once those are known
+	for resolved := true; resolved; {
+		resolved = false
+		for name, ref := range refs {
+			if cd := pre.findConstant(ref); cd != nil {
+				pre.schema.Constants = append(pre.schema.Constants, &ConstantDef{Name: name, Value: cd.Value})
+				delete(refs, name)
+				resolved = true
+			}
+		}
+	}
+	p.declared = pre.schema.Constants
+}
+
+// declareConstant finds the value of a constant, just after the const keyword, for declareConstants. A value that
+// names a constant not yet found is added to the refs instead. Malformed definitions are ignored, they are reported
+// when parsed.
+func (p *Parser) declareConstant(refs map[string]string) {
+	name, err := p.ExpectIdentifier()
+	if err != nil {
+		return
+	}
+	_, err = p.ExpectCompoundIdentifier()
+	if err == nil {
+		err = p.expect(scanner.EQUALS)
+	}
+	tok := p.GetToken()
+	if err != nil || tok == nil {
+		return
+	}
+	if tok.Type == scanner.SYMBOL && !containsOption([]string{"true", "false", "null"}, tok.Text) && p.findConstant(tok.Text) == nil {
+		refs[name] = tok.Text
+		return
+	}
+	val, err := p.parseLiteral(tok)
+	if err == nil {
+		p.schema.Constants = append(p.schema.Constants, &ConstantDef{Name: name, Value: val})
+	}
+}
+
+// lookupConstant returns the constant with the given name, defined so far or declared later in the source, or nil.
+func (p *Parser) lookupConstant(name string) *ConstantDef {
+	if cd := p.findConstant(name); cd != nil {
+		return cd
+	}
+	for _, cd := range p.declared {
+		if cd.Name == name {
+			return cd
+		}
+	}
+	return nil
+}
+
+func (p *Parser) constantNumber(tok *scanner.Token) (*Decimal, error) {
+	if cd := p.lookupConstant(tok.Text); cd != nil {
+		if n, ok := cd.Value.(*Decimal); ok {
+			return n, nil
+		}
+		return nil, p.Error(fmt.Sprintf("Constant %s is not a number", tok.Text))
+	}
+	return nil, p.Error(fmt.Sprintf("Expected number, found %v", tok.Type))
+}
+
 func (p *Parser) parseOperationDirective(comment string) error {
 	name, err := p.ExpectIdentifier()
 	if err != nil {
@@ -402,6 +881,7 @@ func (p *Parser) parseOperationDirective(comment string) error {
 		Exceptions:  exceptions,
 		Comment:     comment,
 		Annotations: options.Annotations,
+		Span:        p.spanFrom(p.directiveToken),
 	}
 	p.schema.Operations = append(p.schema.Operations, op)
 	return nil
@@ -564,7 +1044,7 @@ func (p *Parser) parseHttpDirective(name, comment string) error {
 	if err != nil {
 		return err
 	}
-	options, err := p.ParseOptions("http", []string{"action", "operation", "resource"})
+	options, err := p.parseOptions("http", "http", []string{"action", "operation", "resource", "service", "auth", "scopes", "paginated"})
 	if err != nil {
 		return err
 	}
@@ -576,7 +1056,12 @@ func (p *Parser) parseHttpDirective(name, comment string) error {
 		Path:        pathTemplate,
 		Name:        name,
 		Resource:    options.Resource,
+		Service:     options.Service,
 		Annotations: options.Annotations,
+		Auth:        options.Auth,
+		Scopes:      options.Scopes,
+		Anonymous:   options.Auth != nil && len(options.Auth) == 0,
+		Paginated:   options.Paginated,
 	}
 	tok := p.GetToken()
 	if tok == nil {
@@ -597,6 +1082,7 @@ func (p *Parser) parseHttpDirective(name, comment string) error {
 				return err
 			}
 		}
+		op.Span = p.spanFrom(p.directiveToken)
 		op.Comment, err = p.EndOfStatement(op.Comment)
 		ensureActionName(op)
 		ensureRequiredParams(op)
@@ -629,7 +1115,14 @@ func ensureRequiredParams(hact *HttpDef) {
 	}
 	if needsBody {
 		for _, out := range hact.Expected.Outputs {
-			if out.Header == "" { //body
+			if out.Header == "" && out.Cookie == "" { //body
+				out.Required = true
+			}
+		}
+	}
+	for _, exc := range hact.Exceptions {
+		for _, out := range exc.Outputs {
+			if out.Header == "" && out.Cookie == "" {
 				out.Required = true
 			}
 		}
@@ -667,6 +1160,7 @@ func (p *Parser) parseHttpSpec(op *HttpDef, comment string, top bool) error {
 	if err != nil {
 		return err
 	}
+	start := p.lastToken
 	if ename == "expect" {
 		if !top {
 			err = p.SyntaxError()
@@ -677,25 +1171,58 @@ func (p *Parser) parseHttpSpec(op *HttpDef, comment string, top bool) error {
 		if !top {
 			err = p.SyntaxError()
 		} else {
-			return p.parseHttpExceptionSpec(op, comment)
+			exc, err := p.parseHttpExceptionSpec(op.Exceptions, comment)
+			if err != nil {
+				return err
+			}
+			op.Exceptions = append(op.Exceptions, exc)
+			return nil
+		}
+	} else if ext := p.httpExtension(ename); ext != nil && top {
+		val, err := ext.ParseStatement(p, op, ename)
+		if err != nil {
+			return err
 		}
+		op.Statements = p.addAnnotation(op.Statements, ename, val)
+		_, err = p.EndOfStatement(comment)
+		return err
 	}
 	if err != nil {
 		return err
 	}
-	ts, _, comment, err := p.ParseTypeSpec(comment)
+	spec, err := p.parseHttpParamSpec(pathTemplate, ename, start, comment)
 	if err != nil {
 		return err
 	}
-	options, err := p.ParseOptions("HttpParam", []string{"header", "default", "required"})
+	if top {
+		op.Inputs = append(op.Inputs, spec)
+	} else {
+		op.Expected.Outputs = append(op.Expected.Outputs, spec)
+	}
+	return nil
+}
+
+// parseHttpParamSpec parses the rest of an input or output param, after its name.
+func (p *Parser) parseHttpParamSpec(pathTemplate string, ename string, start *scanner.Token, comment string) (*HttpParamSpec, error) {
+	ts, _, comment, err := p.ParseTypeSpec(comment)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	options, err := p.parseOptions("field", "HttpParam", append([]string{"header", "cookie", "csv", "mediatype", "default", "required"}, fieldConstraintOptions(ts.Type)...))
+	if err != nil {
+		return nil, err
 	}
+	setConstraints(ts, options)
+	span := p.spanFrom(start)
 	comment, err = p.EndOfStatement(comment)
+	if err != nil {
+		return nil, err
+	}
 	spec := &HttpParamSpec{
 		StructFieldDef: StructFieldDef{
 			Name:        ename,
 			Annotations: options.Annotations,
+			Span:        span,
 			Comment:     comment,
 			TypeSpec:    *ts,
 		},
@@ -703,29 +1230,32 @@ func (p *Parser) parseHttpSpec(op *HttpDef, comment string, top bool) error {
 	if options.Default != "" {
 		spec.Default = options.Default
 	}
+	spec.Csv = options.Csv
+	spec.MediaType = options.MediaType
 
-	paramType, paramName := p.parameterSource(pathTemplate, ename, options)
+	paramType, paramName := parameterSource(pathTemplate, ename, options)
 	switch paramType {
 	case "path":
 		spec.Path = true
+	case "greedy":
+		spec.Path = true
+		spec.Greedy = true
 	case "query":
 		spec.Query = paramName
 	case "header":
 		spec.Header = paramName
+	case "cookie":
+		spec.Cookie = paramName
 	case "body":
 	}
-	if top {
-		op.Inputs = append(op.Inputs, spec)
-	} else {
-		op.Expected.Outputs = append(op.Expected.Outputs, spec)
-	}
-	return nil
+	return spec, nil
 }
 
 func (p *Parser) parseHttpExpectedSpec(op *HttpDef, comment string) error {
 	if op.Expected != nil {
 		return p.Error("Only a single 'expect' directive is allowed per HTTP action")
 	}
+	start := p.lastToken
 	estatus, err := p.expectInt32()
 	if err != nil {
 		return err
@@ -769,61 +1299,104 @@ func (p *Parser) parseHttpExpectedSpec(op *HttpDef, comment string) error {
 	} else {
 		p.UngetToken()
 	}
+	op.Expected.Span = p.spanFrom(start)
 	op.Expected.Comment, err = p.EndOfStatement(op.Expected.Comment)
 	return err
 }
 
-func (p *Parser) parseHttpExceptionSpec(op *HttpDef, comment string) error {
+func (p *Parser) parseHttpExceptionSpec(exceptions []*HttpExceptionSpec, comment string) (*HttpExceptionSpec, error) {
 	var estatus int32
+	start := p.lastToken
 	tok := p.GetToken()
 	if tok == nil {
-		return p.EndOfFileError()
+		return nil, p.EndOfFileError()
 	}
 	if tok.IsNumeric() {
 		p.UngetToken()
 		estatus2, err := p.expectInt32()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		estatus = estatus2
 	} else {
 		p.UngetToken()
 	}
-	etype, err := p.ExpectIdentifier()
-	if err != nil {
-		return err
+	var err error
+	var outputs []*HttpParamSpec
+	etype := ""
+	tok = p.GetToken()
+	if tok == nil {
+		return nil, p.EndOfFileError()
+	}
+	if tok.Type == scanner.OPEN_BRACE {
+		//like an expect block: the body is the exception, and the other outputs are headers or cookies
+		comment = p.ParseTrailingComment(comment)
+		for {
+			done, ocomment, err := p.IsBlockDone("")
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				comment = p.MergeComment(comment, ocomment)
+				break
+			}
+			oname, err := p.ExpectIdentifier()
+			if err != nil {
+				return nil, err
+			}
+			out, err := p.parseHttpParamSpec("", oname, p.lastToken, ocomment)
+			if err != nil {
+				return nil, err
+			}
+			if out.Header == "" && out.Cookie == "" && etype == "" {
+				etype = out.Type
+			}
+			outputs = append(outputs, out)
+		}
+		if etype == "" {
+			return nil, p.Error("An HTTP action exception block must have a body output")
+		}
+	} else {
+		p.UngetToken()
+		etype, err = p.ExpectIdentifier()
+		if err != nil {
+			return nil, err
+		}
 	}
 	//check for dups.
 	//the only reason I don't accept dups is for Java codegen, which is type-based.
 	//i.e. without a distinct type for each exception, the code cannot raise them
 	//smithy annotates the error itself with an HTTP status code.
-	for _, exc := range op.Exceptions {
+	for _, exc := range exceptions {
 		if exc.Type == etype {
-			return p.Error("Duplicate HTTP action exception type: " + exc.Type)
+			return nil, p.Error("Duplicate HTTP action exception type: " + exc.Type)
 		}
 	}
 	options, err := p.ParseOptions("HttpResponse", []string{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	exc := &HttpExceptionSpec{
 		Type:        etype,
 		Status:      estatus,
+		Outputs:     outputs,
 		Annotations: options.Annotations,
+		Span:        p.spanFrom(start),
 	}
 	exc.Comment, err = p.EndOfStatement(comment)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	op.Exceptions = append(op.Exceptions, exc)
-	return nil
-
+	return exc, nil
 }
 
-func (p *Parser) parameterSource(pathTemplate, name string, options *Options) (string, string) {
+func parameterSource(pathTemplate, name string, options *Options) (string, string) {
 	if options.Header != "" {
 		return "header", options.Header
 	}
+	if options.Cookie != "" {
+		return "cookie", options.Cookie
+	}
 	path := pathTemplate
 	query := ""
 	n := strings.Index(path, "?")
@@ -831,10 +1404,13 @@ func (p *Parser) parameterSource(pathTemplate, name string, options *Options) (s
 		query = path[n+1:]
 		path = path[:n]
 	}
-	match := "{" + name + "}" //fixme: wildcard for the end of the path
+	match := "{" + name + "}"
 	if strings.Index(path, match) >= 0 {
 		return "path", ""
 	}
+	if strings.Index(path, "{"+name+"+}") >= 0 {
+		return "greedy", ""
+	}
 	for _, qparam := range strings.Split(query, "&") {
 		kv := strings.Split(qparam, "=")
 		if len(kv) > 1 && kv[1] == match {
@@ -885,6 +1461,7 @@ func (p *Parser) parseExampleDirective(comment string) error {
 			Target:  target,
 			Example: val,
 			Comment: comment,
+			Span:    p.spanFrom(p.directiveToken),
 		}
 		if options.Name != "" {
 			ex.Name = options.Name
@@ -946,6 +1523,7 @@ func (p *Parser) parseTypeDirective(comment string) error {
 	if err != nil {
 		return err
 	}
+	td.Span = p.spanFrom(p.directiveToken)
 	p.schema.Types = append(p.schema.Types, td)
 	return nil
 }
@@ -1031,7 +1609,7 @@ func (p *Parser) ParseTypeSpecElements() (string, []string, []*StructFieldDef, [
 	} else if typeName == "Struct" || typeName == "Enum" || typeName == "Union" {
 		if tok.Type != scanner.OPEN_BRACE {
 			p.UngetToken()
-			options, err = p.ParseOptions(typeName, []string{})
+			options, err = p.parseOptions("type", typeName, []string{})
 			if err != nil {
 				return typeName, nil, nil, nil, options, "", err
 			}
@@ -1055,6 +1633,9 @@ func (p *Parser) ParseTypeSpecElements() (string, []string, []*StructFieldDef, [
 				for {
 					field, err := p.parseStructFieldDef()
 					if err != nil {
+						if p.recoverField(err) {
+							continue
+						}
 						return typeName, nil, fields, nil, options, comment, err
 					}
 					if field == nil {
@@ -1156,9 +1737,10 @@ func (p *Parser) parseUUIDDef(td *TypeDef) error {
 }
 
 func (p *Parser) parseUnitValueDef(td *TypeDef, params []string) error {
-	err := p.parseTypeOptions(td)
+	var err error
+	td.Value, td.Unit, err = p.unitValueParams(params)
 	if err == nil {
-		td.Value, td.Unit, err = p.unitValueParams(params)
+		err = p.parseTypeOptions(td, "unit")
 		if err == nil {
 			td.Comment, err = p.EndOfStatement(td.Comment)
 		}
@@ -1215,9 +1797,13 @@ func (p *Parser) parseUnionDef(td *TypeDef, params []string, fields []*StructFie
 			}
 		} else if fields != nil {
 			for _, v := range fields {
-				vd := &UnionVariantDef{}
-				vd.Name = v.Name
-				vd.Type = v.Type
+				vd := &UnionVariantDef{
+					Name:        v.Name,
+					Comment:     v.Comment,
+					Annotations: v.Annotations,
+					Span:        v.Span,
+					TypeSpec:    v.TypeSpec,
+				}
 				td.Variants = append(td.Variants, vd)
 			}
 		} else {
@@ -1230,7 +1816,95 @@ func (p *Parser) parseUnionDef(td *TypeDef, params []string, fields []*StructFie
 
 func (p *Parser) Error(msg string) error {
 	Debug("*** error, last token:", p.lastToken)
-	return fmt.Errorf("*** %s\n", scanner.FormattedAnnotation(p.path, p.Source(), "", msg, p.lastToken, scanner.RED, 5))
+	formatted := fmt.Sprintf("*** %s\n", scanner.FormattedAnnotation(p.path, p.Source(), "", msg, p.lastToken, scanner.RED, 5))
+	return &Diagnostic{Severity: SeverityError, Message: msg, Span: p.tokenSpan(p.lastToken), formatted: formatted}
+}
+
+// Diagnostics returns all the problems found by the last parse.
+func (p *Parser) Diagnostics() Diagnostics {
+	return p.diagnostics
+}
+
+func (p *Parser) report(err error) {
+	p.diagnostics.add(err)
+}
+
+func (p *Parser) tokenSpan(tok *scanner.Token) *Span {
+	if tok == nil {
+		return nil
+	}
+	return &Span{File: p.path, Line: tok.Line, Col: tok.Start, EndLine: tok.Line, EndCol: tok.Start + tokenWidth(tok) - 1}
+}
+
+// the span from the start token through the last token read
+func (p *Parser) spanFrom(start *scanner.Token) *Span {
+	if start == nil {
+		return nil
+	}
+	end := p.lastToken
+	if end != nil && end.Type == scanner.NEWLINE {
+		end = p.prevLastToken
+	}
+	span := &Span{
+		File:    p.path,
+		Line:    start.Line,
+		Col:     start.Start,
+		EndLine: start.Line,
+		EndCol:  start.Start + tokenWidth(start) - 1,
+	}
+	if end != nil && end.Line >= start.Line {
+		span.EndLine = end.Line
+		span.EndCol = end.Start + tokenWidth(end) - 1
+	}
+	return span
+}
+
+func tokenWidth(tok *scanner.Token) int {
+	switch tok.Type {
+	case scanner.STRING:
+		return len(tok.Text) + 2
+	case scanner.NEWLINE:
+		return 1
+	}
+	return len(tok.Text)
+}
+
+// ErrorAt formats a semantic error at the given source span, in the same format as syntax errors. Diagnostics that
+// already carry a span keep it.
+func (p *Parser) ErrorAt(span *Span, err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case Diagnostics:
+		var ds Diagnostics
+		for _, d := range e {
+			ds.add(p.ErrorAt(span, d))
+		}
+		return ds
+	case *Diagnostic:
+		if e.formatted != "" || (e.Span == nil && span == nil) {
+			return e
+		}
+		if e.Span != nil {
+			span = e.Span
+		}
+		err = errors.New(e.Message)
+	}
+	if span == nil {
+		return err
+	}
+	source := p.Source()
+	if span.File != p.path {
+		data, rerr := ReadFile(p.fsys, span.File)
+		if rerr != nil {
+			//i.e. the span of a model loaded from JSON, whose source is not at hand
+			return &Diagnostic{Severity: SeverityError, Span: span, Message: err.Error()}
+		}
+		source = string(data)
+	}
+	tok := &scanner.Token{Type: scanner.SYMBOL, Line: span.Line, Start: span.Col}
+	formatted := fmt.Sprintf("*** %s\n", scanner.FormattedAnnotation(span.File, source, "", err.Error(), tok, scanner.RED, 5))
+	return &Diagnostic{Severity: SeverityError, Span: span, Message: err.Error(), formatted: formatted}
 }
 
 func (p *Parser) SyntaxError() error {
@@ -1305,7 +1979,16 @@ func (p *Parser) expectEqualsString() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return p.ExpectString()
+	tok := p.GetToken()
+	if tok != nil && tok.Type == scanner.SYMBOL {
+		if cd := p.lookupConstant(tok.Text); cd != nil {
+			if s, ok := cd.Value.(*string); ok {
+				return *s, nil
+			}
+			return "", p.Error(fmt.Sprintf("Constant %s is not a string", tok.Text))
+		}
+	}
+	return p.assertString(tok)
 }
 
 func (p *Parser) expectText() (string, error) {
@@ -1353,6 +2036,16 @@ func (p *Parser) expectInt64() (int64, error) {
 		l, err := strconv.ParseInt(tok.Text, 10, 64)
 		return int64(l), err
 	}
+	if tok.Type == scanner.SYMBOL {
+		n, err := p.constantNumber(tok)
+		if err != nil {
+			return 0, err
+		}
+		if !n.IsInt() {
+			return 0, p.Error(fmt.Sprintf("Constant %s is not an integer", tok.Text))
+		}
+		return n.AsInt64(), nil
+	}
 	return 0, p.Error(fmt.Sprintf("Expected number, found %v", tok.Type))
 }
 
@@ -1377,6 +2070,9 @@ func (p *Parser) expectNumber() (*Decimal, error) {
 	if tok.IsNumeric() {
 		return ParseDecimal(tok.Text)
 	}
+	if tok.Type == scanner.SYMBOL {
+		return p.constantNumber(tok)
+	}
 	return nil, p.Error(fmt.Sprintf("Expected number, found %v", tok.Type))
 }
 
@@ -1411,7 +2107,7 @@ func containsOption(options []string, option string) bool {
 }
 
 func (p *Parser) parseTypeOptions(td *TypeDef, acceptable ...string) error {
-	options, err := p.ParseOptions(td.Type, acceptable)
+	options, err := p.parseOptions("type", td.Type, acceptable)
 	if err == nil {
 		td.Pattern = options.Pattern
 		td.Values = options.Values
@@ -1420,29 +2116,59 @@ func (p *Parser) parseTypeOptions(td *TypeDef, acceptable ...string) error {
 		td.Min = options.Min
 		td.Max = options.Max
 		td.Reference = options.Reference
+		if options.Unit != "" {
+			td.Unit = options.Unit
+		}
 		td.Annotations = options.Annotations
 	}
 	return err
 }
 
 type Options struct {
-	Required    bool
-	Default     interface{}
-	Pattern     string
-	Values      []string
-	MinSize     *int64
-	MaxSize     *int64
-	Min         *Decimal
-	Max         *Decimal
-	Action      string
-	Resource    string
-	Header      string
-	Reference   string
-	Name        string
-	Annotations map[string]string
+	Required         bool
+	Nullable         bool
+	Default          interface{}
+	Pattern          string
+	Values           []string
+	MinSize          *int64
+	MaxSize          *int64
+	Min              *Decimal
+	Max              *Decimal
+	Action           string
+	Resource         string
+	Header           string
+	Cookie           string
+	Query            string
+	Csv              bool
+	MediaType        string
+	Format           string
+	TokenUrl         string
+	AuthorizationUrl string
+	Scopes           []string
+	Auth             []string
+	Service          string
+	Version          string
+	Base             string
+	Identifiers      []string
+	Create           string
+	Read             string
+	Update           string
+	Delete           string
+	List             string
+	Paginated        *HttpPaginatedSpec
+	Reference        string
+	Unit             string
+	Name             string
+	Targets          []string
+	Annotations      map[string]interface{}
 }
 
 func (p *Parser) ParseOptions(typeName string, acceptable []string) (*Options, error) {
+	return p.parseOptions("", typeName, acceptable)
+}
+
+// parseOptions is ParseOptions that also accepts the options of extensions for the target.
+func (p *Parser) parseOptions(target string, typeName string, acceptable []string) (*Options, error) {
 	options := &Options{}
 	var err error
 	tok := p.GetToken()
@@ -1462,35 +2188,16 @@ func (p *Parser) ParseOptions(typeName string, acceptable []string) (*Options, e
 				match := strings.ToLower(tok.Text)
 				if strings.HasPrefix(match, "x_") {
 					options.Annotations, err = p.parseExtendedOption(options.Annotations, tok.Text)
+				} else if ext := p.optionExtension(target, tok.Text); ext != nil {
+					var val interface{}
+					val, err = ext.ParseOption(p, target, tok.Text)
+					if err == nil {
+						options.Annotations = p.addAnnotation(options.Annotations, tok.Text, val)
+					}
 				} else if containsOption(acceptable, match) {
-					switch match {
-					case "min":
-						options.Min, err = p.expectEqualsNumber()
-					case "max":
-						options.Max, err = p.expectEqualsNumber()
-					case "minsize":
-						options.MinSize, err = p.expectEqualsInt64()
-					case "maxsize":
-						options.MaxSize, err = p.expectEqualsInt64()
-					case "pattern":
-						options.Pattern, err = p.expectEqualsString()
-					case "values":
-						options.Values, err = p.expectEqualsStringArray()
-					case "required":
-						options.Required = true
-					case "default":
-						options.Default, err = p.parseEqualsLiteral()
-					case "action", "operation":
-						options.Action, err = p.expectEqualsIdentifier()
-					case "resource":
-						options.Resource, err = p.expectEqualsIdentifier()
-					case "reference":
-						options.Reference, err = p.expectEqualsIdentifier()
-					case "header":
-						options.Header, err = p.expectEqualsString()
-					case "name":
-						options.Name, err = p.expectEqualsIdentifier()
-					default:
+					if opt := findOption(match); opt != nil {
+						err = opt.parse(p, options)
+					} else {
 						err = p.Error("Unrecognized option: " + tok.Text)
 					}
 				} else {
@@ -1511,57 +2218,53 @@ func (p *Parser) ParseOptions(typeName string, acceptable []string) (*Options, e
 	}
 }
 
-// parse the next string. And also a line comment, and the end of line, if present. Anything else is an error
-func (p *Parser) parseStringToEndOfLine() (string, string, error) {
-	val := ""
+func (p *Parser) parseExtendedOptionTopLevel(annos map[string]interface{}, anno string) (map[string]interface{}, string, error) {
+	var val interface{} = ""
 	comment := ""
 	tok := p.GetToken()
 	if tok == nil {
-		return val, comment, nil
+		return p.addAnnotation(annos, anno, val), comment, nil
 	}
 	if tok.Type == scanner.EQUALS {
 		//ignore it except error if at end of file
 		tok = p.GetToken()
 		if tok == nil {
-			return "", "", p.EndOfFileError()
+			return annos, "", p.EndOfFileError()
 		}
 	}
-	if tok.Type == scanner.STRING {
-		val = tok.Text
+	if tok.Type != scanner.LINE_COMMENT && tok.Type != scanner.NEWLINE {
+		lit, err := p.parseLiteral(tok)
+		if err != nil {
+			return annos, "", err
+		}
+		val = annotationValue(lit)
 		tok = p.GetToken()
 	}
+	annos = p.addAnnotation(annos, anno, val)
 	if tok == nil {
-		return val, comment, nil
+		return annos, comment, nil
 	}
 	if tok.Type == scanner.LINE_COMMENT {
 		comment = tok.Text
 		tok = p.GetToken()
 	}
 	if tok == nil {
-		return val, comment, nil
+		return annos, comment, nil
 	}
 	if tok.Type != scanner.NEWLINE {
-		return "", "", p.SyntaxError()
+		return annos, "", p.SyntaxError()
 	}
-	return val, comment, nil
+	return annos, comment, nil
 }
 
-func (p *Parser) parseExtendedOptionTopLevel(annos map[string]string, anno string) (map[string]string, string, error) {
-	val, comment, err := p.parseStringToEndOfLine()
-	if annos == nil {
-		annos = make(map[string]string, 0)
-	}
-	annos[anno] = val
-	return annos, comment, err
-}
-
-func (p *Parser) parseExtendedOption(annos map[string]string, anno string) (map[string]string, error) {
+func (p *Parser) parseExtendedOption(annos map[string]interface{}, anno string) (map[string]interface{}, error) {
 	var err error
-	var val string
+	var val interface{} = ""
 	tok := p.GetToken()
 	if tok != nil {
 		if tok.Type == scanner.EQUALS {
-			val, err = p.ExpectString()
+			val, err = p.parseLiteralValue()
+			val = annotationValue(val)
 		} else {
 			p.UngetToken()
 		}
@@ -1571,11 +2274,28 @@ func (p *Parser) parseExtendedOption(annos map[string]string, anno string) (map[
 	if err != nil {
 		return annos, err
 	}
-	if annos == nil {
-		annos = make(map[string]string, 0)
+	return p.addAnnotation(annos, anno, val), err
+}
+
+// annotation values are stored as they would be after a JSON round trip, except numbers, which remain Decimal.
+func annotationValue(v interface{}) interface{} {
+	switch o := v.(type) {
+	case *string:
+		return *o
+	case []interface{}:
+		ary := make([]interface{}, 0, len(o))
+		for _, item := range o {
+			ary = append(ary, annotationValue(item))
+		}
+		return ary
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(o))
+		for k, item := range o {
+			m[k] = annotationValue(item)
+		}
+		return m
 	}
-	annos[anno] = val
-	return annos, err
+	return v
 }
 
 func (p *Parser) parseBytesOptions(typedef *TypeDef) error {
@@ -1631,7 +2351,6 @@ func (p *Parser) parseBytesOptions(typedef *TypeDef) error {
 }
 
 func (p *Parser) expectEqualsStringArray() ([]string, error) {
-	var values []string
 	tok := p.GetToken()
 	if tok == nil {
 		return nil, p.EndOfFileError()
@@ -1639,8 +2358,12 @@ func (p *Parser) expectEqualsStringArray() ([]string, error) {
 	if tok.Type != scanner.EQUALS {
 		return nil, p.SyntaxError()
 	}
+	return p.expectStringArray()
+}
 
-	tok = p.GetToken()
+func (p *Parser) expectStringArray() ([]string, error) {
+	var values []string
+	tok := p.GetToken()
 	if tok == nil {
 		return nil, p.EndOfFileError()
 	}
@@ -1666,9 +2389,67 @@ func (p *Parser) expectEqualsStringArray() ([]string, error) {
 	return values, nil
 }
 
+// expectEqualsStrings expects a string, or an array of them. The result of an empty array is not nil.
+func (p *Parser) expectEqualsStrings() ([]string, error) {
+	tok := p.GetToken()
+	if tok == nil {
+		return nil, p.EndOfFileError()
+	}
+	if tok.Type != scanner.EQUALS {
+		return nil, p.SyntaxError()
+	}
+	tok = p.GetToken()
+	if tok == nil {
+		return nil, p.EndOfFileError()
+	}
+	if tok.Type == scanner.STRING {
+		return []string{tok.Text}, nil
+	}
+	p.UngetToken()
+	values, err := p.expectStringArray()
+	if err == nil && values == nil {
+		values = []string{}
+	}
+	return values, err
+}
+
+// expectEqualsPaginated parses the pagination of an http action, an object naming its fields, i.e.
+// {"inputToken": "after", "outputToken": "items.next", "pageSize": "limit", "items": "items.items"}
+func (p *Parser) expectEqualsPaginated() (*HttpPaginatedSpec, error) {
+	val, err := p.parseEqualsLiteral()
+	if err != nil {
+		return nil, err
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, p.Error("The paginated option needs an object that names the fields of the pagination")
+	}
+	spec := &HttpPaginatedSpec{}
+	for k, v := range m {
+		field := AsString(v)
+		if field == "" {
+			return nil, p.Error(fmt.Sprintf("The paginated option '%s' needs a String value", k))
+		}
+		switch k {
+		case "inputToken":
+			spec.InputToken = field
+		case "outputToken":
+			spec.OutputToken = field
+		case "pageSize":
+			spec.PageSize = field
+		case "items":
+			spec.Items = field
+		default:
+			return nil, p.Error(fmt.Sprintf("Unrecognized paginated option: %s", k))
+		}
+	}
+	return spec, nil
+}
+
 func (p *Parser) parseEnumElementDef() (*EnumElementDef, error) {
 	comment := ""
 	sym := ""
+	var start *scanner.Token
 	var err error
 	for {
 		tok := p.GetToken()
@@ -1686,18 +2467,21 @@ func (p *Parser) parseEnumElementDef() (*EnumElementDef, error) {
 			if err != nil {
 				return nil, err
 			}
+			start = tok
 			break
 		}
 	}
-	options, err := p.ParseOptions("Enum", []string{})
+	options, err := p.ParseOptions("Enum", []string{"json"})
 	if err != nil {
 		return nil, err
 	}
+	span := p.spanFrom(start)
 	comment = p.ParseTrailingComment(comment)
 	return &EnumElementDef{
 		Symbol:      sym,
 		Comment:     comment,
 		Annotations: options.Annotations,
+		Span:        span,
 	}, nil
 }
 
@@ -1742,11 +2526,12 @@ func (p *Parser) parseStructFieldDef() (*StructFieldDef, error) {
 	if err != nil {
 		return nil, err
 	}
+	start := p.lastToken
 	ts, foptions, fcomment, err := p.ParseTypeSpec(comment)
 	if err != nil {
 		return nil, err
 	}
-	comment = p.MergeComment(comment, fcomment)
+	comment = fcomment //already merged with the leading comment
 	field := &StructFieldDef{
 		Name:     fname,
 		Comment:  comment,
@@ -1756,10 +2541,11 @@ func (p *Parser) parseStructFieldDef() (*StructFieldDef, error) {
 	if err != nil {
 		return nil, err
 	}
+	field.Span = p.spanFrom(start)
 	if foptions != nil {
 		if foptions.Annotations != nil && len(foptions.Annotations) > 0 {
 			if field.Annotations == nil {
-				field.Annotations = make(map[string]string, 0)
+				field.Annotations = make(map[string]interface{}, 0)
 			}
 			for k, v := range foptions.Annotations {
 				field.Annotations[k] = v
@@ -1770,32 +2556,135 @@ func (p *Parser) parseStructFieldDef() (*StructFieldDef, error) {
 	return field, nil
 }
 
-func (p *Parser) parseStructFieldOptions(field *StructFieldDef) error {
-	var acceptable []string
-	switch field.Type {
+// parsedOption is an option accepted in parenthesized options, with the function that parses its value, just after its
+// name, into the options.
+type parsedOption struct {
+	name  string
+	parse func(p *Parser, options *Options) error
+}
+
+// optionTable has the options accepted in parenthesized options, other than x_* annotations, in the order the formatter
+// puts them. Which of them apply depends on what is being defined. Their names are matched without regard to case.
+var optionTable = []parsedOption{
+	{"operation", func(p *Parser, o *Options) (err error) { o.Action, err = p.expectEqualsIdentifier(); return }},
+	{"action", func(p *Parser, o *Options) (err error) { o.Action, err = p.expectEqualsIdentifier(); return }},
+	{"resource", func(p *Parser, o *Options) (err error) { o.Resource, err = p.expectEqualsIdentifier(); return }},
+	{"name", func(p *Parser, o *Options) (err error) { o.Name, err = p.expectEqualsIdentifier(); return }},
+	{"required", func(p *Parser, o *Options) error { o.Required = true; return nil }},
+	{"nullable", func(p *Parser, o *Options) error { o.Nullable = true; return nil }},
+	{"default", func(p *Parser, o *Options) (err error) { o.Default, err = p.parseEqualsLiteral(); return }},
+	{"header", func(p *Parser, o *Options) (err error) { o.Header, err = p.expectEqualsString(); return }},
+	{"pattern", func(p *Parser, o *Options) (err error) { o.Pattern, err = p.expectEqualsString(); return }},
+	{"values", func(p *Parser, o *Options) (err error) { o.Values, err = p.expectEqualsStringArray(); return }},
+	{"minsize", func(p *Parser, o *Options) (err error) { o.MinSize, err = p.expectEqualsInt64(); return }},
+	{"maxsize", func(p *Parser, o *Options) (err error) { o.MaxSize, err = p.expectEqualsInt64(); return }},
+	{"min", func(p *Parser, o *Options) (err error) { o.Min, err = p.expectEqualsNumber(); return }},
+	{"max", func(p *Parser, o *Options) (err error) { o.Max, err = p.expectEqualsNumber(); return }},
+	{"reference", func(p *Parser, o *Options) (err error) { o.Reference, err = p.expectEqualsIdentifier(); return }},
+	{"targets", func(p *Parser, o *Options) (err error) { o.Targets, err = p.expectEqualsStringArray(); return }},
+	{"json", func(p *Parser, o *Options) error {
+		wireName, err := p.expectEqualsString()
+		o.Annotations = p.addAnnotation(o.Annotations, "x_wire_name", wireName)
+		return err
+	}},
+	{"unit", func(p *Parser, o *Options) (err error) { o.Unit, err = p.expectEqualsIdentifier(); return }},
+	{"csv", func(p *Parser, o *Options) error { o.Csv = true; return nil }},
+	{"cookie", func(p *Parser, o *Options) (err error) { o.Cookie, err = p.expectEqualsString(); return }},
+	{"mediaType", func(p *Parser, o *Options) (err error) { o.MediaType, err = p.expectEqualsString(); return }},
+	{"query", func(p *Parser, o *Options) (err error) { o.Query, err = p.expectEqualsString(); return }},
+	{"format", func(p *Parser, o *Options) (err error) { o.Format, err = p.expectEqualsString(); return }},
+	{"tokenUrl", func(p *Parser, o *Options) (err error) { o.TokenUrl, err = p.expectEqualsString(); return }},
+	{"authorizationUrl", func(p *Parser, o *Options) (err error) { o.AuthorizationUrl, err = p.expectEqualsString(); return }},
+	{"scopes", func(p *Parser, o *Options) (err error) { o.Scopes, err = p.expectEqualsStringArray(); return }},
+	{"auth", func(p *Parser, o *Options) (err error) { o.Auth, err = p.expectEqualsStrings(); return }},
+	{"service", func(p *Parser, o *Options) (err error) { o.Service, err = p.expectEqualsIdentifier(); return }},
+	{"version", func(p *Parser, o *Options) (err error) { o.Version, err = p.expectEqualsString(); return }},
+	{"base", func(p *Parser, o *Options) (err error) { o.Base, err = p.expectEqualsString(); return }},
+	{"identifiers", func(p *Parser, o *Options) (err error) { o.Identifiers, err = p.expectEqualsStringArray(); return }},
+	{"create", func(p *Parser, o *Options) (err error) { o.Create, err = p.expectEqualsIdentifier(); return }},
+	{"read", func(p *Parser, o *Options) (err error) { o.Read, err = p.expectEqualsIdentifier(); return }},
+	{"update", func(p *Parser, o *Options) (err error) { o.Update, err = p.expectEqualsIdentifier(); return }},
+	{"delete", func(p *Parser, o *Options) (err error) { o.Delete, err = p.expectEqualsIdentifier(); return }},
+	{"list", func(p *Parser, o *Options) (err error) { o.List, err = p.expectEqualsIdentifier(); return }},
+	{"paginated", func(p *Parser, o *Options) (err error) { o.Paginated, err = p.expectEqualsPaginated(); return }},
+}
+
+// OptionNames are the names of the options in optionTable, in the same order.
+var OptionNames = optionNames()
+
+func optionNames() []string {
+	var names []string
+	for _, opt := range optionTable {
+		names = append(names, opt.name)
+	}
+	return names
+}
+
+// findOption returns the option in optionTable with the given name, in any case, or nil if there is none.
+func findOption(name string) *parsedOption {
+	for i := range optionTable {
+		if strings.EqualFold(optionTable[i].name, name) {
+			return &optionTable[i]
+		}
+	}
+	return nil
+}
+
+// the constraint options acceptable for an inline type spec of the given type
+func typeSpecOptions(tname string) []string {
+	switch tname {
 	case "String":
-		acceptable = []string{"pattern", "values", "minsize", "maxsize", "reference"}
+		return []string{"pattern", "values", "minsize", "maxsize", "reference"}
 	case "UUID":
-		acceptable = []string{"reference"}
+		return []string{"reference"}
 	case "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Decimal":
-		acceptable = []string{"min", "max"}
+		return []string{"min", "max"}
 	case "Bytes", "Array", "Map":
-		acceptable = []string{"minsize", "maxsize"}
+		return []string{"minsize", "maxsize"}
+	case "UnitValue":
+		return []string{"unit"}
+	}
+	return nil
+}
+
+// fieldConstraintOptions returns the constraint options that a field, param, or variant of the type accepts. Those of
+// a named type depend on its base type, which may not be defined yet, so they are all accepted here and checked by
+// validateConstraints.
+func fieldConstraintOptions(tname string) []string {
+	for _, bt := range BaseTypes {
+		if bt == tname {
+			return typeSpecOptions(tname)
+		}
 	}
+	return []string{"pattern", "values", "minsize", "maxsize", "min", "max", "reference", "unit"}
+}
+
+func setConstraints(ts *TypeSpec, options *Options) {
+	ts.Pattern = options.Pattern
+	ts.Values = options.Values
+	ts.MinSize = options.MinSize
+	ts.MaxSize = options.MaxSize
+	ts.Min = options.Min
+	ts.Max = options.Max
+	ts.Reference = options.Reference
+	if options.Unit != "" {
+		ts.Unit = options.Unit
+	}
+}
+
+func (p *Parser) parseStructFieldOptions(field *StructFieldDef) error {
+	acceptable := fieldConstraintOptions(field.Type)
 	acceptable = append(acceptable, "required")
+	acceptable = append(acceptable, "nullable")
 	acceptable = append(acceptable, "default")
-	options, err := p.ParseOptions(field.Type, acceptable)
+	acceptable = append(acceptable, "json")
+	options, err := p.parseOptions("field", field.Type, acceptable)
 	if err == nil {
 		field.Required = options.Required
+		field.Nullable = options.Nullable
 		field.Default = options.Default
-		field.Pattern = options.Pattern
-		field.Values = options.Values
-		field.MinSize = options.MinSize
-		field.MaxSize = options.MaxSize
-		field.Min = options.Min
-		field.Max = options.Max
+		setConstraints(&field.TypeSpec, options)
 		field.Annotations = options.Annotations
-		field.Reference = options.Reference
 	}
 	return err
 }
@@ -1842,6 +2731,9 @@ func (p *Parser) parseLiteralSymbol(tok *scanner.Token) (interface{}, error) {
 	case "null":
 		return nil, nil
 	default:
+		if cd := p.lookupConstant(tok.Text); cd != nil {
+			return cd.Value, nil
+		}
 		return nil, fmt.Errorf("Not a valid symbol: %s", tok.Text)
 	}
 }
@@ -2058,8 +2950,14 @@ func (p *Parser) MergeComment(comment1 string, comment2 string) string {
 }
 
 func (p *Parser) Validate() (*Model, error) {
-	var err error
+	for _, cd := range p.model.Constants {
+		err := p.validateConstant(cd)
+		if err != nil {
+			p.report(p.ErrorAt(cd.Span, err))
+		}
+	}
 	for _, td := range p.model.Types {
+		var err error
 		switch td.Type {
 		case "Struct":
 			err = p.validateStruct(td)
@@ -2073,15 +2971,19 @@ func (p *Parser) Validate() (*Model, error) {
 			err = p.validateStringDef(td)
 		case "UUID":
 			err = p.validateReference(td)
+		case "Union":
+			err = p.validateUnion(td)
+		case "Enum":
+			err = p.validateEnum(td)
 		}
 		if err != nil {
-			return nil, err
+			p.report(p.ErrorAt(td.Span, err))
 		}
 	}
 	for _, ex := range p.model.Examples {
-		err = p.validateExample(ex)
+		err := p.validateExample(ex)
 		if err != nil {
-			return nil, err
+			p.report(p.ErrorAt(ex.Span, err))
 		}
 	}
 	/*
@@ -2095,24 +2997,82 @@ func (p *Parser) Validate() (*Model, error) {
 	*/
 	for _, op := range p.model.Operations {
 		//err = p.validateAction(action)
-		err = p.validateOperation(op)
+		err := p.validateOperation(op)
 		if err != nil {
-			return nil, err
+			p.report(p.ErrorAt(op.Span, err))
 		}
 	}
 	for _, hdef := range p.model.Http {
-		err = p.validateHttp(hdef)
+		err := p.validateHttp(hdef)
 		if err != nil {
-			return nil, err
+			p.report(p.ErrorAt(hdef.Span, err))
 		}
 	}
-	for _, ext := range p.extensions {
+	for _, ad := range p.model.Auth {
+		err := p.validateAuth(ad)
+		if err != nil {
+			p.report(p.ErrorAt(ad.Span, err))
+		}
+	}
+	for _, sd := range p.model.Services {
+		err := p.validateService(sd)
+		if err != nil {
+			p.report(p.ErrorAt(sd.Span, err))
+		}
+	}
+	for _, rd := range p.model.Resources {
+		err := p.validateResource(rd)
+		if err != nil {
+			p.report(p.ErrorAt(rd.Span, err))
+		}
+	}
+	if len(p.model.Exceptions) > 0 {
+		var ds Diagnostics
+		for _, exc := range p.model.Exceptions {
+			p.validateHttpException(&ds, nil, exc)
+		}
+		if err := ds.err(); err != nil {
+			p.report(err)
+		}
+	}
+	err := p.model.ValidateAnnotations()
+	if err != nil {
+		p.report(p.ErrorAt(nil, err))
+	}
+	for _, ext := range p.extensionOrder {
 		err = ext.Validate(p)
 		if err != nil {
-			return nil, err
+			p.report(err)
 		}
 	}
-	return p.model, err
+	if p.diagnostics.HasErrors() {
+		return nil, p.diagnostics
+	}
+	for _, ext := range p.extensionOrder {
+		if tx, ok := ext.(TransformExtension); ok {
+			err = tx.Transform(p.model)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", ext.Name(), err)
+			}
+		}
+	}
+	return p.model, nil
+}
+
+func (p *Parser) validateConstant(cd *ConstantDef) error {
+	if p.model.FindType(cd.Name) != nil {
+		return fmt.Errorf("Constant %s has the name of a type", cd.Name)
+	}
+	td := p.model.FindType(cd.Type)
+	if td == nil {
+		return fmt.Errorf("Undefined type '%s' for constant %s", cd.Type, cd.Name)
+	}
+	switch p.model.BaseType(cd.Type) {
+	case "Bool", "String", "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Decimal", "UUID", "Timestamp", "Enum":
+	default:
+		return fmt.Errorf("Constant %s must have a scalar type, not %s", cd.Name, cd.Type)
+	}
+	return p.model.ValidateAgainstTypeSpec("constant "+cd.Name, &td.TypeSpec, cd.Value)
 }
 
 func (p *Parser) validateExample(ex *ExampleDef) error {
@@ -2141,7 +3101,12 @@ func (p *Parser) validateExample(ex *ExampleDef) error {
 		}
 	}
 	if ts == nil {
-		if strings.HasSuffix(ex.Target, "Request") {
+		if hact, exc := p.model.FindHttpException(ex.Target); exc != nil {
+			if len(exc.Outputs) == 0 {
+				return fmt.Errorf("Example target '%s' is the response of an exception of http action '%s' with no outputs (use %s instead)", ex.Target, hact.Name, exc.Type)
+			}
+			return p.validateExampleAgainstHttpResponse(exc.Outputs, ex)
+		} else if strings.HasSuffix(ex.Target, "Request") {
 			hname := Uncapitalize(ex.Target[:len(ex.Target)-7])
 			hact := p.model.FindHttp(hname)
 			if hact == nil {
@@ -2155,11 +3120,9 @@ func (p *Parser) validateExample(ex *ExampleDef) error {
 			if hact == nil {
 				err = fmt.Errorf("Example target not found for '%s' (no http action named '%s' found)", ex.Target, hname)
 			} else {
-				return p.validateExampleAgainstHttpResponse(hact, ex)
+				return p.validateExampleAgainstHttpResponse(hact.Expected.Outputs, ex)
 			}
 		} else {
-			//for exceptions, use synthetic names for each exception type (since they are currently unique)
-			//i.e. "GetFooExceptNotFound".
 			err = fmt.Errorf("Cannot find example target: %q", ex.Target)
 		}
 		return err
@@ -2179,11 +3142,10 @@ func (p *Parser) validateExampleAgainstHttpRequest(hact *HttpDef, ex *ExampleDef
 	for k, v := range m {
 		for _, in := range hact.Inputs {
 			if in.Name == k {
-				td := p.model.FindType(in.Type)
-				if td == nil {
+				if p.model.FindType(in.Type) == nil {
 					return fmt.Errorf("Type not found in example: %s", in.Type)
 				}
-				err := p.model.ValidateAgainstTypeSpec("example for "+ex.Target+"."+k, &td.TypeSpec, v)
+				err := p.model.ValidateAgainstTypeSpec("example for "+ex.Target+"."+k, &in.TypeSpec, v)
 				if err != nil {
 					return err
 				}
@@ -2201,20 +3163,19 @@ func (p *Parser) validateExampleAgainstHttpRequest(hact *HttpDef, ex *ExampleDef
 	return nil
 }
 
-func (p *Parser) validateExampleAgainstHttpResponse(hact *HttpDef, ex *ExampleDef) error {
+func (p *Parser) validateExampleAgainstHttpResponse(outputs []*HttpParamSpec, ex *ExampleDef) error {
 	present := make(map[string]bool, 0)
 	m, ok := ex.Example.(map[string]interface{})
 	if !ok {
 		return fmt.Errorf("Example for an HTTP response must be a JSON object")
 	}
 	for k, v := range m {
-		for _, out := range hact.Expected.Outputs {
+		for _, out := range outputs {
 			if out.Name == k {
-				td := p.model.FindType(out.Type)
-				if td == nil {
+				if p.model.FindType(out.Type) == nil {
 					return fmt.Errorf("Type not found in HTTP response example: %s", out.Type)
 				}
-				err := p.model.ValidateAgainstTypeSpec("HTTP response example for "+ex.Target+"."+k, &td.TypeSpec, v)
+				err := p.model.ValidateAgainstTypeSpec("HTTP response example for "+ex.Target+"."+k, &out.TypeSpec, v)
 				if err != nil {
 					return err
 				}
@@ -2222,7 +3183,7 @@ func (p *Parser) validateExampleAgainstHttpResponse(hact *HttpDef, ex *ExampleDe
 			}
 		}
 	}
-	for _, out := range hact.Expected.Outputs {
+	for _, out := range outputs {
 		if out.Required {
 			if _, ok := present[out.Name]; !ok {
 				return fmt.Errorf("Required output '%s' missing in example for %s", out.Name, ex.Target)
@@ -2255,6 +3216,11 @@ func (p *Parser) validateHttpPathTemplate(path string) error {
 			if inParam {
 				return fmt.Errorf("Bad http path template syntax (variable cannot span elements at %d): %q", i, path)
 			}
+		case '+':
+			//a greedy variable, i.e. {path+}, matches the rest of the path, slashes included
+			if inParam && i != len(path)-2 {
+				return fmt.Errorf("Bad http path template syntax (only the last variable of the path can be greedy, at %d): %q", i, path)
+			}
 		}
 	}
 	if inParam {
@@ -2263,61 +3229,437 @@ func (p *Parser) validateHttpPathTemplate(path string) error {
 	return nil
 }
 
+// validateParamType checks that a query or header parameter is a simple value, or an Array of them, which is given
+// either as a repeated parameter or as a single comma-separated value.
+func (p *Parser) validateParamType(hact *HttpDef, in *HttpParamSpec) error {
+	bt := p.model.BaseType(in.Type)
+	if in.Csv && (in.Query == "" || bt != "Array") {
+		return fmt.Errorf("The 'csv' option of '%s' in %s only applies to an Array query parameter", in.Name, httpSubject(hact))
+	}
+	if in.Query == "" && in.Header == "" && in.Cookie == "" {
+		return nil
+	}
+	if bt == "Array" && in.Cookie == "" {
+		items := in.Items
+		if td := p.model.FindType(in.Type); items == "" && td != nil {
+			items = td.Items
+		}
+		bt = p.model.BaseType(items)
+	}
+	switch bt {
+	case "Bool", "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Decimal", "String", "UUID", "Timestamp", "Enum":
+		return nil
+	}
+	return fmt.Errorf("The '%s' parameter of %s must be a simple type, or an Array of one", in.Name, httpSubject(hact))
+}
+
+// validateMediaType checks that a body with a media type other than JSON can be represented by it: a form is a
+// Struct of simple fields, and anything else is raw Bytes or a String.
+func (p *Parser) validateMediaType(hact *HttpDef, spec *HttpParamSpec, input bool) error {
+	if spec.MediaType == "" {
+		return nil
+	}
+	if spec.Path || spec.Query != "" || spec.Header != "" || spec.Cookie != "" {
+		return fmt.Errorf("The 'mediaType' option of '%s' in %s only applies to a body", spec.Name, httpSubject(hact))
+	}
+	if !strings.Contains(spec.MediaType, "/") {
+		return fmt.Errorf("Bad media type for '%s' in %s: %q", spec.Name, httpSubject(hact), spec.MediaType)
+	}
+	bt := p.model.BaseType(spec.Type)
+	if IsJsonMediaType(spec.MediaType) {
+		return nil
+	}
+	if !IsFormMediaType(spec.MediaType) {
+		if bt != "Bytes" && bt != "String" {
+			return fmt.Errorf("The '%s' body of %s must be Bytes or a String to be %s", spec.Name, httpSubject(hact), spec.MediaType)
+		}
+		return nil
+	}
+	if !input {
+		return fmt.Errorf("Only the body of a request can be a form: '%s' in %s", spec.Name, httpSubject(hact))
+	}
+	if bt != "Struct" {
+		return fmt.Errorf("The '%s' form body of %s must be a Struct", spec.Name, httpSubject(hact))
+	}
+	for _, fd := range p.model.FormFields(spec) {
+		if fd.Nullable {
+			return fmt.Errorf("The '%s' field of the '%s' form body of %s cannot be nullable, a form has no null", fd.Name, spec.Name, httpSubject(hact))
+		}
+		fbt := p.model.BaseType(fd.Type)
+		if fbt == "Array" {
+			items := fd.Items
+			if td := p.model.FindType(fd.Type); items == "" && td != nil {
+				items = td.Items
+			}
+			fbt = p.model.BaseType(items)
+		}
+		switch fbt {
+		case "Bool", "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Decimal", "String", "UUID", "Timestamp", "Enum":
+			continue
+		case "Bytes":
+			if spec.MediaType == "multipart/form-data" {
+				continue
+			}
+		}
+		return fmt.Errorf("The '%s' field of the '%s' form body of %s must be a simple type, or an Array of one", fd.Name, spec.Name, httpSubject(hact))
+	}
+	return nil
+}
+
 func (p *Parser) validateHttp(hact *HttpDef) error {
+	var ds Diagnostics
 	err := p.validateHttpPathTemplate(hact.Path)
 	if err != nil {
-		return err
+		ds.add(err)
 	}
+	p.validateHttpAuth(&ds, hact)
+	p.validateHttpService(&ds, hact)
 	needsBody := hact.Method == "POST" || hact.Method == "PUT" || hact.Method == "PATCH"
 	bodyParam := ""
 	for _, in := range hact.Inputs {
 		t := p.model.FindType(in.Type)
 		if t == nil {
-			return fmt.Errorf("Action '%s' input type '%s' is not defined", hact.Name, in.Type)
+			ds.add(p.ErrorAt(in.Span, fmt.Errorf("Action '%s' input type '%s' is not defined", hact.Name, in.Type)))
+			continue
+		}
+		err = p.validateConstraints(hact.Name+"."+in.Name, &in.TypeSpec)
+		if err != nil {
+			ds.add(p.ErrorAt(in.Span, err))
+		}
+		err = p.validateParamType(hact, in)
+		if err != nil {
+			ds.add(p.ErrorAt(in.Span, err))
+		}
+		err = p.validateMediaType(hact, in, true)
+		if err != nil {
+			ds.add(p.ErrorAt(in.Span, err))
+		}
+		if in.Greedy && p.model.BaseType(in.Type) != "String" {
+			ds.add(p.ErrorAt(in.Span, fmt.Errorf("Greedy path parameter '%s' of action '%s' must be a String", in.Name, hact.Name)))
 		}
 		//paramType, paramName := p.parameterSource(hact.Path, in.Name)
-		if !in.Path && in.Query == "" && in.Header == "" {
+		if !in.Path && in.Query == "" && in.Header == "" && in.Cookie == "" {
 			if needsBody {
 				if bodyParam != "" {
-					return fmt.Errorf("HTTP action cannot have more than one body parameter (%q is already that parameter): %s", bodyParam, Pretty(hact))
+					ds.add(p.ErrorAt(in.Span, fmt.Errorf("HTTP action cannot have more than one body parameter (%q is already that parameter): %s", bodyParam, Pretty(hact))))
+				} else {
+					bodyParam = in.Name
 				}
-				bodyParam = in.Name
 			} else {
-				return fmt.Errorf("Input parameter %q to HTTP action is not a header or a variable in the path: %s - %q", in.Name, Pretty(hact), hact.Method+" "+hact.Path)
+				ds.add(p.ErrorAt(in.Span, fmt.Errorf("Input parameter %q to HTTP action is not a header or a variable in the path: %s - %q", in.Name, Pretty(hact), hact.Method+" "+hact.Path)))
 			}
 		}
 	}
 	needsBody = false
 	if hact.Expected == nil {
-		return nil
+		return ds.err()
 	}
 	needsBody = hact.Expected.Status != 204 && hact.Expected.Status != 304
 	bodyParam = ""
 	for _, out := range hact.Expected.Outputs {
 		t := p.model.FindType(out.Type)
 		if t == nil {
-			return fmt.Errorf("Action '%s' expected type '%s' is not defined", hact.Name, out.Type)
+			ds.add(p.ErrorAt(out.Span, fmt.Errorf("Action '%s' expected type '%s' is not defined", hact.Name, out.Type)))
+			continue
 		}
-		if out.Header == "" {
+		err = p.validateConstraints(hact.Name+"."+out.Name, &out.TypeSpec)
+		if err != nil {
+			ds.add(p.ErrorAt(out.Span, err))
+		}
+		if out.Csv {
+			ds.add(p.ErrorAt(out.Span, fmt.Errorf("The 'csv' option of '%s' in action '%s' only applies to an Array query parameter", out.Name, hact.Name)))
+		}
+		err = p.validateMediaType(hact, out, false)
+		if err != nil {
+			ds.add(p.ErrorAt(out.Span, err))
+		}
+		if out.Header == "" && out.Cookie == "" {
 			if needsBody {
 				if bodyParam != "" {
-					return fmt.Errorf("Action '%s' has a duplicate body parameter '%s' in its expected output ('%s' is already that parameter)", hact.Name, out.Name, bodyParam)
+					ds.add(p.ErrorAt(out.Span, fmt.Errorf("Action '%s' has a duplicate body parameter '%s' in its expected output ('%s' is already that parameter)", hact.Name, out.Name, bodyParam)))
+				} else {
+					bodyParam = out.Name
 				}
-				bodyParam = out.Name
 			} else {
-				return fmt.Errorf("HTTP action cannot have a body in expected output for status codes 204 or 304: %s", Pretty(hact))
+				ds.add(p.ErrorAt(out.Span, fmt.Errorf("HTTP action cannot have a body in expected output for status codes 204 or 304: %s", Pretty(hact))))
 			}
 		}
 	}
 	for _, exc := range hact.Exceptions {
-		t := p.model.FindType(exc.Type)
-		if t == nil {
-			return fmt.Errorf("Action '%s' exception type '%s' is not defined", hact.Name, exc.Type)
+		p.validateHttpException(&ds, hact, exc)
+	}
+	p.migratePaginated(hact)
+	p.validateHttpPaginated(&ds, hact)
+	return ds.err()
+}
+
+// migratePaginated adopts the x_paginated annotation of older models, the paginated trait of Smithy, as the pagination
+// of an http action that has none, if the tokens it names are those of the action. Otherwise it is left an annotation.
+func (p *Parser) migratePaginated(hact *HttpDef) {
+	v, ok := hact.Annotations["x_paginated"]
+	if !ok || hact.Paginated != nil {
+		return
+	}
+	m := AsMap(v)
+	if m == nil {
+		//older models encode the trait as "inputToken=a,outputToken=b,..."
+		m = make(map[string]interface{}, 0)
+		for _, item := range strings.Split(AsString(v), ",") {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) == 2 {
+				m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	pg := &HttpPaginatedSpec{
+		InputToken:  GetString(m, "inputToken"),
+		OutputToken: GetString(m, "outputToken"),
+		PageSize:    GetString(m, "pageSize"),
+		Items:       GetString(m, "items"),
+	}
+	if pg.InputToken == "" || hact.Input(pg.InputToken) == nil || p.model.HttpOutputPath(hact, pg.OutputToken) == nil {
+		return
+	}
+	hact.Paginated = pg
+	delete(hact.Annotations, "x_paginated")
+	if len(hact.Annotations) == 0 {
+		hact.Annotations = nil
+	}
+}
+
+// validateHttpPaginated checks that the pagination of an http action names its fields: the input token and page size
+// are query or header inputs, the output token is a String output, and the items are an Array or Map output.
+func (p *Parser) validateHttpPaginated(ds *Diagnostics, hact *HttpDef) {
+	pg := hact.Paginated
+	if pg == nil {
+		return
+	}
+	if pg.InputToken == "" || pg.OutputToken == "" {
+		ds.add(fmt.Errorf("Action '%s' pagination needs an inputToken and an outputToken", hact.Name))
+		return
+	}
+	if in := hact.Input(pg.InputToken); in == nil || in.Path || (in.Query == "" && in.Header == "") {
+		ds.add(fmt.Errorf("Action '%s' pagination inputToken '%s' is not a query or header input", hact.Name, pg.InputToken))
+	} else if p.model.BaseType(in.Type) != "String" {
+		ds.add(fmt.Errorf("Action '%s' pagination inputToken '%s' must be a String", hact.Name, pg.InputToken))
+	}
+	if pg.PageSize != "" {
+		if in := hact.Input(pg.PageSize); in == nil || in.Path || (in.Query == "" && in.Header == "") {
+			ds.add(fmt.Errorf("Action '%s' pagination pageSize '%s' is not a query or header input", hact.Name, pg.PageSize))
+		} else {
+			switch p.model.BaseType(in.Type) {
+			case "Int8", "Int16", "Int32", "Int64":
+			default:
+				ds.add(fmt.Errorf("Action '%s' pagination pageSize '%s' must be an integer", hact.Name, pg.PageSize))
+			}
+		}
+	}
+	if path := p.model.HttpOutputPath(hact, pg.OutputToken); path == nil {
+		ds.add(fmt.Errorf("Action '%s' pagination outputToken '%s' is not an output", hact.Name, pg.OutputToken))
+	} else if p.model.BaseType(path[len(path)-1].Type) != "String" {
+		ds.add(fmt.Errorf("Action '%s' pagination outputToken '%s' must be a String", hact.Name, pg.OutputToken))
+	}
+	if pg.Items != "" {
+		if path := p.model.HttpOutputPath(hact, pg.Items); path == nil {
+			ds.add(fmt.Errorf("Action '%s' pagination items '%s' is not an output", hact.Name, pg.Items))
+		} else if bt := p.model.BaseType(path[len(path)-1].Type); bt != "Array" && bt != "Map" {
+			ds.add(fmt.Errorf("Action '%s' pagination items '%s' must be an Array or a Map", hact.Name, pg.Items))
+		}
+	}
+}
+
+func (p *Parser) validateAuth(ad *AuthDef) error {
+	switch ad.Scheme {
+	case "apiKey":
+		n := 0
+		for _, in := range []string{ad.Header, ad.Query, ad.Cookie} {
+			if in != "" {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("The apiKey auth scheme '%s' needs one of the header, query, or cookie options", ad.Name)
+		}
+	case "oauth2":
+		if ad.TokenUrl == "" && ad.AuthorizationUrl == "" {
+			return fmt.Errorf("The oauth2 auth scheme '%s' needs a tokenUrl or an authorizationUrl option", ad.Name)
+		}
+	case "bearer", "basic", "mutualTLS":
+	default:
+		return fmt.Errorf("Auth scheme '%s' is of an unknown kind: %s", ad.Name, ad.Scheme)
+	}
+	return nil
+}
+
+// validateResource checks that the actions of a resource are defined, of the methods their lifecycle operations
+// need, and bound to no other. Its identifiers are path variables of the actions of an instance: read, update, and delete.
+func (p *Parser) validateResource(rd *ResourceDef) error {
+	var ds Diagnostics
+	for _, op := range ResourceLifecycle {
+		name := rd.LifecycleAction(op)
+		if name == "" {
+			continue
+		}
+		hact := p.model.FindHttp(name)
+		if hact == nil {
+			ds.add(fmt.Errorf("Resource '%s' %s action '%s' is not defined", rd.Name, op, name))
+			continue
+		}
+		if other, otherOp := p.model.HttpLifecycle(hact); other != rd || otherOp != op {
+			ds.add(fmt.Errorf("Resource '%s' %s action '%s' is bound more than once", rd.Name, op, name))
+		}
+		var methods []string
+		switch op {
+		case "create":
+			methods = []string{"POST", "PUT"}
+		case "read", "list":
+			methods = []string{"GET"}
+		case "update":
+			methods = []string{"PUT", "PATCH", "POST"}
+		case "delete":
+			methods = []string{"DELETE"}
+		}
+		if !containsOption(methods, hact.Method) {
+			ds.add(fmt.Errorf("Resource '%s' %s action '%s' cannot be a %s", rd.Name, op, name, hact.Method))
+		}
+		if hact.Resource == "" {
+			hact.Resource = rd.Name
+		} else if hact.Resource != rd.Name {
+			ds.add(fmt.Errorf("Resource '%s' %s action '%s' belongs to resource '%s'", rd.Name, op, name, hact.Resource))
+		}
+		if op == "create" || op == "list" {
+			//the server chooses the identifiers of a new resource, and a listing is of all of them
+			for _, in := range hact.Inputs {
+				if in.Path && containsOption(rd.Identifiers, in.Name) {
+					ds.add(fmt.Errorf("Resource '%s' identifier '%s' cannot be a path variable of its %s action '%s'", rd.Name, in.Name, op, name))
+				}
+			}
+			continue
+		}
+		for _, id := range rd.Identifiers {
+			found := false
+			for _, in := range hact.Inputs {
+				if in.Path && in.Name == id {
+					found = true
+				}
+			}
+			if !found {
+				ds.add(fmt.Errorf("Resource '%s' identifier '%s' is not a path variable of its %s action '%s'", rd.Name, id, op, name))
+			}
+		}
+	}
+	return ds.err()
+}
+
+// validateHttpAuth checks that the auth schemes an http action names are defined, and that any scopes it requires
+// are declared by its oauth2 schemes, if they declare any.
+func (p *Parser) validateHttpAuth(ds *Diagnostics, hact *HttpDef) {
+	for _, name := range hact.Auth {
+		if p.model.FindAuth(name) == nil {
+			ds.add(fmt.Errorf("Action '%s' auth scheme '%s' is not defined", hact.Name, name))
+		}
+	}
+	if len(hact.Scopes) == 0 {
+		return
+	}
+	schemes := p.model.HttpAuth(hact)
+	if len(schemes) == 0 {
+		ds.add(fmt.Errorf("Action '%s' has scopes, but no auth scheme", hact.Name))
+		return
+	}
+	var declared []string
+	for _, ad := range schemes {
+		if ad.Scheme == "oauth2" {
+			declared = append(declared, ad.Scopes...)
+		}
+	}
+	if len(declared) == 0 {
+		return
+	}
+	for _, scope := range hact.Scopes {
+		if !containsOption(declared, scope) {
+			ds.add(fmt.Errorf("Action '%s' scope '%s' is not declared by its auth schemes", hact.Name, scope))
 		}
 	}
+}
+
+// validateService checks that the name of a service, which the generators name its interface for, is not that of a type.
+func (p *Parser) validateService(sd *ServiceDef) error {
+	if p.model.FindType(Capitalize(sd.Name)) != nil {
+		return fmt.Errorf("Service '%s' has the name of a type", sd.Name)
+	}
 	return nil
 }
 
+// validateHttpService checks that the service an http action names is defined. It needs to name one if the model has
+// more than one.
+func (p *Parser) validateHttpService(ds *Diagnostics, hact *HttpDef) {
+	if hact.Service != "" {
+		if p.model.FindService(hact.Service) == nil {
+			ds.add(fmt.Errorf("Action '%s' service '%s' is not defined", hact.Name, hact.Service))
+		}
+	} else if len(p.model.Services) > 1 {
+		ds.add(fmt.Errorf("Action '%s' needs to name its service, the model has more than one", hact.Name))
+	}
+}
+
+// validateHttpException checks an exception of an http action. The exceptions common to all actions are checked
+// once, as if of an action named for the service.
+func (p *Parser) validateHttpException(ds *Diagnostics, hact *HttpDef, exc *HttpExceptionSpec) {
+	what := fmt.Sprintf("exception %d of %s", exc.Status, httpSubject(hact))
+	scope := p.model.Name
+	if hact == nil {
+		what = fmt.Sprintf("common exception %d", exc.Status)
+	} else {
+		scope = hact.Name
+	}
+	t := p.model.FindType(exc.Type)
+	if t == nil {
+		ds.add(p.ErrorAt(exc.Span, fmt.Errorf("The type '%s' of %s is not defined", exc.Type, what)))
+	}
+	bodyParam := ""
+	for _, out := range exc.Outputs {
+		if out.Header == "" && out.Cookie == "" && out.Type != exc.Type {
+			ds.add(p.ErrorAt(out.Span, fmt.Errorf("The body '%s' of %s must be of type '%s'", out.Name, what, exc.Type)))
+		}
+		if p.model.FindType(out.Type) == nil {
+			ds.add(p.ErrorAt(out.Span, fmt.Errorf("The output type '%s' of %s is not defined", out.Type, what)))
+			continue
+		}
+		err := p.validateConstraints(scope+"."+out.Name, &out.TypeSpec)
+		if err != nil {
+			ds.add(p.ErrorAt(out.Span, err))
+		}
+		err = p.validateParamType(hact, out)
+		if err != nil {
+			ds.add(p.ErrorAt(out.Span, err))
+		}
+		err = p.validateMediaType(hact, out, false)
+		if err != nil {
+			ds.add(p.ErrorAt(out.Span, err))
+		}
+		if out.Header == "" && out.Cookie == "" {
+			if bodyParam != "" {
+				ds.add(p.ErrorAt(out.Span, fmt.Errorf("%s has a duplicate body parameter '%s' ('%s' is already that parameter)", Capitalize(what), out.Name, bodyParam)))
+			} else {
+				bodyParam = out.Name
+			}
+		}
+	}
+	if len(exc.Outputs) > 0 && bodyParam == "" {
+		ds.add(p.ErrorAt(exc.Span, fmt.Errorf("%s has outputs, but none of them is its body", Capitalize(what))))
+	}
+}
+
+// httpSubject describes an http action in diagnostics. The exceptions common to all actions are validated with no
+// action, and are described as such.
+func httpSubject(hact *HttpDef) string {
+	if hact == nil {
+		return "the common exceptions"
+	}
+	return fmt.Sprintf("action '%s'", hact.Name)
+}
+
 /*
    func (p *Parser) validateAction(action *ActionDef) error {
 	if action.Input != "" {
@@ -2385,51 +3727,144 @@ func (p *Parser) validateUnitValue(td *TypeDef) error {
 }
 
 func (p *Parser) validateStruct(td *TypeDef) error {
-	model := p.model
+	var ds Diagnostics
+	keys := make(map[string]string, 0)
 	for _, field := range td.Fields {
-		ftd := model.FindType(field.Type)
-		if ftd == nil {
-			return fmt.Errorf("Undefined type '%s' in struct field '%s.%s'", field.Type, td.Name, field.Name)
+		err := p.validateStructField(td, field)
+		if err == nil {
+			err = checkWireName(keys, td.Name+"."+field.Name, field.WireName())
 		}
-		switch field.Type {
-		case "Array":
-			if field.Items != "" && field.Items != "Any" {
-				fitd := model.FindType(field.Items)
-				if fitd == nil {
-					return fmt.Errorf("Undefined array item type '%s' in struct field '%s.%s'", field.Items, td.Name, field.Name)
-				}
-			}
-		case "Map":
-			if field.Keys != "" && field.Keys != "Any" {
-				fitd := model.FindType(field.Keys)
-				if fitd == nil {
-					return fmt.Errorf("Undefined map key type '%s' in struct field '%s.%s'", field.Keys, td.Name, field.Name)
-				}
-				//TODO: ensure the key type is stringable.
-			}
-			if field.Items != "" && field.Items != "Any" {
-				fitd := model.FindType(field.Items)
-				if fitd == nil {
-					return fmt.Errorf("Undefined map value '%s' in struct field '%s.%s'", field.Items, td.Name, field.Name)
-				}
+		if err != nil {
+			ds.add(p.ErrorAt(field.Span, err))
+		}
+	}
+	return ds.err()
+}
+
+// checkWireName ensures that no two fields, variants, or symbols of a type have the same JSON representation.
+func checkWireName(seen map[string]string, name string, wireName string) error {
+	if other, ok := seen[wireName]; ok {
+		return fmt.Errorf("'%s' and '%s' are both represented as %q in JSON", other, name, wireName)
+	}
+	seen[wireName] = name
+	return nil
+}
+
+func (p *Parser) validateStructField(td *TypeDef, field *StructFieldDef) error {
+	model := p.model
+	ftd := model.FindType(field.Type)
+	if ftd == nil {
+		return fmt.Errorf("Undefined type '%s' in struct field '%s.%s'", field.Type, td.Name, field.Name)
+	}
+	switch field.Type {
+	case "Array":
+		if field.Items != "" && field.Items != "Any" {
+			fitd := model.FindType(field.Items)
+			if fitd == nil {
+				return fmt.Errorf("Undefined array item type '%s' in struct field '%s.%s'", field.Items, td.Name, field.Name)
 			}
 		}
-		if field.Default != nil {
-			if field.Required {
-				return fmt.Errorf("Cannot have a default value for required field: '%s.%s'", td.Name, field.Name)
+	case "Map":
+		if field.Keys != "" && field.Keys != "Any" {
+			fitd := model.FindType(field.Keys)
+			if fitd == nil {
+				return fmt.Errorf("Undefined map key type '%s' in struct field '%s.%s'", field.Keys, td.Name, field.Name)
 			}
-			err := model.ValidateAgainstTypeSpec(field.Type, &field.TypeSpec, field.Default)
-			if err != nil {
-				return err
+			//TODO: ensure the key type is stringable.
+		}
+		if field.Items != "" && field.Items != "Any" {
+			fitd := model.FindType(field.Items)
+			if fitd == nil {
+				return fmt.Errorf("Undefined map value '%s' in struct field '%s.%s'", field.Items, td.Name, field.Name)
 			}
 		}
-		if field.Values != nil && field.Pattern != "" {
-			return fmt.Errorf("Cannot have both 'values' and 'pattern' constraints in one string field: '%s.%s'", td.Name, field.Name)
+	}
+	err := p.validateConstraints(td.Name+"."+field.Name, &field.TypeSpec)
+	if err != nil {
+		return err
+	}
+	if field.Default != nil {
+		if field.Required {
+			return fmt.Errorf("Cannot have a default value for required field: '%s.%s'", td.Name, field.Name)
+		}
+		err := model.ValidateAgainstTypeSpec(field.Type, &field.TypeSpec, field.Default)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// validateConstraints checks that the constraints given inline on a field, param, or variant apply to its type.
+func (p *Parser) validateConstraints(name string, ts *TypeSpec) error {
+	acceptable := typeSpecOptions(p.model.BaseType(ts.Type))
+	constraints := []struct {
+		option string
+		set    bool
+	}{
+		{"pattern", ts.Pattern != ""},
+		{"values", ts.Values != nil},
+		{"minsize", ts.MinSize != nil},
+		{"maxsize", ts.MaxSize != nil},
+		{"min", ts.Min != nil},
+		{"max", ts.Max != nil},
+		{"reference", ts.Reference != ""},
+		{"unit", ts.Unit != ""},
+	}
+	for _, c := range constraints {
+		if c.set && !containsOption(acceptable, c.option) {
+			return fmt.Errorf("The '%s' option does not apply to '%s', which is a %s", c.option, name, ts.Type)
+		}
+	}
+	if ts.Values != nil && ts.Pattern != "" {
+		return fmt.Errorf("Cannot have both 'values' and 'pattern' constraints in one string field: '%s'", name)
+	}
+	if ts.Reference != "" && p.model.FindType(ts.Reference) == nil {
+		return fmt.Errorf("Undefined type '%s' for %s reference", ts.Reference, name)
+	}
+	if ts.Unit != "" {
+		if p.model.FindType(ts.Unit) == nil {
+			return fmt.Errorf("Undefined unit type '%s' for %s", ts.Unit, name)
+		}
+		if bt := p.model.BaseType(ts.Unit); bt != "String" && bt != "Enum" {
+			return fmt.Errorf("The unit type of %s is not String or Enum: %s", name, ts.Unit)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) validateEnum(td *TypeDef) error {
+	var ds Diagnostics
+	values := make(map[string]string, 0)
+	for _, el := range td.Elements {
+		err := checkWireName(values, td.Name+"."+el.Symbol, el.WireName())
+		if err != nil {
+			ds.add(p.ErrorAt(el.Span, err))
+		}
+	}
+	return ds.err()
+}
+
+func (p *Parser) validateUnion(td *TypeDef) error {
+	var ds Diagnostics
+	keys := make(map[string]string, 0)
+	for _, vd := range td.Variants {
+		var err error
+		if p.model.FindType(vd.Type) == nil {
+			err = fmt.Errorf("Undefined type '%s' in union variant '%s.%s'", vd.Type, td.Name, vd.Name)
+		} else {
+			err = p.validateConstraints(td.Name+"."+vd.Name, &vd.TypeSpec)
+		}
+		if err == nil {
+			err = checkWireName(keys, td.Name+"."+vd.Name, vd.WireName())
+		}
+		if err != nil {
+			ds.add(p.ErrorAt(vd.Span, err))
+		}
+	}
+	return ds.err()
+}
+
 func (p *Parser) validateArray(td *TypeDef) error {
 	model := p.model
 	if td.Items == "Any" {
@@ -2470,11 +3905,33 @@ func (p *Parser) registerExtension(handler Extension) error {
 		return fmt.Errorf("Extension already exists: %s", name)
 	}
 	p.extensions[name] = handler
+	p.extensionOrder = append(p.extensionOrder, handler)
+	return nil
+}
+
+func (p *Parser) optionExtension(target string, name string) OptionExtension {
+	if target == "" {
+		return nil
+	}
+	for _, ext := range p.extensionOrder {
+		if oext, ok := ext.(OptionExtension); ok && containsOption(oext.Options(target), name) {
+			return oext
+		}
+	}
+	return nil
+}
+
+func (p *Parser) httpExtension(keyword string) HttpExtension {
+	for _, ext := range p.extensionOrder {
+		if hext, ok := ext.(HttpExtension); ok && containsOption(hext.Statements(), keyword) {
+			return hext
+		}
+	}
 	return nil
 }
 
 func (p *Parser) expectedDirectiveError() error {
-	msg := "Expected one of 'type', 'namespace', 'name', 'version', 'base', include, "
+	msg := "Expected one of 'type', 'const', 'annotation', 'namespace', 'name', 'version', 'base', include, "
 	if p.extensions != nil {
 		for k, _ := range p.extensions {
 			msg = msg + fmt.Sprintf("'%s', ", k)
@@ -0,0 +1,430 @@
+package sadl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RenameType renames a type defined in the model, and every reference to it.
+func (model *Model) RenameType(name string, newName string) error {
+	td, err := model.userType(name)
+	if err != nil {
+		return err
+	}
+	err = model.checkNewTypeName(newName)
+	if err != nil {
+		return err
+	}
+	rename := func(s *string) {
+		if *s == name {
+			*s = newName
+		}
+	}
+	model.forEachTypeSpec(func(ts *TypeSpec) {
+		rename(&ts.Type)
+		rename(&ts.Items)
+		rename(&ts.Keys)
+		rename(&ts.Value)
+		rename(&ts.Unit)
+		rename(&ts.Reference)
+	})
+	for _, cd := range model.Constants {
+		rename(&cd.Type)
+	}
+	for _, op := range model.Operations {
+		for i := range op.Exceptions {
+			rename(&op.Exceptions[i])
+		}
+	}
+	for _, hd := range model.Http {
+		for _, exc := range model.HttpExceptions(hd) {
+			if exc.Type == name {
+				target := ExceptionExampleTarget(hd, exc)
+				renamed := *exc
+				renamed.Type = newName
+				for _, ex := range model.Examples {
+					if ex.Target == target {
+						ex.Target = ExceptionExampleTarget(hd, &renamed)
+					}
+				}
+			}
+		}
+	}
+	for _, hd := range model.Http {
+		for _, exc := range hd.Exceptions {
+			rename(&exc.Type)
+		}
+	}
+	for _, exc := range model.Exceptions {
+		rename(&exc.Type)
+	}
+	for _, ex := range model.Examples {
+		rename(&ex.Target)
+		if strings.HasPrefix(ex.Target, name+".") {
+			ex.Target = newName + ex.Target[len(name):]
+		}
+	}
+	delete(model.typeIndex, name)
+	td.Name = newName
+	model.typeIndex[newName] = td
+	return nil
+}
+
+// RenameField renames a field of a Struct type. If keepWireName is set, the field gets an x_wire_name annotation with
+// its old name, so that its JSON representation does not change. Otherwise the keys of examples of the type are
+// renamed as well.
+func (model *Model) RenameField(typeName string, name string, newName string, keepWireName bool) error {
+	td, err := model.userType(typeName)
+	if err != nil {
+		return err
+	}
+	fd := findField(td, name)
+	if fd == nil {
+		return fmt.Errorf("No field named '%s' in type %s", name, typeName)
+	}
+	if !IsSymbol(newName) {
+		return fmt.Errorf("Bad field name: %q", newName)
+	}
+	if findField(td, newName) != nil {
+		return fmt.Errorf("Type %s already has a field named '%s'", typeName, newName)
+	}
+	wireName := fd.WireName()
+	for _, hd := range model.Http {
+		if pg := hd.Paginated; pg != nil {
+			pg.OutputToken = model.renamePathField(hd, pg.OutputToken, fd, newName)
+			pg.Items = model.renamePathField(hd, pg.Items, fd, newName)
+		}
+	}
+	fd.Name = newName
+	if keepWireName && GetAnnotation(fd.Annotations, "x_wire_name") == "" {
+		if fd.Annotations == nil {
+			fd.Annotations = make(map[string]interface{})
+		}
+		fd.Annotations["x_wire_name"] = name
+	}
+	if newWireName := fd.WireName(); newWireName != wireName {
+		model.forEachExampleValue(func(ts *TypeSpec, v interface{}) {
+			if obj, ok := v.(map[string]interface{}); ok && ts == &td.TypeSpec {
+				if val, ok := obj[wireName]; ok {
+					delete(obj, wireName)
+					obj[newWireName] = val
+				}
+			}
+		})
+	}
+	for _, ex := range model.Examples {
+		if ex.Target == typeName+"."+name {
+			ex.Target = typeName + "." + newName
+		}
+	}
+	return nil
+}
+
+// renamePathField returns a dotted path into the outputs of an http action, with the name of the given field replaced
+// wherever the path goes through it.
+func (model *Model) renamePathField(hd *HttpDef, path string, fd *StructFieldDef, newName string) string {
+	names := strings.Split(path, ".")
+	for i, f := range model.HttpOutputPath(hd, path) {
+		if f == fd {
+			names[i] = newName
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// InlineType replaces every reference to a type that is not a Struct, Enum, or Union with its definition, and
+// removes the type. The options of a field that referred to the type take precedence over those of the type. Where
+// only a type name can be used, i.e. as the items of an Array, the type must have no options of its own.
+func (model *Model) InlineType(name string) error {
+	td, err := model.userType(name)
+	if err != nil {
+		return err
+	}
+	switch td.Type {
+	case "Struct", "Enum", "Union":
+		return fmt.Errorf("Cannot inline %s, a %s type", name, td.Type)
+	}
+	plain := reflect.DeepEqual(td.TypeSpec, TypeSpec{Type: td.Type})
+	var problem error
+	model.forEachTypeSpec(func(ts *TypeSpec) {
+		for _, ref := range []string{ts.Items, ts.Keys, ts.Value, ts.Unit} {
+			if ref == name && !plain {
+				problem = fmt.Errorf("Cannot inline %s, it is used as a type parameter and has options of its own", name)
+			}
+		}
+	})
+	for _, hd := range model.Http {
+		for _, exc := range hd.Exceptions {
+			if exc.Type == name {
+				problem = fmt.Errorf("Cannot inline %s, it is the type of an exception of http action %s", name, hd.Name)
+			}
+		}
+	}
+	for _, exc := range model.Exceptions {
+		if exc.Type == name {
+			problem = fmt.Errorf("Cannot inline %s, it is the type of an exception common to all http actions", name)
+		}
+	}
+	for _, op := range model.Operations {
+		if containsOption(op.Exceptions, name) {
+			problem = fmt.Errorf("Cannot inline %s, it is an exception of operation %s", name, op.Name)
+		}
+	}
+	for _, ex := range model.Examples {
+		if ex.Target == name {
+			problem = fmt.Errorf("Cannot inline %s, it is the target of an example", name)
+		}
+	}
+	if problem != nil {
+		return problem
+	}
+	model.forEachTypeSpec(func(ts *TypeSpec) {
+		if ts.Type == name {
+			*ts = inlinedTypeSpec(&td.TypeSpec, ts)
+		}
+		for _, ref := range []*string{&ts.Items, &ts.Keys, &ts.Value, &ts.Unit} {
+			if *ref == name {
+				*ref = td.Type
+			}
+		}
+	})
+	for _, cd := range model.Constants {
+		if cd.Type == name {
+			cd.Type = td.Type
+		}
+	}
+	model.removeType(td)
+	return nil
+}
+
+// ExtractType moves some of the fields of a Struct type into a new Struct type, and replaces them with a single field
+// of the new type, which is required if any of the moved fields were. Examples of the type are restructured to match.
+func (model *Model) ExtractType(typeName string, newName string, fieldName string, fieldNames ...string) error {
+	td, err := model.userType(typeName)
+	if err != nil {
+		return err
+	}
+	if td.Type != "Struct" {
+		return fmt.Errorf("Cannot extract fields from %s, a %s type", typeName, td.Type)
+	}
+	err = model.checkNewTypeName(newName)
+	if err != nil {
+		return err
+	}
+	if len(fieldNames) == 0 {
+		return fmt.Errorf("No fields to extract from %s", typeName)
+	}
+	for _, fname := range fieldNames {
+		if findField(td, fname) == nil {
+			return fmt.Errorf("No field named '%s' in type %s", fname, typeName)
+		}
+	}
+	if !IsSymbol(fieldName) {
+		return fmt.Errorf("Bad field name: %q", fieldName)
+	}
+	if findField(td, fieldName) != nil && !containsOption(fieldNames, fieldName) {
+		return fmt.Errorf("Type %s already has a field named '%s'", typeName, fieldName)
+	}
+	ntd := &TypeDef{Name: newName, TypeSpec: TypeSpec{Type: "Struct"}}
+	field := &StructFieldDef{Name: fieldName, TypeSpec: TypeSpec{Type: newName}}
+	var fields []*StructFieldDef
+	for _, fd := range td.Fields {
+		if !containsOption(fieldNames, fd.Name) {
+			fields = append(fields, fd)
+			continue
+		}
+		if len(ntd.Fields) == 0 {
+			fields = append(fields, field)
+		}
+		ntd.Fields = append(ntd.Fields, fd)
+		field.Required = field.Required || fd.Required
+	}
+	model.forEachExampleValue(func(ts *TypeSpec, v interface{}) {
+		if obj, ok := v.(map[string]interface{}); ok && ts == &td.TypeSpec {
+			nested := make(map[string]interface{})
+			for _, fd := range ntd.Fields {
+				if val, ok := obj[fd.WireName()]; ok {
+					nested[fd.WireName()] = val
+					delete(obj, fd.WireName())
+				}
+			}
+			if len(nested) > 0 {
+				obj[fieldName] = nested
+			}
+		}
+	})
+	for _, ex := range model.Examples {
+		if n := strings.Index(ex.Target, "."); n > 0 && ex.Target[:n] == typeName && containsOption(fieldNames, ex.Target[n+1:]) {
+			ex.Target = newName + ex.Target[n:]
+		}
+	}
+	td.Fields = fields
+	//the new type goes just after the one it was extracted from
+	var types []*TypeDef
+	for _, t := range model.Types {
+		types = append(types, t)
+		if t == td {
+			types = append(types, ntd)
+		}
+	}
+	model.Types = types
+	model.typeIndex[newName] = ntd
+	return nil
+}
+
+func (model *Model) userType(name string) (*TypeDef, error) {
+	for _, td := range model.Types {
+		if td.Name == name {
+			return td, nil
+		}
+	}
+	return nil, fmt.Errorf("No type named '%s' is defined in the model", name)
+}
+
+func (model *Model) checkNewTypeName(name string) error {
+	if !IsSymbol(name) {
+		return fmt.Errorf("Bad type name: %q", name)
+	}
+	if model.FindType(name) != nil {
+		return fmt.Errorf("Type already exists: %s", name)
+	}
+	return nil
+}
+
+func (model *Model) removeType(td *TypeDef) {
+	var types []*TypeDef
+	for _, t := range model.Types {
+		if t != td {
+			types = append(types, t)
+		}
+	}
+	model.Types = types
+	delete(model.typeIndex, td.Name)
+}
+
+func findField(td *TypeDef, name string) *StructFieldDef {
+	for _, fd := range td.Fields {
+		if fd.Name == name {
+			return fd
+		}
+	}
+	return nil
+}
+
+// forEachTypeSpec calls the function with every type spec in the model, including those of fields and params.
+func (model *Model) forEachTypeSpec(fn func(ts *TypeSpec)) {
+	var visit func(ts *TypeSpec)
+	visit = func(ts *TypeSpec) {
+		fn(ts)
+		for _, fd := range ts.Fields {
+			visit(&fd.TypeSpec)
+		}
+		for _, vd := range ts.Variants {
+			visit(&vd.TypeSpec)
+		}
+	}
+	for _, td := range model.Types {
+		visit(&td.TypeSpec)
+	}
+	for _, ad := range model.AnnotationDefs {
+		visit(&ad.TypeSpec)
+	}
+	for _, op := range model.Operations {
+		for _, in := range op.Inputs {
+			visit(&in.TypeSpec)
+		}
+		for _, out := range op.Outputs {
+			visit(&out.TypeSpec)
+		}
+	}
+	for _, hd := range model.Http {
+		for _, in := range hd.Inputs {
+			visit(&in.TypeSpec)
+		}
+		if hd.Expected != nil {
+			for _, out := range hd.Expected.Outputs {
+				visit(&out.TypeSpec)
+			}
+		}
+		for _, exc := range hd.Exceptions {
+			for _, out := range exc.Outputs {
+				visit(&out.TypeSpec)
+			}
+		}
+	}
+	for _, exc := range model.Exceptions {
+		for _, out := range exc.Outputs {
+			visit(&out.TypeSpec)
+		}
+	}
+}
+
+// forEachExampleValue calls the function with every value in the examples of the model, and the type spec it is an
+// instance of. A value of a defined type is given with the type spec of its definition.
+func (model *Model) forEachExampleValue(fn func(ts *TypeSpec, v interface{})) {
+	var visit func(ts *TypeSpec, v interface{})
+	visit = func(ts *TypeSpec, v interface{}) {
+		if td := model.FindType(ts.Type); td != nil && td.Name != td.Type {
+			ts = &td.TypeSpec
+		}
+		fn(ts, v)
+		switch val := v.(type) {
+		case map[string]interface{}:
+			switch ts.Type {
+			case "Struct":
+				for _, fd := range ts.Fields {
+					if fv, ok := val[fd.WireName()]; ok {
+						visit(&fd.TypeSpec, fv)
+					}
+				}
+			case "Union":
+				for _, vd := range ts.Variants {
+					if vv, ok := val[vd.WireName()]; ok {
+						visit(&vd.TypeSpec, vv)
+					}
+				}
+			case "Map":
+				for _, item := range val {
+					visit(&TypeSpec{Type: ts.Items}, item)
+				}
+			}
+		case []interface{}:
+			if ts.Type == "Array" {
+				for _, item := range val {
+					visit(&TypeSpec{Type: ts.Items}, item)
+				}
+			}
+		}
+	}
+	params := func(specs []*HttpParamSpec, v interface{}) {
+		if obj, ok := v.(map[string]interface{}); ok {
+			for _, spec := range specs {
+				if pv, ok := obj[spec.Name]; ok {
+					visit(&spec.TypeSpec, pv)
+				}
+			}
+		}
+	}
+	for _, ex := range model.Examples {
+		if td := model.FindType(ex.Target); td != nil {
+			visit(&td.TypeSpec, ex.Example)
+		} else if n := strings.Index(ex.Target, "."); n > 0 {
+			if td := model.FindType(ex.Target[:n]); td != nil {
+				if fd := findField(td, ex.Target[n+1:]); fd != nil {
+					visit(&fd.TypeSpec, ex.Example)
+				}
+			}
+		} else if _, exc := model.FindHttpException(ex.Target); exc != nil {
+			params(exc.Outputs, ex.Example)
+		} else if strings.HasSuffix(ex.Target, "Request") {
+			if hd := model.FindHttp(Uncapitalize(strings.TrimSuffix(ex.Target, "Request"))); hd != nil {
+				params(hd.Inputs, ex.Example)
+			}
+		} else if strings.HasSuffix(ex.Target, "Response") {
+			if hd := model.FindHttp(Uncapitalize(strings.TrimSuffix(ex.Target, "Response"))); hd != nil && hd.Expected != nil {
+				params(hd.Expected.Outputs, ex.Example)
+			}
+		}
+	}
+}
@@ -23,17 +23,23 @@ var BaseTypes = []string{
 }
 
 type Schema struct {
-	Sadl        string            `json:"sadl"`
-	Name        string            `json:"name"`
-	Namespace   string            `json:"namespace,omitempty"`
-	Version     string            `json:"version,omitempty"`
-	Comment     string            `json:"comment,omitempty"`
-	Types       []*TypeDef        `json:"types,omitempty"`
-	Examples    []*ExampleDef     `json:"examples,omitempty"`
-	Operations  []*OperationDef   `json:"operations,omitempty"`
-	Http        []*HttpDef        `json:"http,omitempty"`
-	Base        string            `json:"base,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Sadl           string                 `json:"sadl"`
+	Name           string                 `json:"name"`
+	Namespace      string                 `json:"namespace,omitempty"`
+	Version        string                 `json:"version,omitempty"`
+	Comment        string                 `json:"comment,omitempty"`
+	Types          []*TypeDef             `json:"types,omitempty"`
+	Constants      []*ConstantDef         `json:"constants,omitempty"`
+	AnnotationDefs []*AnnotationDef       `json:"annotationDefs,omitempty"`
+	Examples       []*ExampleDef          `json:"examples,omitempty"`
+	Services       []*ServiceDef          `json:"services,omitempty"`
+	Resources      []*ResourceDef         `json:"resources,omitempty"`
+	Operations     []*OperationDef        `json:"operations,omitempty"`
+	Http           []*HttpDef             `json:"http,omitempty"`
+	Exceptions     []*HttpExceptionSpec   `json:"exceptions,omitempty"`
+	Auth           []*AuthDef             `json:"auth,omitempty"`
+	Base           string                 `json:"base,omitempty"`
+	Annotations    map[string]interface{} `json:"annotations,omitempty"`
 }
 
 type TypeSpec struct {
@@ -55,49 +61,74 @@ type TypeSpec struct {
 }
 
 type TypeDef struct {
-	Name        string            `json:"name"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Name        string                 `json:"name"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
 	TypeSpec
 }
 
+// AnnotationDef declares the type of an x_* annotation, and the kinds of definitions it may be attached to.
+type AnnotationDef struct {
+	Name    string   `json:"name"`
+	Comment string   `json:"comment,omitempty"`
+	Targets []string `json:"targets,omitempty"`
+	Span    *Span    `json:"span,omitempty"`
+	TypeSpec
+}
+
+type ConstantDef struct {
+	Name        string                 `json:"name"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+	Type        string                 `json:"type"`
+	Value       interface{}            `json:"value"`
+}
+
 type EnumElementDef struct {
-	Symbol      string            `json:"symbol"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Symbol      string                 `json:"symbol"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
 }
 
 type StructFieldDef struct {
-	Name        string            `json:"name"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
-	Required    bool              `json:"required,omitempty"`
-	Default     interface{}       `json:"default,omitempty"`
+	Name        string                 `json:"name"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+	Required    bool                   `json:"required,omitempty"`
+	Nullable    bool                   `json:"nullable,omitempty"`
+	Default     interface{}            `json:"default,omitempty"`
 	TypeSpec
 }
 
 type UnionVariantDef struct {
-	Name        string            `json:"name"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Name        string                 `json:"name"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
 	TypeSpec
 }
 
 type ExampleDef struct {
-	Target      string            `json:"target"`
-	Name        string            `json:"name,omitempty"`
-	Example     interface{}       `json:"example,omitempty"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Target      string                 `json:"target"`
+	Name        string                 `json:"name,omitempty"`
+	Example     interface{}            `json:"example,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
 }
 
 type OperationDef struct {
-	Name        string             `json:"name,omitempty"`
-	Comment     string             `json:"comment,omitempty"`
-	Annotations map[string]string  `json:"annotations,omitempty"`
-	Inputs      []*OperationInput  `json:"inputs,omitempty"`
-	Outputs     []*OperationOutput `json:"outputs,omitempty"`
-	Exceptions  []string           `json:"exceptions,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+	Inputs      []*OperationInput      `json:"inputs,omitempty"`
+	Outputs     []*OperationOutput     `json:"outputs,omitempty"`
+	Exceptions  []string               `json:"exceptions,omitempty"`
 }
 
 type OperationInput struct {
@@ -105,41 +136,116 @@ type OperationInput struct {
 }
 
 type OperationOutput struct {
-	Name        string            `json:"name"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Name        string                 `json:"name"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
 	TypeSpec
 }
 
 type HttpDef struct {
-	Name        string               `json:"name,omitempty"`
-	Resource    string               `json:"resource,omitempty"`
-	Comment     string               `json:"comment,omitempty"`
-	Annotations map[string]string    `json:"annotations,omitempty"`
-	Method      string               `json:"method"`
-	Path        string               `json:"path"`
-	Inputs      []*HttpParamSpec     `json:"inputs,omitempty"`
-	Expected    *HttpExpectedSpec    `json:"expected,omitempty"`
-	Exceptions  []*HttpExceptionSpec `json:"exceptions,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Resource    string                 `json:"resource,omitempty"`
+	Service     string                 `json:"service,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+	Method      string                 `json:"method"`
+	Path        string                 `json:"path"`
+	Inputs      []*HttpParamSpec       `json:"inputs,omitempty"`
+	Expected    *HttpExpectedSpec      `json:"expected,omitempty"`
+	Exceptions  []*HttpExceptionSpec   `json:"exceptions,omitempty"`
+	Auth        []string               `json:"auth,omitempty"`
+	Scopes      []string               `json:"scopes,omitempty"`
+	Anonymous   bool                   `json:"anonymous,omitempty"`
+	Paginated   *HttpPaginatedSpec     `json:"paginated,omitempty"`
+	Statements  map[string]interface{} `json:"statements,omitempty"`
+}
+
+// HttpPaginatedSpec names the inputs and outputs of an http action that return its results in pages. The input token
+// and page size are inputs, the output token and items are dotted paths into the outputs.
+type HttpPaginatedSpec struct {
+	InputToken  string `json:"inputToken"`
+	OutputToken string `json:"outputToken"`
+	PageSize    string `json:"pageSize,omitempty"`
+	Items       string `json:"items,omitempty"`
 }
 
 type HttpParamSpec struct {
-	Header string `json:"header,omitempty"`
-	Query  string `json:"query,omitempty"`
-	Path   bool   `json:"path,omitempty"`
+	Header    string `json:"header,omitempty"`
+	Query     string `json:"query,omitempty"`
+	Cookie    string `json:"cookie,omitempty"`
+	Path      bool   `json:"path,omitempty"`
+	Greedy    bool   `json:"greedy,omitempty"`
+	Csv       bool   `json:"csv,omitempty"`
+	MediaType string `json:"mediaType,omitempty"`
 	StructFieldDef
 }
 
 type HttpExpectedSpec struct {
-	Outputs     []*HttpParamSpec  `json:"outputs,omitempty"`
-	Status      int32             `json:"status"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Outputs     []*HttpParamSpec       `json:"outputs,omitempty"`
+	Status      int32                  `json:"status"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
 }
 
 type HttpExceptionSpec struct {
-	Type        string            `json:"type"`
-	Status      int32             `json:"status"`
-	Comment     string            `json:"comment,omitempty"`
-	Annotations map[string]string `json:"annotations,omitempty"`
+	Type        string                 `json:"type"`
+	Status      int32                  `json:"status"`
+	Outputs     []*HttpParamSpec       `json:"outputs,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+}
+
+// AuthDef is an authentication scheme of the service, one of "apiKey", "bearer", "basic", "oauth2", or "mutualTLS".
+// An http action requires the credentials of any one of the schemes, unless it names others.
+type AuthDef struct {
+	Name             string                 `json:"name"`
+	Scheme           string                 `json:"scheme"`
+	Header           string                 `json:"header,omitempty"`
+	Query            string                 `json:"query,omitempty"`
+	Cookie           string                 `json:"cookie,omitempty"`
+	Format           string                 `json:"format,omitempty"`
+	AuthorizationUrl string                 `json:"authorizationUrl,omitempty"`
+	TokenUrl         string                 `json:"tokenUrl,omitempty"`
+	Scopes           []string               `json:"scopes,omitempty"`
+	Comment          string                 `json:"comment,omitempty"`
+	Annotations      map[string]interface{} `json:"annotations,omitempty"`
+	Span             *Span                  `json:"span,omitempty"`
+}
+
+// ServiceDef is a service of the model, with its own version and base path. It groups the http actions that name it,
+// or all of them if it is the only one. A model that declares no services is itself the service of all its actions.
+type ServiceDef struct {
+	Name        string                 `json:"name"`
+	Version     string                 `json:"version,omitempty"`
+	Base        string                 `json:"base,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+}
+
+// ResourceDef is a resource, identified by path variables of its http actions, with the actions that create, read,
+// update, delete, and list its instances.
+type ResourceDef struct {
+	Name        string                 `json:"name"`
+	Identifiers []string               `json:"identifiers,omitempty"`
+	Create      string                 `json:"create,omitempty"`
+	Read        string                 `json:"read,omitempty"`
+	Update      string                 `json:"update,omitempty"`
+	Delete      string                 `json:"delete,omitempty"`
+	List        string                 `json:"list,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Span        *Span                  `json:"span,omitempty"`
+}
+
+// Span is the location of a definition in its source file. Lines and columns are 1-based, the end is inclusive.
+type Span struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Col     int    `json:"col"`
+	EndLine int    `json:"endLine"`
+	EndCol  int    `json:"endCol"`
 }
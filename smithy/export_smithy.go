@@ -93,7 +93,26 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 	if model.Base != "" {
 		ast.Metadata.Put("base", model.Base)
 	}
+	if len(model.Constants) > 0 {
+		constants := data.NewObject()
+		for _, cd := range model.Constants {
+			c := data.NewObject()
+			c.Put("type", cd.Type)
+			c.Put("value", cd.Value)
+			if cd.Comment != "" {
+				c.Put("documentation", cd.Comment)
+			}
+			constants.Put(cd.Name, c)
+		}
+		ast.Metadata.Put("constants", constants)
+	}
 
+	for _, ad := range model.AnnotationDefs {
+		err := defineTraitShape(model, ns, ast.Shapes, ad)
+		if err != nil {
+			return nil, err
+		}
+	}
 	for _, td := range model.Types {
 		err := defineShapeFromTypeSpec(model, ns, ast.Shapes, &td.TypeSpec, td.Name, td.Comment, td.Annotations)
 		if err != nil {
@@ -101,16 +120,15 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 		}
 	}
 	var ops []*smithylib.ShapeRef
+	serviceOps := make(map[string][]*smithylib.ShapeRef, 0)
 	prefix := ns + "#"
 	for _, hd := range model.Http {
 		expectedCode := 200
 		if hd.Expected != nil {
 			expectedCode = int(hd.Expected.Status)
 		}
-		path := hd.Path
-		if model.Base != "" {
-			path = model.Base + path
-		}
+		//Smithy has no base path for a service, it precedes the uri of each of its operations
+		path := model.Base + model.HttpBase(hd) + hd.Path
 		n := strings.Index(path, "?")
 		if n >= 0 {
 			path = path[:n]
@@ -125,13 +143,30 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 		}
 		ensureShapeTraits(&shape).Put("smithy.api#documentation", hd.Comment)
 		ensureShapeTraits(&shape).Put("smithy.api#http", httpTrait(path, hd.Method, expectedCode))
+		if hd.Paginated != nil {
+			ensureShapeTraits(&shape).Put("smithy.api#paginated", paginatedSpecTrait(hd.Paginated))
+		}
 		if hd.Annotations != nil {
-			if tags, ok := hd.Annotations["x_tags"]; ok {
-				ensureShapeTraits(&shape).Put("smithy.api#tags", strings.Split(tags, ","))
+			if tags := sadl.GetAnnotationStrings(hd.Annotations, "x_tags"); tags != nil {
+				ensureShapeTraits(&shape).Put("smithy.api#tags", tags)
 			}
-			if pagi, ok := hd.Annotations["x_paginated"]; ok {
+			if pagi, ok := hd.Annotations["x_paginated"]; ok && hd.Paginated == nil {
 				ensureShapeTraits(&shape).Put("smithy.api#paginated", paginatedTrait(pagi))
 			}
+			for id, v := range customTraits(model, ns, hd.Annotations) {
+				ensureShapeTraits(&shape).Put(id, v)
+			}
+		}
+		//the scopes an action requires have no equivalent in Smithy
+		if hd.Anonymous {
+			ensureShapeTraits(&shape).Put("smithy.api#auth", []string{})
+		} else if len(hd.Auth) > 0 {
+			//without an auth trait the operation would accept all the schemes of its service
+			ids := authTraitIds(model.HttpAuth(hd))
+			if len(ids) == 0 {
+				return nil, fmt.Errorf("Action '%s': Smithy has no auth trait for its auth schemes %v", hd.Name, hd.Auth)
+			}
+			ensureShapeTraits(&shape).Put("smithy.api#auth", ids)
 		}
 		switch hd.Method {
 		case "GET":
@@ -149,9 +184,20 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 			}
 			ensureShapeTraits(&inShape).Put("smithy.api#input", true)
 			for _, in := range hd.Inputs {
+				if in.Cookie != "" {
+					continue //Smithy has no http binding for cookies
+				}
 				mem := &smithylib.Member{
 					Target: typeReferenceByName(ns, in.Type),
 				}
+				if in.Type == "Array" {
+					//Smithy repeats the values of a list-typed query param, and comma-separates those of a header
+					mem.Target = ns + "#" + listTypeReference(model, ns, ast.Shapes, name+inputSuffix, &in.StructFieldDef)
+				}
+				if len(in.Values) > 0 {
+					mem.Target = valuesTypeReference(model, ns, ast.Shapes, name+inputSuffix, in.Name, &in.TypeSpec)
+				}
+				constraintTraits(mem, &in.TypeSpec)
 				if in.Path {
 					ensureMemberTraits(mem).Put("smithy.api#httpLabel", true)
 					ensureMemberTraits(mem).Put("smithy.api#required", true)
@@ -162,6 +208,9 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 				} else {
 					ensureMemberTraits(mem).Put("smithy.api#httpPayload", true)
 				}
+				for id, v := range customTraits(model, ns, in.Annotations) {
+					ensureMemberTraits(mem).Put(id, v)
+				}
 				inShape.Members.Put(in.Name, mem)
 			}
 			ast.Shapes.Put(shape.Input.Target, &inShape)
@@ -176,60 +225,69 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 			}
 			ensureShapeTraits(&outShape).Put("smithy.api#output", true)
 			for _, out := range hd.Expected.Outputs {
+				if out.Cookie != "" {
+					continue
+				}
 				mem := &smithylib.Member{
 					Target: typeReferenceByName(ns, out.Type),
 				}
+				if len(out.Values) > 0 {
+					mem.Target = valuesTypeReference(model, ns, ast.Shapes, name+outputSuffix, out.Name, &out.TypeSpec)
+				}
+				constraintTraits(mem, &out.TypeSpec)
 				if out.Header != "" {
 					ensureMemberTraits(mem).Put("smithy.api#httpHeader", out.Header)
 				} else {
 					ensureMemberTraits(mem).Put("smithy.api#httpPayload", true)
 				}
+				for id, v := range customTraits(model, ns, out.Annotations) {
+					ensureMemberTraits(mem).Put(id, v)
+				}
 				outShape.Members.Put(out.Name, mem)
 			}
 			ast.Shapes.Put(shape.Output.Target, &outShape)
 		}
 
-		//if we have any exceptions, define them
-		if len(hd.Exceptions) > 0 {
-			for _, e := range hd.Exceptions {
-				//so, these are *wrappers* for error resources in smithy
-				//i.e. I need to generate *another* type with a field marked with @httpPayload attribute
-				//So, this is the "NotFoundErrorContent" type that smithy->openapi produces. If I specify it,
-				//then smithy reference tooling does not generate anything else.
-				//typical pattern to use:
-				// type Error Struct { message String }
-				// type NotFoundError { error Error } //this is the wrapper for the operation
-				//this produces (assuming NotFoundError is referenced in an 'except' response):
-				// structure Error { message: String }
-				// @error("client")
-				// @httpError(404)
-				// structure NotFoundError {
-				//   @httpPayload
-				//   error: Error
-				// }
-				//to do: modify the sadl model to support headers in exception responses
-				em := &smithylib.ShapeRef{Target: ns + "#" + e.Type}
-				shape.Errors = append(shape.Errors, em)
-				if tmp := ast.Shapes.Get(em.Target); tmp != nil {
-					ensureShapeTraits(tmp).Put("smithy.api#httpError", e.Status)
-					ensureShapeTraits(tmp).Put("smithy.api#error", httpErrorCategory(e.Status))
-					//check that the shape has a single member that is a struct. If so, mark it as payload
-					if tmp.Members != nil && tmp.Members.Length() == 1 {
-						for _, k := range tmp.Members.Keys() {
-							m := tmp.Members.Get(k)
-							ensureMemberTraits(m).Put("smithy.api#httpPayload", true)
-						}
-					}
-				} else {
-					return nil, fmt.Errorf("Cannot find shape for error declaration type %q", e.Type)
-				}
+		//if we have any exceptions, define them. Those common to all actions are errors of the service instead.
+		for _, e := range hd.Exceptions {
+			em, err := defineErrorShape(ns, ast, e, prefix+name+"Except"+e.Type)
+			if err != nil {
+				return nil, err
 			}
+			shape.Errors = append(shape.Errors, em)
 		}
 		ast.Shapes.Put(prefix+name, &shape)
-		ops = append(ops, &smithylib.ShapeRef{
+		ref := &smithylib.ShapeRef{
 			Target: prefix + name,
-		})
-
+		}
+		if rd, _ := model.HttpLifecycle(hd); rd != nil {
+			//the resource binds the operation, rather than the service
+		} else if sd := model.HttpService(hd); sd != nil {
+			serviceOps[sd.Name] = append(serviceOps[sd.Name], ref)
+		} else {
+			ops = append(ops, ref)
+		}
+	}
+	var resources []*smithylib.ShapeRef
+	serviceResources := make(map[string][]*smithylib.ShapeRef, 0)
+	for _, rd := range model.Resources {
+		ref, err := defineResourceShape(model, ns, ast, rd)
+		if err != nil {
+			return nil, err
+		}
+		//a resource is in the service of its actions
+		var sd *sadl.ServiceDef
+		for _, op := range sadl.ResourceLifecycle {
+			if hd := model.FindHttp(rd.LifecycleAction(op)); hd != nil {
+				sd = model.HttpService(hd)
+				break
+			}
+		}
+		if sd != nil {
+			serviceResources[sd.Name] = append(serviceResources[sd.Name], ref)
+		} else {
+			resources = append(resources, ref)
+		}
 	}
 	for _, od := range model.Operations {
 		shape := smithylib.Shape{
@@ -281,20 +339,31 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 		})
 	}
 
-	if len(ops) > 0 {
-		service := &smithylib.Shape{
-			Type:       "service",
-			Version:    model.Version,
-			Operations: ops,
-		}
-		if service.Version == "" {
-			service.Version = "0.0" //Smithy requires a version on a service
+	if len(model.Services) == 0 {
+		if len(ops) > 0 || len(resources) > 0 {
+			err := defineServiceShape(model, ns, ast, model.Name, model.Version, model.Comment, ops, resources)
+			if err != nil {
+				return nil, err
+			}
 		}
-		if model.Comment != "" {
-			ensureShapeTraits(service).Put("smithy.api#documentation", model.Comment)
+	} else {
+		//the model is named in the metadata, since each service is named for itself. Operations that are not http
+		//actions belong to the first service.
+		ast.Metadata.Put("name", model.Name)
+		for i, sd := range model.Services {
+			sops := serviceOps[sd.Name]
+			if i == 0 {
+				sops = append(sops, ops...)
+			}
+			version := sd.Version
+			if version == "" {
+				version = model.Version
+			}
+			err := defineServiceShape(model, ns, ast, sd.Name, version, sd.Comment, sops, serviceResources[sd.Name])
+			if err != nil {
+				return nil, err
+			}
 		}
-		serviceName := sadl.Capitalize(model.Name)
-		ast.Shapes.Put(prefix+serviceName, service)
 	}
 	if len(model.Examples) > 0 {
 		examplesFromSADL(ns, ast, model)
@@ -302,6 +371,96 @@ func FromSADL(model *sadl.Model, ns string) (*smithylib.AST, error) {
 	return ast, nil
 }
 
+// defineServiceShape defines a service of the operations. The auth schemes and common exceptions of the model apply to
+// all of its services.
+func defineServiceShape(model *sadl.Model, ns string, ast *smithylib.AST, name string, version string, comment string, ops []*smithylib.ShapeRef, resources []*smithylib.ShapeRef) error {
+	service := &smithylib.Shape{
+		Type:       "service",
+		Version:    version,
+		Operations: ops,
+		Resources:  resources,
+	}
+	if service.Version == "" {
+		service.Version = "0.0" //Smithy requires a version on a service
+	}
+	if comment != "" {
+		ensureShapeTraits(service).Put("smithy.api#documentation", comment)
+	}
+	serviceName := sadl.Capitalize(name)
+	for _, ad := range model.Auth {
+		if id, v := authTrait(ad); id != "" {
+			ensureShapeTraits(service).Put(id, v)
+		}
+	}
+	if ids := authTraitIds(model.Auth); len(ids) > 0 {
+		ensureShapeTraits(service).Put("smithy.api#auth", ids)
+	} else if len(model.Auth) > 0 {
+		//without an auth trait the operations of the service would need no authentication at all
+		return fmt.Errorf("Service '%s': Smithy has no auth trait for its auth schemes", serviceName)
+	}
+	prefix := ns + "#"
+	for _, e := range model.Exceptions {
+		em, err := defineErrorShape(ns, ast, e, prefix+serviceName+"Except"+e.Type)
+		if err != nil {
+			return err
+		}
+		service.Errors = append(service.Errors, em)
+	}
+	ast.Shapes.Put(prefix+serviceName, service)
+	return nil
+}
+
+// defineResourceShape defines a resource, bound to the operations of its http actions. Each identifier targets the type
+// of the path variable of that name. The resource is named for the type it manages, so it is given a suffix if a shape
+// of that name is already defined.
+func defineResourceShape(model *sadl.Model, ns string, ast *smithylib.AST, rd *sadl.ResourceDef) (*smithylib.ShapeRef, error) {
+	prefix := ns + "#"
+	name := prefix + sadl.Capitalize(rd.Name)
+	if ast.Shapes.Get(name) != nil {
+		name = name + "Resource"
+	}
+	shape := &smithylib.Shape{
+		Type: "resource",
+	}
+	if rd.Comment != "" {
+		ensureShapeTraits(shape).Put("smithy.api#documentation", rd.Comment)
+	}
+	for _, op := range sadl.ResourceLifecycle {
+		hd := model.FindHttp(rd.LifecycleAction(op))
+		if hd == nil {
+			continue
+		}
+		ref := &smithylib.ShapeRef{Target: prefix + capitalize(hd.Name)}
+		switch op {
+		case "create":
+			shape.Create = ref
+		case "read":
+			shape.Read = ref
+		case "update":
+			shape.Update = ref
+		case "delete":
+			shape.Delete = ref
+		case "list":
+			shape.List = ref
+		}
+		for _, id := range rd.Identifiers {
+			for _, in := range hd.Inputs {
+				if in.Path && in.Name == id {
+					if shape.Identifiers == nil {
+						shape.Identifiers = make(map[string]*smithylib.ShapeRef, 0)
+					}
+					shape.Identifiers[id] = &smithylib.ShapeRef{Target: typeReferenceByName(ns, in.Type)}
+				}
+			}
+		}
+	}
+	if len(shape.Identifiers) != len(rd.Identifiers) {
+		return nil, fmt.Errorf("Resource %s: its identifiers are not all path variables of its actions", rd.Name)
+	}
+	ast.Shapes.Put(name, shape)
+	return &smithylib.ShapeRef{Target: name}, nil
+}
+
 func sadlExamplesForAction(model *sadl.Model, hdef *sadl.HttpDef) []map[string]interface{} {
 	opName := sadl.Capitalize(hdef.Name)
 	reqType := opName + "Request"
@@ -325,7 +484,18 @@ func sadlExamplesForAction(model *sadl.Model, hdef *sadl.HttpDef) []map[string]i
 				if ex.Target == resType {
 					data["output"] = ex.Example.(map[string]interface{})
 				} else {
-					for _, exc := range hdef.Exceptions {
+					for _, exc := range model.HttpExceptions(hdef) {
+						if len(exc.Outputs) > 0 && sadl.ExceptionExampleTarget(hdef, exc) == ex.Target {
+							//the example is of the whole wrapper, whose members are the outputs
+							tmp := make(map[string]interface{}, 0)
+							tmp["error"] = ex.Example.(map[string]interface{})
+							tmp["shapeId"] = ex.Target
+							if model.IsCommonException(exc) {
+								tmp["shapeId"] = sadl.Capitalize(model.Name) + "Except" + exc.Type
+							}
+							data["error"] = tmp
+							break
+						}
 						if exc.Type == ex.Target {
 							tmp := make(map[string]interface{}, 0)
 							tmp["error"] = ex.Example.(map[string]interface{})
@@ -404,16 +574,8 @@ func typeReferenceByName(ns string, name string) string {
 	}
 }
 
-func getAnnotation(annos map[string]string, key string) string {
-	if annos != nil {
-		if s, ok := annos[key]; ok {
-			if s == "" {
-				s = "true"
-			}
-			return s
-		}
-	}
-	return ""
+func getAnnotation(annos map[string]interface{}, key string) string {
+	return sadl.GetAnnotation(annos, key)
 }
 
 func listTypeReference(model *sadl.Model, ns string, shapes *smithylib.Shapes, prefix string, fd *sadl.StructFieldDef) string {
@@ -479,6 +641,36 @@ func enumTypeReference(model *sadl.Model, ns string, shapes *smithylib.Shapes, p
 	return ftype
 }
 
+// valuesTypeReference defines a string shape for a member with inline values, since the enum trait cannot be applied
+// to members.
+func valuesTypeReference(model *sadl.Model, ns string, shapes *smithylib.Shapes, prefix string, name string, ts *sadl.TypeSpec) string {
+	ftype := capitalize(prefix) + capitalize(name)
+	if model.FindType(ftype) != nil {
+		panic("Already have a type named " + ftype + ", cannot synthesize one for the values of " + prefix + "." + name)
+	}
+	shape := shapeFromString(model.ConstrainedTypeSpec(ts))
+	ensureShapeTraits(&shape).Put("smithy.api#documentation", "[autogenerated for field '"+name+"' in '"+prefix+"']")
+	shapes.Put(ns+"#"+ftype, &shape)
+	return ns + "#" + ftype
+}
+
+// constraintTraits applies the constraints given inline on a field, param, or variant to its member. Those of a member
+// with values are all on the shape defined by valuesTypeReference.
+func constraintTraits(member *smithylib.Member, ts *sadl.TypeSpec) {
+	if len(ts.Values) > 0 {
+		return
+	}
+	if l := lengthTrait(ts.MinSize, ts.MaxSize); l != nil {
+		ensureMemberTraits(member).Put("smithy.api#length", l)
+	}
+	if ts.Pattern != "" {
+		ensureMemberTraits(member).Put("smithy.api#pattern", ts.Pattern)
+	}
+	if r := rangeTrait(ts.Min, ts.Max); r != nil {
+		ensureMemberTraits(member).Put("smithy.api#range", r)
+	}
+}
+
 func capitalize(s string) string {
 	if s == "" {
 		return s
@@ -500,7 +692,7 @@ func ensureMemberTraits(member *smithylib.Member) *data.Object {
 	return member.Traits
 }
 
-func defineShapeFromTypeSpec(model *sadl.Model, ns string, shapes *smithylib.Shapes, ts *sadl.TypeSpec, name string, comment string, annos map[string]string) error {
+func defineShapeFromTypeSpec(model *sadl.Model, ns string, shapes *smithylib.Shapes, ts *sadl.TypeSpec, name string, comment string, annos map[string]interface{}) error {
 	var shape smithylib.Shape
 	switch ts.Type {
 	case "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Decimal":
@@ -530,12 +722,12 @@ func defineShapeFromTypeSpec(model *sadl.Model, ns string, shapes *smithylib.Sha
 		for k, v := range annos {
 			switch k {
 			case "x_tags":
-				ensureShapeTraits(&shape).Put("smithy.api#tags", strings.Split(v, ","))
+				ensureShapeTraits(&shape).Put("smithy.api#tags", sadl.GetAnnotationStrings(annos, k))
 			case "x_sensitive":
 				ensureShapeTraits(&shape).Put("smithy.api#sensitive", true)
 			case "x_deprecated":
 				dep := make(map[string]interface{}, 0)
-				if v != "" {
+				if v := sadl.AsString(v); v != "" {
 					n := strings.Index(v, "|")
 					if n >= 0 {
 						dep["since"] = v[:n]
@@ -547,12 +739,15 @@ func defineShapeFromTypeSpec(model *sadl.Model, ns string, shapes *smithylib.Sha
 				}
 			}
 		}
+		for id, v := range customTraits(model, ns, annos) {
+			ensureShapeTraits(&shape).Put(id, v)
+		}
 	}
 	shapes.Put(ns+"#"+name, &shape)
 	return nil
 }
 
-func shapeFromArray(model *sadl.Model, ns string, shapes *smithylib.Shapes, tname string, ts *sadl.TypeSpec, annos map[string]string) smithylib.Shape {
+func shapeFromArray(model *sadl.Model, ns string, shapes *smithylib.Shapes, tname string, ts *sadl.TypeSpec, annos map[string]interface{}) smithylib.Shape {
 	member := smithylib.Member{
 		Target: EnsureNamespaced(ns, typeReferenceByName(ns, ts.Items)),
 	}
@@ -605,12 +800,22 @@ func shapeFromStruct(model *sadl.Model, ns string, shapes *smithylib.Shapes, tna
 		case "Enum":
 			ftype = enumTypeReference(model, ns, shapes, tname, fd)
 		}
+		if len(fd.Values) > 0 {
+			ftype = valuesTypeReference(model, ns, shapes, tname, fd.Name, &fd.TypeSpec)
+		}
 		member := &smithylib.Member{
 			Target: ftype,
 		}
 		if fd.Required {
 			ensureMemberTraits(member).Put("smithy.api#required", true)
 		}
+		constraintTraits(member, &fd.TypeSpec)
+		if fd.WireName() != fd.Name {
+			ensureMemberTraits(member).Put("smithy.api#jsonName", fd.WireName())
+		}
+		for id, v := range customTraits(model, ns, fd.Annotations) {
+			ensureMemberTraits(member).Put(id, v)
+		}
 		members.Put(fd.Name, member)
 	}
 	shape.Members = members
@@ -626,9 +831,19 @@ func shapeFromUnion(model *sadl.Model, ns string, shapes *smithylib.Shapes, tnam
 		//		fd := model.FindType(vtype.Type)
 		//		ftype := typeReference(&fd.TypeSpec)
 		member := &smithylib.Member{
-			Target: EnsureNamespaced(ns, vd.Type),
+			Target: EnsureNamespaced(ns, typeReference(ns, &vd.TypeSpec)),
+		}
+		if len(vd.Values) > 0 {
+			member.Target = valuesTypeReference(model, ns, shapes, tname, vd.Name, &vd.TypeSpec)
+		}
+		constraintTraits(member, &vd.TypeSpec)
+		if vd.WireName() != vd.Name {
+			ensureMemberTraits(member).Put("smithy.api#jsonName", vd.WireName())
 		}
 		ensureMemberTraits(member).Put("smithy.api#documentation", vd.Comment)
+		for id, v := range customTraits(model, ns, vd.Annotations) {
+			ensureMemberTraits(member).Put(id, v)
+		}
 		members.Put(vd.Name, member)
 	}
 	shape.Members = members
@@ -693,10 +908,8 @@ func shapeFromEnum(ts *sadl.TypeSpec) smithylib.Shape {
 		mem := &smithylib.Member{
 			Target: "smithy.api#Unit",
 		}
-		if el.Annotations != nil {
-			if val, ok := el.Annotations["x_enumValue"]; ok {
-				ensureMemberTraits(mem).Put("smithy.api#enumValue", val)
-			}
+		if val := el.WireName(); val != el.Symbol {
+			ensureMemberTraits(mem).Put("smithy.api#enumValue", val)
 		}
 		mems.Put(el.Symbol, mem)
 	}
@@ -709,7 +922,7 @@ func enumTrait(ts *sadl.TypeSpec) []interface{} {
 	if len(ts.Elements) > 0 {
 		for _, eds := range ts.Elements {
 			ei := make(map[string]interface{}, 0)
-			ei["value"] = eds.Symbol
+			ei["value"] = eds.WireName()
 			ei["name"] = eds.Symbol
 			if eds.Comment != "" {
 				ei["documentation"] = eds.Comment
@@ -727,16 +940,157 @@ func enumTrait(ts *sadl.TypeSpec) []interface{} {
 	return e
 }
 
-func paginatedTrait(sval string) map[string]interface{} {
-	lst := strings.Split(sval, ",")
+// the shape id of the custom trait for a declared annotation, i.e. x_owner -> ns#owner
+func traitId(ns string, annoName string) string {
+	return ns + "#" + strings.TrimPrefix(annoName, "x_")
+}
+
+// define a custom trait shape for an annotation declaration. Bool annotations become marker traits.
+func defineTraitShape(model *sadl.Model, ns string, shapes *smithylib.Shapes, ad *sadl.AnnotationDef) error {
+	id := traitId(ns, ad.Name)
+	ts := &ad.TypeSpec
+	if td := model.FindType(ts.Type); td != nil && td.Name != td.Type {
+		//traits cannot refer to another shape, so the named type is expanded in place
+		ts = &td.TypeSpec
+	}
+	var shape *smithylib.Shape
+	switch model.BaseType(ts.Type) {
+	case "Bool":
+		shape = &smithylib.Shape{Type: "structure", Members: smithylib.NewMembers()}
+	case "Timestamp":
+		shape = &smithylib.Shape{Type: "timestamp"}
+	case "Any":
+		shape = &smithylib.Shape{Type: "document"}
+	default:
+		err := defineShapeFromTypeSpec(model, ns, shapes, ts, strings.TrimPrefix(ad.Name, "x_"), ad.Comment, nil)
+		if err != nil {
+			return err
+		}
+		shape = shapes.Get(id)
+	}
+	if ad.Comment != "" {
+		ensureShapeTraits(shape).Put("smithy.api#documentation", ad.Comment)
+	}
+	trait := make(map[string]interface{}, 0)
+	if sel := traitSelector(ad.Targets); sel != "" {
+		trait["selector"] = sel
+	}
+	ensureShapeTraits(shape).Put("smithy.api#trait", trait)
+	shapes.Put(id, shape)
+	return nil
+}
+
+func traitSelector(targets []string) string {
+	var sels []string
+	for _, target := range targets {
+		var sel string
+		switch target {
+		case "model":
+			sel = "service"
+		case "type":
+			sel = ":not(:is(member, operation, service, resource))"
+		case "field", "element":
+			sel = "member"
+		case "http", "operation":
+			sel = "operation"
+		}
+		if sel != "" && !containsString(sels, sel) {
+			sels = append(sels, sel)
+		}
+	}
+	switch len(sels) {
+	case 0:
+		return ""
+	case 1:
+		return sels[0]
+	default:
+		return ":is(" + strings.Join(sels, ", ") + ")"
+	}
+}
+
+func containsString(lst []string, val string) bool {
+	for _, s := range lst {
+		if s == val {
+			return true
+		}
+	}
+	return false
+}
+
+// the trait values for any annotations that have a declaration in the model
+func customTraits(model *sadl.Model, ns string, annos map[string]interface{}) map[string]interface{} {
+	traits := make(map[string]interface{}, 0)
+	for k, v := range annos {
+		ad := model.FindAnnotationDef(k)
+		if ad == nil {
+			continue
+		}
+		if model.BaseType(ad.Type) == "Bool" {
+			if v == "" || v == true {
+				traits[traitId(ns, k)] = make(map[string]interface{}, 0)
+			}
+		} else {
+			traits[traitId(ns, k)] = v
+		}
+	}
+	return traits
+}
+
+func paginatedTrait(val interface{}) map[string]interface{} {
+	if m := sadl.AsMap(val); m != nil {
+		return m
+	}
+	//older models encode the trait as "inputToken=a,outputToken=b,..."
 	m := make(map[string]interface{}, 0)
-	for _, item := range lst {
+	for _, item := range strings.Split(sadl.AsString(val), ",") {
 		kv := strings.Split(item, "=")
-		m[kv[0]] = kv[1]
+		if len(kv) == 2 {
+			m[kv[0]] = kv[1]
+		}
+	}
+	return m
+}
+
+func paginatedSpecTrait(pg *sadl.HttpPaginatedSpec) map[string]interface{} {
+	m := map[string]interface{}{"inputToken": pg.InputToken, "outputToken": pg.OutputToken}
+	if pg.PageSize != "" {
+		m["pageSize"] = pg.PageSize
+	}
+	if pg.Items != "" {
+		m["items"] = pg.Items
 	}
 	return m
 }
 
+// authTrait returns the id and value of the Smithy trait for an auth scheme. Smithy has none for oauth2, mutualTLS, or
+// an api key in a cookie, so the id is empty for those.
+func authTrait(ad *sadl.AuthDef) (string, interface{}) {
+	switch ad.Scheme {
+	case "bearer":
+		return "smithy.api#httpBearerAuth", map[string]interface{}{}
+	case "basic":
+		return "smithy.api#httpBasicAuth", map[string]interface{}{}
+	case "apiKey":
+		if ad.Header != "" {
+			return "smithy.api#httpApiKeyAuth", map[string]interface{}{"name": ad.Header, "in": "header"}
+		}
+		if ad.Query != "" {
+			return "smithy.api#httpApiKeyAuth", map[string]interface{}{"name": ad.Query, "in": "query"}
+		}
+	}
+	return "", nil
+}
+
+func authTraitIds(schemes []*sadl.AuthDef) []string {
+	ids := []string{}
+	for _, ad := range schemes {
+		if id, _ := authTrait(ad); id != "" && !containsString(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func httpTrait(path, method string, code int) map[string]interface{} {
 	t := make(map[string]interface{}, 0)
 	t["uri"] = path
@@ -775,6 +1129,72 @@ func rangeTrait(min *sadl.Decimal, max *sadl.Decimal) map[string]interface{} {
 	return l
 }
 
+// defineErrorShape returns the reference to the error shape for an exception. An exception with outputs is exported as
+// a wrapper structure with the given id, otherwise its type is marked as the error.
+func defineErrorShape(ns string, ast *smithylib.AST, e *sadl.HttpExceptionSpec, wrapper string) (*smithylib.ShapeRef, error) {
+	//so, these are *wrappers* for error resources in smithy
+	//i.e. I need to generate *another* type with a field marked with @httpPayload attribute
+	//So, this is the "NotFoundErrorContent" type that smithy->openapi produces. If I specify it,
+	//then smithy reference tooling does not generate anything else.
+	//typical pattern to use:
+	// type Error Struct { message String }
+	// type NotFoundError { error Error } //this is the wrapper for the operation
+	//this produces (assuming NotFoundError is referenced in an 'except' response):
+	// structure Error { message: String }
+	// @error("client")
+	// @httpError(404)
+	// structure NotFoundError {
+	//   @httpPayload
+	//   error: Error
+	// }
+	//an exception with headers is exported as just such a wrapper, with a member for each header.
+	status := e.ResponseStatus()
+	if len(e.Outputs) > 0 {
+		eShape := smithylib.Shape{
+			Type:    "structure",
+			Members: smithylib.NewMembers(),
+		}
+		ensureShapeTraits(&eShape).Put("smithy.api#error", httpErrorCategory(status))
+		ensureShapeTraits(&eShape).Put("smithy.api#httpError", status)
+		if e.Comment != "" {
+			ensureShapeTraits(&eShape).Put("smithy.api#documentation", e.Comment)
+		}
+		for _, out := range e.Outputs {
+			if out.Cookie != "" {
+				continue
+			}
+			mem := &smithylib.Member{
+				Target: typeReferenceByName(ns, out.Type),
+			}
+			constraintTraits(mem, &out.TypeSpec)
+			if out.Header != "" {
+				ensureMemberTraits(mem).Put("smithy.api#httpHeader", out.Header)
+			} else {
+				ensureMemberTraits(mem).Put("smithy.api#httpPayload", true)
+			}
+			eShape.Members.Put(out.Name, mem)
+		}
+		em := &smithylib.ShapeRef{Target: wrapper}
+		ast.Shapes.Put(em.Target, &eShape)
+		return em, nil
+	}
+	em := &smithylib.ShapeRef{Target: ns + "#" + e.Type}
+	tmp := ast.Shapes.Get(em.Target)
+	if tmp == nil {
+		return nil, fmt.Errorf("Cannot find shape for error declaration type %q", e.Type)
+	}
+	ensureShapeTraits(tmp).Put("smithy.api#httpError", status)
+	ensureShapeTraits(tmp).Put("smithy.api#error", httpErrorCategory(status))
+	//check that the shape has a single member that is a struct. If so, mark it as payload
+	if tmp.Members != nil && tmp.Members.Length() == 1 {
+		for _, k := range tmp.Members.Keys() {
+			m := tmp.Members.Get(k)
+			ensureMemberTraits(m).Put("smithy.api#httpPayload", true)
+		}
+	}
+	return em, nil
+}
+
 func httpErrorCategory(status int32) string {
 	//Smithy 1.0 only specifies "client" and "server", with apparently no way to handle other status codes
 	if status < 200 {
@@ -1,9 +1,14 @@
 package smithy
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/boynton/data"
@@ -29,17 +34,42 @@ func IsValidFile(path string) bool {
 }
 
 func Import(paths []string, conf *sadl.Data) (*sadl.Model, error) {
-	var err error
+	return ImportFS(nil, paths, conf)
+}
+
+// ImportFS imports the Smithy files from the file system, or the OS file system if fsys is nil.
+func ImportFS(fsys fs.FS, paths []string, conf *sadl.Data) (*sadl.Model, error) {
+	var tags []string //fir filtering, if non-nil
+	model, err := AssembleModelFS(fsys, paths, tags)
+	if err != nil {
+		return nil, err
+	}
+	return importModel(model, conf)
+}
+
+// ImportReader imports the Smithy IDL or JSON AST read from r. The path's extension determines the format.
+func ImportReader(r io.Reader, path string, conf *sadl.Data) (*sadl.Model, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	ast, err := decodeAST(data, path)
+	if err != nil {
+		return nil, err
+	}
+	model, err := assemble([]*smithylib.AST{ast}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return importModel(model, conf)
+}
+
+func importModel(model *smithylib.AST, conf *sadl.Data) (*sadl.Model, error) {
 	name := conf.GetString("name")
 	namespace := conf.GetString("namespace")
 	if namespace == "" {
 		conf.Put("namespace", UnspecifiedNamespace)
 	}
-	var tags []string //fir filtering, if non-nil
-	model, err := AssembleModel(paths, tags)
-	if err != nil {
-		return nil, err
-	}
 	conf.Put("name", name)
 	return ToSadl(model, conf)
 }
@@ -96,6 +126,7 @@ type Importer struct {
 	ast       *smithylib.AST
 	ioParams  map[string]*smithylib.Shape
 	schema    *sadl.Schema
+	services  map[string]string //the service of each operation, if the model has several
 }
 
 func ToSadl(ast *smithylib.AST, conf *sadl.Data) (*sadl.Model, error) {
@@ -120,16 +151,28 @@ func ToSadl(ast *smithylib.AST, conf *sadl.Data) (*sadl.Model, error) {
 	if namespace != UnspecifiedNamespace {
 		i.namespace = namespace
 	}
-	annos := make(map[string]string, 0)
+	annos := make(map[string]interface{}, 0)
+	var constants []*sadl.ConstantDef
 
 	if ast.Metadata != nil {
 		for _, k := range ast.Metadata.Keys() {
-			if k != "name" {
-				s := sadl.AsString(ast.Metadata.Get(k))
+			if k == "constants" {
+				cmap := data.AsObject(ast.Metadata.Get(k))
+				for _, cname := range cmap.Keys() {
+					c := cmap.GetObject(cname)
+					constants = append(constants, &sadl.ConstantDef{
+						Name:    cname,
+						Comment: c.GetString("documentation"),
+						Type:    c.GetString("type"),
+						Value:   c.Get("value"),
+					})
+				}
+			} else if k != "name" {
+				v := ast.Metadata.Get(k)
 				if k == "base" {
-					annos[k] = s
+					annos[k] = sadl.AsString(v)
 				} else {
-					annos["x_"+k] = s
+					annos["x_"+k] = v
 				}
 			}
 		}
@@ -141,6 +184,7 @@ func ToSadl(ast *smithylib.AST, conf *sadl.Data) (*sadl.Model, error) {
 		Sadl:        sadl.Version,
 		Annotations: annos,
 		Base:        base,
+		Constants:   constants,
 	}
 	//capture?     ast.Smithy
 	if schema.Namespace == UnspecifiedNamespace {
@@ -151,22 +195,14 @@ func ToSadl(ast *smithylib.AST, conf *sadl.Data) (*sadl.Model, error) {
 	}
 	i.schema = schema
 
-	serviceName := ""
-	serviceVersion := ""
+	var serviceNames []string
 	for _, shapeName := range ast.Shapes.Keys() {
 		shapeDef := ast.Shapes.Get(shapeName)
 		if shapeDef.Type == "service" {
-			if service != "" {
-				if shapeName != service {
-					continue
-				}
-			} else {
-				if serviceName != "" {
-					return nil, fmt.Errorf("SADL only supports one service per model (%s, %s)", serviceName, shapeName)
-				}
+			if service != "" && shapeName != service {
+				continue
 			}
-			serviceName = shapeName
-			serviceVersion = shapeDef.Version
+			serviceNames = append(serviceNames, shapeName)
 		} else if shapeDef.Type == "operation" {
 			if shapeDef.Input != nil {
 				i.ioParams[shapeDef.Input.Target] = i.ast.GetShape(shapeDef.Input.Target)
@@ -176,15 +212,71 @@ func ToSadl(ast *smithylib.AST, conf *sadl.Data) (*sadl.Model, error) {
 			}
 		}
 	}
-	if schema.Name == "" {
-		schema.Name = stripNamespace(serviceName)
+	serviceName := ""
+	if len(serviceNames) > 0 {
+		serviceName = serviceNames[0]
 	}
-	if schema.Version == "" {
-		schema.Version = serviceVersion
+	if len(serviceNames) > 1 || (serviceName != "" && i.name != "" && i.name != stripNamespace(serviceName)) {
+		//each service of the model is declared, and the model is named in the metadata
+		if schema.Name == "" {
+			schema.Name = i.name
+		}
+		i.services = make(map[string]string, 0)
+		for _, sname := range serviceNames {
+			shape := ast.Shapes.Get(sname)
+			sd := &sadl.ServiceDef{
+				Name:    stripNamespace(sname),
+				Comment: escapeComment(shape.Traits.GetString("smithy.api#documentation")),
+			}
+			if shape.Version != UnspecifiedVersion {
+				sd.Version = shape.Version
+			}
+			//the actions of the only service need not name it
+			for _, op := range i.boundOperations(shape) {
+				if _, ok := i.services[op.Target]; !ok && len(serviceNames) > 1 {
+					i.services[op.Target] = sd.Name
+				}
+			}
+			schema.Services = append(schema.Services, sd)
+		}
+	} else if serviceName != "" {
+		if schema.Name == "" {
+			schema.Name = stripNamespace(serviceName)
+		}
+		if schema.Version == "" {
+			schema.Version = ast.Shapes.Get(serviceName).Version
+		}
+	}
+	if serviceName != "" {
+		service := ast.Shapes.Get(serviceName)
+		//the errors of the service are common to all of its operations
+		for _, etype := range service.Errors {
+			schema.Exceptions = append(schema.Exceptions, i.importError(etype))
+		}
+		for _, id := range service.Traits.Keys() {
+			if name, ok := authNames[id]; ok {
+				ad := &sadl.AuthDef{Name: name}
+				switch id {
+				case "smithy.api#httpBearerAuth":
+					ad.Scheme = "bearer"
+				case "smithy.api#httpBasicAuth":
+					ad.Scheme = "basic"
+				default:
+					t := service.Traits.GetMap(id)
+					ad.Scheme = "apiKey"
+					if sadl.GetString(t, "in") == "query" {
+						ad.Query = sadl.GetString(t, "name")
+					} else {
+						ad.Header = sadl.GetString(t, "name")
+					}
+				}
+				schema.Auth = append(schema.Auth, ad)
+			}
+		}
 	}
 	for _, k := range ast.Shapes.Keys() {
 		v := ast.Shapes.Get(k)
-		if v.Type != "service" || k == serviceName {
+		if v.Type != "service" {
 			i.importShape(k, v)
 		}
 	}
@@ -195,6 +287,11 @@ func (i *Importer) importShape(shapeName string, shapeDef *smithylib.Shape) {
 	if _, ok := i.ioParams[shapeName]; ok {
 		return
 	}
+	if shapeDef.Traits != nil && shapeDef.Traits.Get("smithy.api#trait") != nil {
+		if i.importTraitShape(shapeName, shapeDef) {
+			return
+		}
+	}
 	switch shapeDef.Type {
 	case "byte", "short", "integer", "long", "float", "double", "bigInteger", "bigDecimal":
 		i.importNumericShape(shapeDef.Type, shapeName, shapeDef)
@@ -430,26 +527,28 @@ func (i *Importer) importBooleanShape(shapeName string, shape *smithylib.Shape)
 	i.schema.Types = append(i.schema.Types, td)
 }
 
-func (i *Importer) importTraitsAsAnnotations(annos map[string]string, traits *data.Object) map[string]string {
+func (i *Importer) importTraitsAsAnnotations(annos map[string]interface{}, traits *data.Object) map[string]interface{} {
 	for _, k := range traits.Keys() {
 		v := traits.Get(k)
 		switch k {
 		case "smithy.api#error":
 			annos = WithAnnotation(annos, "x_"+stripNamespace(k), sadl.AsString(v))
 		case "smithy.api#httpError":
-			annos = WithAnnotation(annos, "x_"+stripNamespace(k), fmt.Sprintf("%v", v))
+			annos = WithAnnotation(annos, "x_"+stripNamespace(k), v)
 		case "smithy.api#httpPayload", "smithy.api#httpLabel", "smithy.api#httpQuery", "smithy.api#httpHeader":
 			/* ignore, implicit in SADL */
 		case "smithy.api#required", "smithy.api#documentation", "smithy.api#range", "smithy.api#length":
 			/* ignore, implicit in SADL */
 		case "smithy.api#tags":
-			annos = WithAnnotation(annos, "x_"+stripNamespace(k), strings.Join(sadl.AsStringArray(v), ","))
+			annos = WithAnnotation(annos, "x_"+stripNamespace(k), v)
 		case "smithy.api#readonly", "smithy.api#idempotent", "smithy.api#sensitive", "smithy.api#box":
 			//			annos = WithAnnotation(annos, "x_"+stripNamespace(k), "true")
-		case "smithy.api#http":
+		case "smithy.api#http", "smithy.api#auth":
 			/* ignore, handled elsewhere */
 		case "smithy.api#timestampFormat", "smithy.api#enumValue":
 			annos = WithAnnotation(annos, "x_"+stripNamespace(k), sadl.AsString(v))
+		case "smithy.api#jsonName":
+			annos = WithAnnotation(annos, "x_wire_name", sadl.AsString(v))
 		case "smithy.api#deprecated":
 			//message
 			//since
@@ -464,13 +563,7 @@ func (i *Importer) importTraitsAsAnnotations(annos map[string]string, traits *da
 				annos = WithAnnotation(annos, "x_deprecated_since", since)
 			}
 		case "smithy.api#paginated":
-			dv := sadl.AsMap(v)
-			inputToken := sadl.AsString(dv["inputToken"])
-			outputToken := sadl.AsString(dv["outputToken"])
-			pageSize := sadl.AsString(dv["pageSize"])
-			items := sadl.AsString(dv["items"])
-			s := fmt.Sprintf("inputToken=%s,outputToken=%s,pageSize=%s,items=%s", inputToken, outputToken, pageSize, items)
-			annos = WithAnnotation(annos, "x_paginated", s)
+			annos = WithAnnotation(annos, "x_paginated", v)
 		case "aws.protocols#restJson1":
 			//ignore
 		case "smithy.api#examples":
@@ -480,10 +573,11 @@ func (i *Importer) importTraitsAsAnnotations(annos map[string]string, traits *da
 			if tshape != nil {
 				tm := tshape.Traits.GetMap("smithy.api#trait")
 				if tm != nil {
-					fmt.Println("tshape:", k, tm)
-					//FIX ME
-					//I don't really have a place to put non-string annotations (note how I stuff string annos into "x_name").
-					//So I really need arbitrary annotations values to do this. Note also: need multiple ns support!
+					//custom trait. Note: traits from different namespaces with the same name will collide
+					if m := sadl.AsMap(v); m != nil && len(m) == 0 {
+						v = true
+					}
+					annos = WithAnnotation(annos, "x_"+stripNamespace(k), v)
 				} else {
 					fmt.Println("Unhandled trait:", k, " =", sadl.Pretty(v))
 					panic("here: " + k)
@@ -702,8 +796,109 @@ func (i *Importer) shapeRefToTypeRef(shapeRef string) string {
 	return typeRef
 }
 
+// import a custom trait definition as an annotation declaration. Only simple trait shapes are supported, others
+// are imported as ordinary types.
+func (i *Importer) importTraitShape(shapeName string, shape *smithylib.Shape) bool {
+	ad := &sadl.AnnotationDef{
+		Name:    "x_" + stripNamespace(shapeName),
+		Comment: escapeComment(shape.Traits.GetString("smithy.api#documentation")),
+	}
+	switch shape.Type {
+	case "structure":
+		if shape.Members != nil && shape.Members.Length() > 0 {
+			return false
+		}
+		ad.Type = "Bool" //a marker trait
+	case "boolean":
+		ad.Type = "Bool"
+	case "string":
+		ad.Type = "String"
+		ad.Pattern = shape.Traits.GetString("smithy.api#pattern")
+	case "byte", "short", "integer", "long", "float", "double", "bigDecimal", "timestamp":
+		ad.Type = i.shapeRefToTypeRef(capitalize(shape.Type))
+	case "document":
+		ad.Type = "Any"
+	case "list":
+		if shape.Member == nil || i.shapeRefToTypeRef(shape.Member.Target) != "String" {
+			return false
+		}
+		ad.Type = "Array"
+		ad.Items = "String"
+	default:
+		return false
+	}
+	sel := sadl.AsString(sadl.AsMap(shape.Traits.Get("smithy.api#trait"))["selector"])
+	if strings.Contains(sel, ":not(") {
+		ad.Targets = append(ad.Targets, "type")
+		sel = sel[strings.Index(sel, ")")+1:]
+	}
+	if strings.Contains(sel, "service") {
+		ad.Targets = append(ad.Targets, "model")
+	}
+	if strings.Contains(sel, "member") {
+		ad.Targets = append(ad.Targets, "field")
+	}
+	if strings.Contains(sel, "operation") {
+		ad.Targets = append(ad.Targets, "http")
+	}
+	i.schema.AnnotationDefs = append(i.schema.AnnotationDefs, ad)
+	return true
+}
+
+// boundOperations returns the operations of a service or resource, including those of its resources.
+func (i *Importer) boundOperations(shape *smithylib.Shape) []*smithylib.ShapeRef {
+	ops := append([]*smithylib.ShapeRef{}, shape.Operations...)
+	for _, ref := range []*smithylib.ShapeRef{shape.Create, shape.Put, shape.Read, shape.Update, shape.Delete, shape.List} {
+		if ref != nil {
+			ops = append(ops, ref)
+		}
+	}
+	ops = append(ops, shape.CollectionOperations...)
+	for _, ref := range shape.Resources {
+		if rshape := i.ast.GetShape(ref.Target); rshape != nil {
+			ops = append(ops, i.boundOperations(rshape)...)
+		}
+	}
+	return ops
+}
+
+// importResourceShape imports the identifiers and lifecycle of a resource. Its other operations are just http actions.
 func (i *Importer) importResourceShape(shapeName string, shape *smithylib.Shape) {
-	//to do: preserve the resource info as tags on the operations
+	name := stripNamespace(shapeName)
+	if base := strings.TrimSuffix(shapeName, "Resource"); base != shapeName {
+		//a resource named for its type is exported with a suffix
+		if other := i.ast.GetShape(base); other != nil && other.Type != "resource" {
+			name = stripNamespace(base)
+		}
+	}
+	rd := &sadl.ResourceDef{
+		Name:        name,
+		Comment:     escapeComment(shape.Traits.GetString("smithy.api#documentation")),
+		Annotations: i.importTraitsAsAnnotations(nil, shape.Traits),
+	}
+	for id := range shape.Identifiers {
+		rd.Identifiers = append(rd.Identifiers, id)
+	}
+	sort.Strings(rd.Identifiers)
+	action := func(ref *smithylib.ShapeRef) string {
+		//only operations with http bindings are http actions
+		if ref != nil {
+			if op := i.ast.GetShape(ref.Target); op != nil && op.Traits.Has("smithy.api#http") {
+				return sadl.Uncapitalize(stripNamespace(ref.Target))
+			}
+		}
+		return ""
+	}
+	rd.Create = action(shape.Create)
+	if rd.Create == "" {
+		//a put creates an instance with the identifiers the client gives it
+		rd.Create = action(shape.Put)
+	}
+	rd.Read = action(shape.Read)
+	rd.Update = action(shape.Update)
+	rd.Delete = action(shape.Delete)
+	rd.List = action(shape.List)
+	i.schema.Resources = append(i.schema.Resources, rd)
 }
 
 func (i *Importer) importOperationShape(shapeName string, shape *smithylib.Shape) {
@@ -799,9 +994,27 @@ func (i *Importer) importOperationShape(shapeName string, shape *smithylib.Shape
 		Method:      method,
 		Path:        uri,
 		Name:        sadl.Uncapitalize(sadlName),
+		Service:     i.services[shapeName],
 		Comment:     escapeComment(shape.Traits.GetString("smithy.api#documentation")),
 		Annotations: i.importTraitsAsAnnotations(nil, shape.Traits),
 	}
+	if pg := importPaginated(shape.Traits.GetMap("smithy.api#paginated")); pg != nil {
+		//the trait is kept as an annotation only if it relies on that of the service for its tokens
+		hdef.Paginated = pg
+		delete(hdef.Annotations, "x_paginated")
+		if len(hdef.Annotations) == 0 {
+			hdef.Annotations = nil
+		}
+	}
+	if shape.Traits.Has("smithy.api#auth") {
+		ids := shape.Traits.GetStringArray("smithy.api#auth")
+		for _, id := range ids {
+			if name, ok := authNames[id]; ok {
+				hdef.Auth = append(hdef.Auth, name)
+			}
+		}
+		hdef.Anonymous = len(ids) == 0
+	}
 	if code == 0 {
 		code = 200
 	}
@@ -841,6 +1054,7 @@ func (i *Importer) importOperationShape(shapeName string, shape *smithylib.Shape
 					}
 					in.Header = fval.Traits.GetString("smithy.api#httpHeader")
 					in.Path = fval.Traits.GetBool("smithy.api#httpLabel")
+					in.Greedy = in.Path && strings.Contains(uri, "{"+fname+"+}")
 					hdef.Inputs = append(hdef.Inputs, in)
 				}
 			} else {
@@ -896,30 +1110,54 @@ func (i *Importer) importOperationShape(shapeName string, shape *smithylib.Shape
 	}
 	if shape.Errors != nil {
 		for _, etype := range shape.Errors {
-			eShapeName := etype.Target
-			eStruct := i.ast.GetShape(eShapeName)
-			eType := i.shapeRefToTypeRef(eShapeName)
-			if eStruct == nil {
-				panic("error type not found: " + eShapeName)
-			}
-			exc := &sadl.HttpExceptionSpec{}
-			exc.Type = eType
-			exc.Status = int32(eStruct.Traits.GetInt("smithy.api#httpError"))
-			exc.Comment = escapeComment(eStruct.Traits.GetString("smithy.api#documentation"))
-			//preserve other traits as annotations?
-			hdef.Exceptions = append(hdef.Exceptions, exc)
+			hdef.Exceptions = append(hdef.Exceptions, i.importError(etype))
 		}
 	}
 	//Comment string
-	//Annotations map[string]string
+	//Annotations map[string]interface{}
 	hdef.Expected = expected
 	i.schema.Http = append(i.schema.Http, hdef)
 }
 
-func WithAnnotation(annos map[string]string, key string, value string) map[string]string {
-	if value != "" {
+// authNames are the names of the auth schemes for the Smithy auth traits
+var authNames = map[string]string{
+	"smithy.api#httpBearerAuth": "Bearer",
+	"smithy.api#httpBasicAuth":  "Basic",
+	"smithy.api#httpApiKeyAuth": "ApiKey",
+}
+
+func (i *Importer) importError(etype *smithylib.ShapeRef) *sadl.HttpExceptionSpec {
+	eShapeName := etype.Target
+	eStruct := i.ast.GetShape(eShapeName)
+	eType := i.shapeRefToTypeRef(eShapeName)
+	if eStruct == nil {
+		panic("error type not found: " + eShapeName)
+	}
+	exc := &sadl.HttpExceptionSpec{}
+	exc.Type = eType
+	exc.Status = int32(eStruct.Traits.GetInt("smithy.api#httpError"))
+	exc.Comment = escapeComment(eStruct.Traits.GetString("smithy.api#documentation"))
+	//preserve other traits as annotations?
+	return exc
+}
+
+func importPaginated(trait map[string]interface{}) *sadl.HttpPaginatedSpec {
+	pg := &sadl.HttpPaginatedSpec{
+		InputToken:  sadl.GetString(trait, "inputToken"),
+		OutputToken: sadl.GetString(trait, "outputToken"),
+		PageSize:    sadl.GetString(trait, "pageSize"),
+		Items:       sadl.GetString(trait, "items"),
+	}
+	if pg.InputToken == "" || pg.OutputToken == "" {
+		return nil
+	}
+	return pg
+}
+
+func WithAnnotation(annos map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if value != nil && value != "" {
 		if annos == nil {
-			annos = make(map[string]string, 0)
+			annos = make(map[string]interface{}, 0)
 		}
 		annos[key] = value
 	}
@@ -935,41 +1173,105 @@ func stripNamespace(trait string) string {
 }
 
 func AssembleModel(paths []string, tags []string) (*smithylib.AST, error) {
-	flatPathList, err := expandPaths(paths)
+	return AssembleModelFS(nil, paths, tags)
+}
+
+// AssembleModelFS merges the Smithy files at the paths within the file system, or the OS file system if fsys is nil.
+// Directories are searched for Smithy files.
+func AssembleModelFS(fsys fs.FS, paths []string, tags []string) (*smithylib.AST, error) {
+	flatPathList, err := expandPaths(fsys, paths)
 	if err != nil {
 		return nil, err
 	}
+	var asts []*smithylib.AST
+	for _, path := range flatPathList {
+		ast, err := loadAST(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		asts = append(asts, ast)
+	}
+	return assemble(asts, tags)
+}
+
+func assemble(asts []*smithylib.AST, tags []string) (*smithylib.AST, error) {
 	assembly := &smithylib.AST{
 		Smithy: "2",
 	}
-	for _, path := range flatPathList {
-		var ast *smithylib.AST
-		var err error
+	for _, ast := range asts {
+		err := assembly.Merge(ast)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(tags) > 0 {
+		assembly.Filter(tags)
+	}
+	err := assembly.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return assembly, nil
+}
+
+func loadAST(fsys fs.FS, path string) (*smithylib.AST, error) {
+	if fsys == nil {
 		ext := filepath.Ext(path)
 		switch ext {
 		case ".json":
-			ast, err = smithylib.LoadAST(path)
+			return smithylib.LoadAST(path)
 		case ".smithy":
-			ast, err = smithylib.Parse(path)
+			return smithylib.Parse(path)
 		default:
 			return nil, fmt.Errorf("parse for file type %q not implemented", ext)
 		}
+	}
+	data, err := sadl.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAST(data, path)
+}
+
+// the path's extension determines whether the data is Smithy IDL or a JSON AST
+func decodeAST(data []byte, path string) (*smithylib.AST, error) {
+	ext := filepath.Ext(path)
+	switch ext {
+	case ".json":
+		var ast smithylib.AST
+		err := json.Unmarshal(data, &ast)
 		if err != nil {
 			return nil, err
 		}
-		err = assembly.Merge(ast)
-		if err != nil {
-			return nil, err
-		}
+		return &ast, nil
+	case ".smithy":
+		return parseIDL(data, path)
+	default:
+		return nil, fmt.Errorf("parse for file type %q not implemented", ext)
 	}
-	if len(tags) > 0 {
-		assembly.Filter(tags)
+}
+
+// the smithy library only parses IDL from a path, so the data is staged in a temporary file, with the extension of the
+// path it came from.
+func parseIDL(data []byte, path string) (*smithylib.AST, error) {
+	f, err := os.CreateTemp("", "sadl-*"+filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
 	}
-	err = assembly.Validate()
 	if err != nil {
 		return nil, err
 	}
-	return assembly, nil
+	ast, err := smithylib.Parse(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s", strings.ReplaceAll(err.Error(), tmpPath, path))
+	}
+	return ast, nil
 }
 
 var ImportFileExtensions = map[string][]string{
@@ -977,7 +1279,10 @@ var ImportFileExtensions = map[string][]string{
 	".json":   []string{"smithy"},
 }
 
-func expandPaths(paths []string) ([]string, error) {
+func expandPaths(fsys fs.FS, paths []string) ([]string, error) {
+	if fsys != nil {
+		return expandPathsFS(fsys, paths)
+	}
 	var result []string
 	for _, path := range paths {
 		ext := filepath.Ext(path)
@@ -1004,3 +1309,26 @@ func expandPaths(paths []string) ([]string, error) {
 	}
 	return result, nil
 }
+
+func expandPathsFS(fsys fs.FS, paths []string) ([]string, error) {
+	var result []string
+	for _, path := range paths {
+		if _, ok := ImportFileExtensions[filepath.Ext(path)]; ok {
+			result = append(result, path)
+			continue
+		}
+		err := fs.WalkDir(fsys, path, func(wpath string, d fs.DirEntry, errIncoming error) error {
+			if errIncoming != nil {
+				return errIncoming
+			}
+			if _, ok := ImportFileExtensions[filepath.Ext(wpath)]; ok && !d.IsDir() {
+				result = append(result, wpath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
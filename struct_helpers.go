@@ -38,6 +38,9 @@ func AsArray(v interface{}) []interface{} {
 }
 
 func AsStringArray(v interface{}) []string {
+	if a, ok := v.([]string); ok {
+		return a
+	}
 	var sa []string
 	a := AsArray(v)
 	if a != nil {
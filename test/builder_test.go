@@ -0,0 +1,56 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boynton/sadl"
+)
+
+func TestBuilder(test *testing.T) {
+	model, err := sadl.NewBuilder("crudl").
+		Namespace("example").
+		Type("ItemId", "String", sadl.Pattern("^[a-z]+$")).
+		Type("Tags", "Array<String>", sadl.MaxSize(10)).
+		Struct("Item", sadl.Comment("An item")).
+		Field("id", "ItemId", sadl.Required()).
+		Field("count", "Int32", sadl.Min(0), sadl.Default(1)).
+		Field("tags", "Tags").
+		Http("GET", "/items/{id}?detail={detail}").
+		Input("id", "ItemId").
+		Input("detail", "Bool").
+		Expect(200).
+		Output("body", "Item").
+		Output("etag", "String", sadl.Header("ETag")).
+		Except(404, "Item").
+		Example("Item", map[string]interface{}{"id": "abc", "count": 2, "tags": []string{"a"}}).
+		Build()
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	hd := model.FindHttp("getItems")
+	if hd == nil || !hd.Inputs[0].Path || hd.Inputs[1].Query != "detail" || hd.Expected.Outputs[1].Header != "ETag" {
+		test.Fatalf("Bad http action: %s", sadl.Pretty(model.Http))
+	}
+	src := sadl.DecompileSadl(model)
+	parsed, err := parseString(src)
+	if err != nil {
+		test.Fatalf("The built model does not parse: %v\n%s", err, src)
+	}
+	if parsed.FindType("Item").Comment != "An item" || len(parsed.FindType("Item").Fields) != 3 {
+		test.Errorf("Parsed model differs from the built one:\n%s", src)
+	}
+
+	_, err = sadl.NewBuilder("bad").
+		Type("Id", "String", sadl.Min(1)).
+		Struct("Id").Field("x", "Int32").Field("x", "String").
+		Build()
+	diags := sadl.AsDiagnostics(err)
+	if len(diags) != 3 || !strings.Contains(diags[0].Message, "min") || !strings.Contains(diags[1].Message, "Duplicate type") {
+		test.Errorf("Expected the problems to be reported as the model is built, got: %v", err)
+	}
+	_, err = sadl.NewBuilder("undefined").Struct("Foo").Field("bar", "Bar").Build()
+	if err == nil || !strings.Contains(err.Error(), "Bar") {
+		test.Errorf("Expected the built model to be validated, got: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/boynton/sadl"
+)
+
+func TestFormat(test *testing.T) {
+	src := `
+
+name test
+type  Point Struct{
+  x Int32(x_unit="px",min=0, required ) // horizontal
+    longername Int32 // vertical
+  /* a block comment
+     that is left alone */
+}
+
+
+const  Origin Int32=0 //the origin
+type Pair Map<String, Int32>
+example Point {"x":1, "y": [1,2]}
+`
+	expected := `name test
+type Point Struct {
+	x Int32 (required, min=0, x_unit="px") // horizontal
+	longername Int32                       // vertical
+	/* a block comment
+     that is left alone */
+}
+
+const Origin Int32 = 0 //the origin
+type Pair Map<String,Int32>
+example Point { "x": 1, "y": [1, 2] }
+`
+	formatted, err := sadl.FormatSadl(src)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if formatted != expected {
+		test.Errorf("Bad format, expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+	//option names are ranked without regard to case, as the parser accepts them
+	formatted, err = sadl.FormatSadl(`type Id String (maxSize=5, Pattern="^[a-z]+$")` + "\n")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if expected := `type Id String (Pattern="^[a-z]+$", maxSize=5)` + "\n"; formatted != expected {
+		test.Errorf("Bad format, expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+	_, err = sadl.FormatSadl("type Foo Struct {\n")
+	if err == nil {
+		test.Errorf("Expected an error formatting unbalanced source")
+	}
+}
+
+func TestFormatExamples(test *testing.T) {
+	paths, _ := filepath.Glob("../examples/*.sadl")
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			test.Fatalf("%v", err)
+		}
+		formatted, err := sadl.FormatSadl(string(src))
+		if err != nil {
+			test.Errorf("%s: %v", path, err)
+			continue
+		}
+		again, _ := sadl.FormatSadl(formatted)
+		if again != formatted {
+			test.Errorf("%s: formatting is not idempotent", path)
+		}
+		before, err1 := sadl.ParseSadlString(string(src), sadl.NewData())
+		after, err2 := sadl.ParseSadlString(formatted, sadl.NewData())
+		if err1 != nil || err2 != nil {
+			if (err1 == nil) != (err2 == nil) {
+				test.Errorf("%s: formatting changed whether it parses: %v, %v", path, err1, err2)
+			}
+			continue
+		}
+		before.ClearSpans()
+		after.ClearSpans()
+		if sadl.Pretty(before) != sadl.Pretty(after) {
+			test.Errorf("%s: formatting changed the model", path)
+		}
+	}
+}
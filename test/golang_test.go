@@ -0,0 +1,358 @@
+package test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boynton/sadl"
+	"github.com/boynton/sadl/golang"
+)
+
+const goMod = `module example
+
+go 1.24
+
+require (
+	github.com/gorilla/handlers v1.5.2
+	github.com/gorilla/mux v1.8.1
+)
+`
+
+// compileGo generates the Go model, server, and client for the source, and builds them. It is skipped if the go
+// command, or the modules the server uses, are not at hand.
+func compileGo(test *testing.T, src string) {
+	test.Helper()
+	runGo(test, src, "", "build")
+}
+
+// testGo generates the Go code for the source as compileGo does, adds the test source to its package, and runs it.
+func testGo(test *testing.T, src string, testSrc string) {
+	test.Helper()
+	runGo(test, src, testSrc, "test")
+}
+
+func runGo(test *testing.T, src string, testSrc string, command string) {
+	test.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		test.Skip("no go command")
+	}
+	model, err := parseString(src)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	dir := test.TempDir()
+	conf := sadl.NewData()
+	conf.Put("package", "example")
+	conf.Put("server", true)
+	conf.Put("client", true)
+	err = golang.Export(model, dir, conf)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if testSrc != "" {
+		files, _ := filepath.Glob(filepath.Join(dir, "*", "*_client.go"))
+		if len(files) != 1 {
+			test.Fatalf("Cannot find the generated client in %s", dir)
+		}
+		err = os.WriteFile(filepath.Join(filepath.Dir(files[0]), "generated_test.go"), []byte(testSrc), 0644)
+		if err != nil {
+			test.Fatalf("%v", err)
+		}
+	}
+	cmd := exec.Command("go", command, "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOSUMDB=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.HasPrefix(string(out), "go: ") {
+			test.Skipf("cannot resolve the modules of the generated code: %s", out)
+		}
+		test.Errorf("Generated Go code failed to %s: %v\n%s", command, err, out)
+	}
+}
+
+func TestGoParamTypes(test *testing.T) {
+	compileGo(test, `name Params
+type Amount Decimal
+type Color Enum {
+   RED
+   DARK_GREEN (json="dark-green")
+}
+type Result Struct {
+   count Int32
+}
+http GET "/things?when={when}&whens={whens}&amount={amount}&amounts={amounts}&color={color}&colors={colors}" (action=getThings) {
+   when Timestamp
+   whens Array<Timestamp> (csv)
+   amount Decimal
+   amounts Array<Decimal>
+   color Color (default="RED")
+   colors Array<Color> (csv)
+   amount2 Amount (header="X-Amount")
+   amounts2 Array<Amount> (header="X-Amounts")
+   color2 Color (header="X-Color")
+   tags Array<String> (header="X-Tags")
+   expect 200 {
+      result Result
+   }
+}
+`)
+}
+
+func TestGoServerWithoutTimestamps(test *testing.T) {
+	compileGo(test, `name Blobs
+type Blob Struct {
+   name String
+}
+http GET "/blobs/{name}" (action=getBlob) {
+   name String
+   expect 200 {
+      blob Blob
+   }
+}
+`)
+}
+
+func TestGoFormTypes(test *testing.T) {
+	compileGo(test, `name Forms
+type Amount Decimal
+type Color Enum {
+   RED
+   DARK_GREEN (json="dark-green")
+}
+type Order Struct {
+   when Timestamp
+   amount Decimal
+   amount2 Amount
+   amounts Array<Amount>
+   color Color
+   colors Array<Color>
+   count Int32
+}
+type Upload Struct {
+   name String
+   amount Decimal
+   color Color
+   file Bytes
+}
+http POST "/orders" (action=postOrder) {
+   order Order (mediaType="application/x-www-form-urlencoded")
+   expect 204
+}
+http POST "/uploads" (action=postUpload) {
+   upload Upload (mediaType="multipart/form-data")
+   expect 204
+}
+`)
+}
+
+func TestGoServerBodyLimit(test *testing.T) {
+	testGo(test, `name Blobs
+http PUT "/blobs/{id}" (action=putBlob) {
+   id String
+   data Bytes (mediaType="application/octet-stream")
+   expect 204
+}
+`, `package example
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type blobs struct {
+	data []byte
+}
+
+func (b *blobs) PutBlob(req *PutBlobRequest) (*PutBlobResponse, error) {
+	b.data = req.Data
+	return &PutBlobResponse{}, nil
+}
+
+func TestBodyLimit(test *testing.T) {
+	impl := &blobs{}
+	handler := InitServer(impl, "")
+	MaxBodySize = 4
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/blobs/a", bytes.NewReader([]byte("abcd"))))
+	if w.Code != 204 || string(impl.data) != "abcd" {
+		test.Errorf("Expected the body to be read, got %d: %q", w.Code, impl.data)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/blobs/a", bytes.NewReader([]byte("abcde"))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		test.Errorf("Expected a body over the limit to fail with a 413, got %d", w.Code)
+	}
+}
+`)
+}
+
+func TestGoDefaultException(test *testing.T) {
+	compileGo(test, `name Errors
+type ServiceError Struct {
+   code Int32
+   message String
+}
+type NotFound Struct {
+   message String
+}
+type Item Struct {
+   id String
+}
+except ServiceError
+http GET "/items/{id}" (action=getItem) {
+   id String
+   expect 200 {
+      item Item
+   }
+   except 404 NotFound
+}
+`)
+}
+
+func TestGoResource(test *testing.T) {
+	compileGo(test, `name Store
+type Item Struct {
+   id String
+   descr String
+}
+type ItemListing Struct {
+   items Array<Item>
+   next String
+}
+type NotFound Struct {
+   message String
+}
+resource Item (identifiers=["id"], create=createItem, read=getItem, update=putItem, delete=deleteItem, list=listItems)
+http POST "/items" (action=createItem) {
+   item Item
+   expect 201 {
+      item Item
+   }
+}
+http GET "/items/{id}" (action=getItem) {
+   id String
+   expect 200 {
+      item Item
+   }
+   except 404 NotFound
+}
+http PUT "/items/{id}" (action=putItem) {
+   id String
+   item Item
+   expect 200 {
+      item Item
+   }
+   except 404 NotFound
+}
+http DELETE "/items/{id}" (action=deleteItem) {
+   id String
+   expect 204
+   except 404 NotFound
+}
+http GET "/items?limit={limit}" (action=listItems) {
+   limit Int32
+   expect 200 {
+      items ItemListing
+   }
+}
+`)
+}
+
+func TestGoPagination(test *testing.T) {
+	compileGo(test, `name Pages
+type Item Struct {
+   id String
+}
+type Cursor Struct {
+   next String (nullable)
+}
+type ItemListing Struct {
+   items Array<Item> (required)
+   next String
+   cursor Cursor (nullable)
+   last String (nullable, required)
+}
+http GET "/items?limit={limit}&after={after}" (action=listItems, paginated={"inputToken": "after", "outputToken": "items.next", "pageSize": "limit", "items": "items.items"}) {
+   limit Int32 (default=10)
+   after String
+   expect 200 {
+      items ItemListing
+   }
+}
+http GET "/cursors?after={after}" (action=listCursors, paginated={"inputToken": "after", "outputToken": "items.cursor.next"}) {
+   after String
+   expect 200 {
+      items ItemListing
+   }
+}
+http GET "/lasts?after={after}" (action=listLasts, paginated={"inputToken": "after", "outputToken": "items.last"}) {
+   after String
+   expect 200 {
+      items ItemListing
+   }
+}
+`)
+}
+
+func TestGoClientPaths(test *testing.T) {
+	testGo(test, `name Paths
+type Item Struct {
+   id String
+}
+resource Item (identifiers=["id"], read=getItem)
+http GET "/items/{id}" (action=getItem) {
+   id String
+   expect 200 {
+      item Item
+   }
+}
+http GET "/files/{path+}" (action=getFile) {
+   path String
+   expect 204
+}
+`, `package example
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaths(test *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		if r.URL.Path == "/files/a b/c" {
+			w.WriteHeader(204)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	if _, err := client.GetItem(&GetItemRequest{Id: "a b/c"}); err != nil {
+		test.Fatalf("%v", err)
+	}
+	if _, err := client.ItemResource().Read(&GetItemRequest{Id: "a b/c"}); err != nil {
+		test.Fatalf("%v", err)
+	}
+	if _, err := client.GetFile(&GetFileRequest{Path: "a b/c"}); err != nil {
+		test.Fatalf("%v", err)
+	}
+	if len(paths) != 3 || paths[0] != "/items/a%20b%2Fc" || paths[1] != paths[0] || paths[2] != "/files/a%20b/c" {
+		test.Errorf("Path variables not substituted: %v", paths)
+	}
+}
+`)
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boynton/sadl"
+	"github.com/boynton/sadl/graphql"
+)
+
+func TestGraphqlNullable(test *testing.T) {
+	model, err := parseString(`name test
+type Item Struct {
+  id String (required)
+  note String (nullable)
+  owner String (nullable, required)
+}
+`)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	src, err := graphql.FromSadl(model, sadl.NewData())
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	model, err = graphql.ImportReader(strings.NewReader(src), sadl.NewData())
+	if err != nil {
+		test.Fatalf("%v\n%s", err, src)
+	}
+	fields := model.FindType("Item").Fields
+	if !fields[0].Required || fields[0].Nullable || fields[1].Required || !fields[1].Nullable || !fields[2].Required || !fields[2].Nullable {
+		test.Errorf("Nullable fields did not round trip:\n%s", src)
+	}
+}
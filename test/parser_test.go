@@ -2,7 +2,9 @@ package test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/boynton/sadl"
 )
@@ -337,6 +339,505 @@ func TestPathTemplateSyntax(test *testing.T) {
 	}
 }
 
+func TestGreedyPathLabel(test *testing.T) {
+	v, err := parseString(`http GET "/files/{bucket}/{key+}" (action=getFile) {
+  bucket String
+  key String
+}`)
+	if err != nil {
+		test.Fatalf("Greedy path label caused an error: %v", err)
+	}
+	in := v.Http[0].Inputs[1]
+	if !in.Path || !in.Greedy || v.Http[0].Inputs[0].Greedy {
+		test.Errorf("Only the key input should be a greedy path parameter: %v", sadl.Pretty(v.Http[0].Inputs))
+	}
+	v, err = parseString(`http GET "/files/{key+}/content" { key String }`)
+	if err == nil {
+		test.Errorf("Greedy label before the end of the path should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`http GET "/files/{key+}" { key Int32 }`)
+	if err == nil {
+		test.Errorf("Greedy label of a non-String type should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestArrayParams(test *testing.T) {
+	v, err := parseString(`type Color Enum { RED, GREEN }
+http GET "/items?tag={tags}&id={ids}&color={colors}" (action=listItems) {
+  tags Array<String>
+  ids Array<Int32> (csv)
+  colors Array<Color>
+  flags Array<String> (header="X-Flags")
+}
+example listItemsRequest { "tags": ["a", "b"], "ids": [1, 2], "colors": ["RED"], "flags": ["x"] }`)
+	if err != nil {
+		test.Fatalf("Array params caused an error: %v", err)
+	}
+	ins := v.Http[0].Inputs
+	if ins[0].Query != "tag" || ins[0].Csv || !ins[1].Csv || ins[3].Header != "X-Flags" {
+		test.Errorf("Array params not parsed as expected: %v", sadl.Pretty(ins))
+	}
+	v, err = parseString(`type Color Enum { RED, GREEN }
+http GET "/items?color={colors}" (action=listItems) {
+  colors Array<Color>
+}
+example listItemsRequest { "colors": ["RED", "BLUE"] }`)
+	if err == nil {
+		test.Errorf("Bad item in an example of an Array param should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`http GET "/items/{id}" (action=getItem) { id String (csv) }`)
+	if err == nil {
+		test.Errorf("The csv option on a path param should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`http GET "/items?q={q}" (action=listItems) { q Array<Struct> }`)
+	if err == nil {
+		test.Errorf("An Array of Struct query param should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestCookieParams(test *testing.T) {
+	v, err := parseString(`type Profile Struct {
+  name String
+}
+http POST "/login" (action=login) {
+  user String (header="X-User")
+  expect 204 {
+    session String (cookie="sid")
+  }
+}
+http GET "/profile" (action=getProfile) {
+  session String (cookie="sid")
+  expect 200 {
+    profile Profile
+  }
+}`)
+	if err != nil {
+		test.Fatalf("Cookie params caused an error: %v", err)
+	}
+	if v.Http[0].Expected.Outputs[0].Cookie != "sid" || v.Http[1].Inputs[0].Cookie != "sid" {
+		test.Errorf("Cookie params not parsed as expected: %v", sadl.Pretty(v.Http))
+	}
+	v, err = parseString(`http GET "/profile" (action=getProfile) { session Array<String> (cookie="sid") }`)
+	if err == nil {
+		test.Errorf("An Array cookie param should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestExceptionHeaders(test *testing.T) {
+	v, err := parseString(`type TooManyRequests Struct {
+  message String
+}
+http GET "/foo" (action=getFoo) {
+  expect 204
+  except 429 {
+    error TooManyRequests
+    retryAfter Int32 (header="Retry-After")
+  }
+}
+example GetFooExceptTooManyRequests {
+  "error": {"message": "slow down"},
+  "retryAfter": 30
+}`)
+	if err != nil {
+		test.Fatalf("Exception headers caused an error: %v", err)
+	}
+	exc := v.Http[0].Exceptions[0]
+	if exc.Type != "TooManyRequests" || len(exc.Outputs) != 2 || exc.Outputs[1].Header != "Retry-After" {
+		test.Errorf("Exception headers not parsed as expected: %v", sadl.Pretty(exc))
+	}
+	v, err = parseString(`type TooManyRequests Struct {
+  message String
+}
+http GET "/foo" (action=getFoo) {
+  expect 204
+  except 429 {
+    error TooManyRequests
+    retryAfter Int32 (header="Retry-After")
+  }
+}
+example GetFooExceptTooManyRequests {
+  "retryAfter": "soon"
+}`)
+	if err == nil {
+		test.Errorf("A bad example of an exceptional response should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestMediaTypes(test *testing.T) {
+	v, err := parseString(`type Signup Struct {
+  name String
+  tags Array<String>
+}
+http PUT "/photos/{id}" (action=putPhoto) {
+  id String
+  body Bytes (mediaType="image/png")
+  expect 204
+}
+http POST "/signup" (action=signup) {
+  body Signup (mediaType="application/x-www-form-urlencoded")
+  expect 200 {
+    body String (mediaType="text/plain")
+  }
+}`)
+	if err != nil {
+		test.Fatalf("Media types caused an error: %v", err)
+	}
+	if v.Http[0].Inputs[1].MediaType != "image/png" || v.Http[1].Expected.Outputs[0].MediaType != "text/plain" {
+		test.Errorf("Media types not parsed as expected: %v", sadl.Pretty(v.Http))
+	}
+	v, err = parseString(`type Foo Struct {
+  x String
+}
+http PUT "/foo" (action=putFoo) {
+  body Foo (mediaType="image/png")
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("A Struct body sent as raw bytes should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`type Foo Struct {
+  x Map<String,String>
+}
+http POST "/foo" (action=postFoo) {
+  body Foo (mediaType="application/x-www-form-urlencoded")
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("A form field that is not a simple type should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`type Foo Struct {
+  x String (nullable)
+}
+http POST "/foo" (action=postFoo) {
+  body Foo (mediaType="application/x-www-form-urlencoded")
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("A nullable form field should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestCommonExceptions(test *testing.T) {
+	v, err := parseString(`type Error Struct {
+  message String
+}
+type BadRequest Struct {
+  message String
+}
+type NotFound Struct {
+  message String
+}
+except 400 BadRequest
+except Error
+http GET "/foo" (action=getFoo) {
+  expect 204
+  except 404 NotFound
+}
+http PUT "/foo" (action=putFoo) {
+  expect 204
+  except 400 NotFound
+}`)
+	if err != nil {
+		test.Fatalf("Common exceptions caused an error: %v", err)
+	}
+	if exceptions := v.HttpExceptions(v.Http[0]); len(exceptions) != 3 || exceptions[1].Type != "BadRequest" || exceptions[2].Type != "Error" {
+		test.Errorf("Common exceptions not added to the action: %v", sadl.Pretty(exceptions))
+	}
+	if exceptions := v.HttpExceptions(v.Http[1]); len(exceptions) != 2 || exceptions[0].Type != "NotFound" || exceptions[1].ResponseStatus() != 500 {
+		test.Errorf("Common exception not overridden by the action: %v", sadl.Pretty(exceptions))
+	}
+	v, err = parseString(`type BadRequest Struct {
+  message String
+}
+type Invalid Struct {
+  message String
+}
+except 400 BadRequest
+except 400 Invalid
+`)
+	if err == nil {
+		test.Errorf("Duplicate common exception status should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`name test
+except 400 Missing
+`)
+	if err == nil || !strings.Contains(err.Error(), "common exception 400") || strings.Contains(err.Error(), "Action 'test'") {
+		test.Errorf("Undefined common exception type should have been described as such, got: %v", err)
+	}
+}
+
+func TestAuth(test *testing.T) {
+	v, err := parseString(`type Item Struct {
+  id String
+}
+auth Key apiKey (header="X-Api-Key")
+auth OAuth oauth2 (tokenUrl="https://example.com/token", scopes=["read", "write"])
+http GET "/items/{id}" (action=getItem, scopes=["read"]) {
+  id String
+  expect 200 {
+    body Item
+  }
+}
+http PUT "/items/{id}" (action=putItem, auth="OAuth", scopes=["write"]) {
+  id String
+  body Item
+  expect 204
+}
+http GET "/health" (action=health, auth=[]) {
+  expect 204
+}`)
+	if err != nil {
+		test.Fatalf("Auth schemes caused an error: %v", err)
+	}
+	if schemes := v.HttpAuth(v.Http[0]); len(schemes) != 2 || schemes[0].Header != "X-Api-Key" {
+		test.Errorf("Action did not require the auth schemes of the service: %v", sadl.Pretty(schemes))
+	}
+	if schemes := v.HttpAuth(v.Http[1]); len(schemes) != 1 || schemes[0].Scheme != "oauth2" {
+		test.Errorf("Action did not require the auth scheme it names: %v", sadl.Pretty(schemes))
+	}
+	if !v.Http[2].Anonymous || len(v.HttpAuth(v.Http[2])) != 0 {
+		test.Errorf("Action should not require authentication: %v", sadl.Pretty(v.Http[2]))
+	}
+	v, err = parseString(`auth OAuth oauth2 (tokenUrl="https://example.com/token", scopes=["read"])
+http GET "/foo" (action=getFoo, scopes=["admin"]) {
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("An undeclared scope should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`http GET "/foo" (action=getFoo, auth="Jwt") {
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("An undefined auth scheme should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestServices(test *testing.T) {
+	v, err := parseString(`type Item Struct {
+  id String
+}
+service Store (version="2.1", base="/store")
+service Admin
+http GET "/items/{id}" (action=getItem, service=Store) {
+  id String
+  expect 200 {
+    body Item
+  }
+}
+http DELETE "/items/{id}" (action=deleteItem, service=Admin) {
+  id String
+  expect 204
+}`)
+	if err != nil {
+		test.Fatalf("Services caused an error: %v", err)
+	}
+	if sd := v.HttpService(v.Http[0]); sd == nil || sd.Version != "2.1" || v.HttpBase(v.Http[0]) != "/store" {
+		test.Errorf("Action not in the service it names: %v", sadl.Pretty(sd))
+	}
+	if actions := v.ServiceHttp(v.FindService("Admin")); len(actions) != 1 || actions[0].Name != "deleteItem" {
+		test.Errorf("Wrong actions for the service: %v", sadl.Pretty(actions))
+	}
+	_, err = parseString(`type Item Struct {
+  id String
+}
+service Item
+http GET "/items/{id}" (action=getItem) {
+  id String
+  expect 200 {
+    body Item
+  }
+}`)
+	if err == nil {
+		test.Errorf("A service with the name of a type should have caused an error")
+	}
+	if err := v.SelectService("Nope"); err == nil {
+		test.Errorf("Selecting an undeclared service should have caused an error")
+	}
+	if err := v.SelectService("Admin"); err != nil || len(v.Services) != 1 || len(v.Http) != 1 || v.FindHttp("getItem") != nil {
+		test.Errorf("Expected only the selected service and its actions (%v): %v", err, sadl.Pretty(v.Schema))
+	}
+	v, err = parseString(`service Store
+http GET "/foo" (action=getFoo) {
+  expect 204
+}`)
+	if err != nil || v.HttpService(v.Http[0]) == nil {
+		test.Errorf("Action should be in the only service (%v): %v", err, sadl.Pretty(v))
+	}
+	v, err = parseString(`service Store
+service Admin
+http GET "/foo" (action=getFoo) {
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("An action that names no service of several should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`http GET "/foo" (action=getFoo, service=Store) {
+  expect 204
+}`)
+	if err == nil {
+		test.Errorf("An undefined service should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestResources(test *testing.T) {
+	actions := `type Item Struct {
+  id String
+}
+http POST "/items" (action=createItem) {
+  item Item
+  expect 201 {
+    item Item
+  }
+}
+http GET "/items/{id}" (action=getItem) {
+  id String
+  expect 200 {
+    item Item
+  }
+}
+http DELETE "/items/{id}" (action=deleteItem) {
+  id String
+  expect 204
+}
+`
+	v, err := parseString(actions + `resource Item (identifiers=["id"], create=createItem, read=getItem, delete=deleteItem)`)
+	if err != nil {
+		test.Fatalf("Resource caused an error: %v", err)
+	}
+	if rd, op := v.HttpLifecycle(v.FindHttp("getItem")); rd == nil || rd.Name != "Item" || op != "read" {
+		test.Errorf("Action not bound to the lifecycle of the resource: %v", sadl.Pretty(rd))
+	}
+	v, err = parseString(actions + `resource Item (identifiers=["id"], read=fetchItem)`)
+	if err == nil {
+		test.Errorf("An undefined action should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(actions + `resource Item (identifiers=["id"], create=getItem)`)
+	if err == nil {
+		test.Errorf("An action with the wrong method should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(actions + `resource Item (identifiers=["name"], read=getItem)`)
+	if err == nil {
+		test.Errorf("An identifier that is not a path input should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString("name test\n" + actions + `resource Item (identifiers=["id"], read=getItem)`)
+	if err != nil {
+		test.Fatalf("Resource caused an error: %v", err)
+	}
+	if hact := v.FindHttp("getItem"); hact.Resource != "Item" {
+		test.Errorf("A bound action should belong to its resource: %v", hact.Resource)
+	}
+	v, err = parseString(sadl.DecompileSadl(v) + `http GET "/widgets/{id}" (action=getWidget, resource=Widget) {
+  id String
+  expect 200 {
+    item Item
+  }
+}`)
+	if err != nil {
+		test.Fatalf("Decompiled resource caused an error: %v", err)
+	}
+	if v.FindHttp("getItem") == nil || v.FindHttp("getWidget").Resource != "Widget" {
+		test.Errorf("Action names and resources did not round trip: %v", sadl.Pretty(v.Http))
+	}
+	if again, err := parseString(sadl.DecompileSadl(v)); err != nil || again.FindHttp("getWidget").Resource != "Widget" {
+		test.Errorf("Action resource did not round trip: %v", err)
+	}
+	v, err = parseString(actions + `http PUT "/items/{id}" (action=putItem) {
+  id String
+  item Item
+  expect 200 {
+    item Item
+  }
+}
+resource Item (identifiers=["id"], create=putItem)`)
+	if err == nil {
+		test.Errorf("An identifier that is a path variable of a create action should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(`type Item Struct {
+  id String
+}
+http GET "/items/{id}" (action=getItem, resource=Widget) {
+  id String
+  expect 200 {
+    item Item
+  }
+}
+resource Item (identifiers=["id"], read=getItem)`)
+	if err == nil {
+		test.Errorf("An action of another resource should have caused an error: %v", sadl.Pretty(v))
+	}
+}
+
+func TestPaginated(test *testing.T) {
+	listing := `type ItemListing Struct {
+  items Array<String>
+  next String
+}
+`
+	v, err := parseString(listing + `http GET "/items?limit={limit}&after={after}" (action=listItems, paginated={"inputToken": "after", "outputToken": "listing.next", "pageSize": "limit", "items": "listing.items"}) {
+  limit Int32
+  after String
+  expect 200 {
+    listing ItemListing
+  }
+}`)
+	if err != nil {
+		test.Fatalf("Pagination caused an error: %v", err)
+	}
+	if pg := v.FindHttp("listItems").Paginated; pg == nil || pg.OutputToken != "listing.next" || pg.PageSize != "limit" {
+		test.Errorf("Pagination not parsed: %v", sadl.Pretty(pg))
+	}
+	v, err = parseString(listing + `http GET "/items?after={after}" (action=listItems, paginated={"inputToken": "after", "outputToken": "listing.token"}) {
+  after String
+  expect 200 {
+    listing ItemListing
+  }
+}`)
+	if err == nil {
+		test.Errorf("An output token that is not an output should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(listing + `http GET "/items?after={after}" (action=listItems, paginated={"inputToken": "after", "outputToken": "listing.next", "items": "listing.next"}) {
+  after String
+  expect 200 {
+    listing ItemListing
+  }
+}`)
+	if err == nil {
+		test.Errorf("Items that are not an Array should have caused an error: %v", sadl.Pretty(v))
+	}
+	v, err = parseString(listing + `http GET "/items" (action=listItems, paginated={"inputToken": "after", "outputToken": "listing.next"}) {
+  expect 200 {
+    listing ItemListing
+  }
+}`)
+	if err == nil {
+		test.Errorf("An input token that is not an input should have caused an error: %v", sadl.Pretty(v))
+	}
+	for _, anno := range []string{`x_paginated="inputToken=after,outputToken=listing.next"`, `x_paginated={"inputToken": "after", "outputToken": "listing.next"}`} {
+		v, err = parseString(listing + `http GET "/items?after={after}" (action=listItems, ` + anno + `) {
+  after String
+  expect 200 {
+    listing ItemListing
+  }
+}`)
+		if err != nil {
+			test.Fatalf("Pagination annotation caused an error: %v", err)
+		}
+		if hd := v.FindHttp("listItems"); hd.Paginated == nil || hd.Paginated.OutputToken != "listing.next" || hd.Annotations != nil {
+			test.Errorf("Pagination annotation not adopted as the pagination: %v", sadl.Pretty(hd))
+		}
+	}
+	v, err = parseString(listing + `http GET "/items?after={after}" (action=listItems, x_paginated="inputToken=after,outputToken=nextToken") {
+  after String
+  expect 200 {
+    listing ItemListing
+  }
+}`)
+	if err != nil || v.FindHttp("listItems").Paginated != nil || v.FindHttp("listItems").Annotations["x_paginated"] == nil {
+		test.Errorf("Pagination annotation that names no output should be kept as is (%v): %v", err, sadl.Pretty(v))
+	}
+}
+
 func TestSimpleExpect(test *testing.T) {
 	v, err := parseString(`type Foo Struct {
   x String
@@ -361,3 +862,553 @@ http GET "/foo" {
 		test.Errorf("simple expect caused an error (%v): %v", err, sadl.Pretty(v))
 	}
 }
+
+func TestConstants(test *testing.T) {
+	testParse(test, true, `
+const MaxPageSize Int32 = 100
+const DefaultColor String = "blue"
+type Test Struct {
+   limit Int32 (min=1, max=MaxPageSize, default=MaxPageSize)
+   color String (maxsize=MaxPageSize, default=DefaultColor)
+}
+`)
+	testParse(test, false, `
+const MaxPageSize Int8 = 1000
+`)
+	testParse(test, false, `
+const MaxPageSize Int32 = "100"
+`)
+	testParse(test, false, `
+type Test Struct {
+   limit Int32 (max=MaxPageSize)
+}
+`)
+	testParse(test, false, `
+const MaxPageSize Int32 = 100
+const MaxPageSize Int32 = 200
+`)
+	testParse(test, false, `
+const Limit Int32 = 10
+type Test Struct {
+   limit Int32 (max=Limit, default=20)
+}
+`)
+	v, err := parseString(`name test
+const DefaultLimit Int32 = MaxLimit
+type Test Struct {
+   limit Int32 (max=MaxLimit, default=DefaultLimit)
+}
+const MaxLimit Int32 = 100
+`)
+	if err != nil {
+		test.Fatalf("Constants used before their definitions caused an error: %v", err)
+	}
+	if cd := v.FindConstant("DefaultLimit"); cd == nil || sadl.ToString(cd.Value) != "100" || v.FindType("Test").Fields[0].Max == nil {
+		test.Errorf("Constant not resolved from a later one: %v", sadl.Pretty(v.Constants))
+	}
+	testParse(test, false, `
+const A Int32 = B
+const B Int32 = A
+`)
+	testParse(test, false, `
+type Limit Int32
+const Limit Int32 = 10
+`)
+	testParse(test, false, `
+const String String = "s"
+`)
+}
+
+func TestNullableField(test *testing.T) {
+	testParse(test, true, `
+type Test Struct {
+   name String (required)
+   nickname String (nullable)
+}
+example Test {
+   "name": "Lee",
+   "nickname": null
+}
+`)
+	testParse(test, false, `
+type Test Struct {
+   name String (required)
+   nickname String
+}
+example Test {
+   "name": "Lee",
+   "nickname": null
+}
+`)
+	testParse(test, false, `
+type Test Struct {
+   name String (required)
+}
+example Test {
+   "name": null
+}
+`)
+}
+
+func TestTypedAnnotations(test *testing.T) {
+	src := `
+name test
+x_level 3
+type Test Struct {
+   id String (x_flag, x_weight=1.5, x_tags=["a", "b"], x_meta={"n": [1, true]})
+}
+`
+	model, err := parseString(src)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	src2 := sadl.DecompileSadl(model)
+	model2, err := parseString(src2)
+	if err != nil {
+		test.Fatalf("%v\n%s", err, src2)
+	}
+	for _, m := range []*sadl.Model{model, model2} {
+		if sadl.GetAnnotation(m.Annotations, "x_level") != "3" {
+			test.Errorf("Bad top level annotation: %v", m.Annotations)
+		}
+		annos := m.FindType("Test").Fields[0].Annotations
+		if sadl.GetAnnotation(annos, "x_flag") != "true" {
+			test.Errorf("Bad flag annotation: %v", annos)
+		}
+		if tags := sadl.GetAnnotationStrings(annos, "x_tags"); len(tags) != 2 || tags[1] != "b" {
+			test.Errorf("Bad array annotation: %v", annos)
+		}
+		if s := sadl.GetAnnotation(annos, "x_meta"); s != `{"n":[1,true]}` {
+			test.Errorf("Bad object annotation: %s", s)
+		}
+	}
+}
+
+func TestAnnotationDeclarations(test *testing.T) {
+	decls := `
+annotation x_owner String (pattern="^[a-z]+$", targets=["type", "field"])
+annotation x_internal Bool (targets=["http"])
+`
+	testParse(test, true, decls+`
+type Item Struct (x_owner="catalog") {
+   id String (x_owner="ids")
+}
+http GET "/items" (x_internal) {
+   expect 200 {
+      items Array<Item>
+   }
+}
+`)
+	//misspelled
+	testParse(test, false, decls+`
+type Item String (x_ownr="catalog")
+`)
+	//mistyped
+	testParse(test, false, decls+`
+type Item String (x_owner="Catalog")
+`)
+	//wrong target
+	testParse(test, false, decls+`
+http GET "/items" (x_owner="catalog") {
+   expect 204 {}
+}
+`)
+	//undeclared annotations are allowed if there are no declarations
+	testParse(test, true, `
+type Item String (x_ownr="catalog")
+`)
+}
+
+func TestSourceSpans(test *testing.T) {
+	model, err := parseString(`name test
+
+type Foo Struct {
+   a String
+}
+`)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	td := model.FindType("Foo")
+	if td.Span == nil || td.Span.Line != 3 || td.Span.Col != 1 || td.Span.EndLine != 5 {
+		test.Errorf("Bad span for type Foo: %s", sadl.Pretty(td.Span))
+	}
+	if fs := td.Fields[0].Span; fs == nil || fs.Line != 4 || fs.Col != 4 {
+		test.Errorf("Bad span for field Foo.a: %s", sadl.Pretty(fs))
+	}
+	_, err = parseString(`name test
+
+type Foo Struct {
+   a String
+   b Bar
+}
+`)
+	diags, ok := err.(sadl.Diagnostics)
+	if !ok || len(diags) != 1 {
+		test.Fatalf("Expected one diagnostic, got: %v", err)
+	}
+	if serr := diags[0]; serr.Span.Line != 5 || serr.Span.Col != 4 {
+		test.Errorf("Bad error location: %s", sadl.Pretty(serr.Span))
+	}
+}
+
+func TestErrorRecovery(test *testing.T) {
+	_, err := parseString(`name test
+
+type Foo Struct {
+   a String (requird)
+   b = 3
+   c Int32
+}
+
+type Bar Struct {
+   x Baz
+   y Quux
+}
+
+const Three Int32 = 3 (x_nope
+`)
+	diags, ok := err.(sadl.Diagnostics)
+	if !ok {
+		test.Fatalf("Expected Diagnostics, got: %v", err)
+	}
+	lines := []int{4, 5, 14}
+	if len(diags) != len(lines) {
+		test.Fatalf("Expected %d diagnostics, got %d: %v", len(lines), len(diags), err)
+	}
+	for i, d := range diags {
+		if d.Severity != sadl.SeverityError || d.Span == nil || d.Span.Line != lines[i] {
+			test.Errorf("Bad diagnostic, expected an error on line %d: %s", lines[i], sadl.Pretty(d))
+		}
+	}
+	_, err = parseString(`name test
+
+type Bar Struct {
+   x Baz
+   y Quux
+}
+`)
+	if diags := sadl.AsDiagnostics(err); len(diags) != 2 || diags[1].Span.Line != 5 {
+		test.Errorf("Expected both undefined types to be reported, got: %v", err)
+	}
+}
+
+func TestParseFS(test *testing.T) {
+	fsys := fstest.MapFS{
+		"api/main.sadl": &fstest.MapFile{Data: []byte(`name main
+include "api/types.sadl"
+type Foo Struct {
+   id Id
+}
+`)},
+		"api/types.sadl": &fstest.MapFile{Data: []byte(`type Id String (pattern="[a-z]+")
+`)},
+	}
+	model, err := sadl.ParseSadl(fsys, "api/main.sadl", sadl.NewData())
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if td := model.FindType("Id"); td == nil || sadl.GetAnnotation(td.Annotations, "x_include") != "api/types.sadl" {
+		test.Errorf("Included type not found in the file system")
+	}
+	_, err = sadl.ParseSadl(fsys, "api/missing.sadl", sadl.NewData())
+	if err == nil {
+		test.Errorf("Expected an error parsing a file not in the file system")
+	}
+	model, err = sadl.ParseSadlReader(strings.NewReader("name reader\ntype Foo String\n"), "reader.sadl", sadl.NewData())
+	if err != nil || model.FindType("Foo") == nil {
+		test.Errorf("Cannot parse from a reader: %v", err)
+	}
+}
+
+type policyExtension struct {
+	transformed bool
+}
+
+func (ext *policyExtension) Name() string                   { return "policy" }
+func (ext *policyExtension) Result() interface{}            { return nil }
+func (ext *policyExtension) Parse(p *sadl.Parser) error     { return p.SyntaxError() }
+func (ext *policyExtension) Validate(p *sadl.Parser) error  { return nil }
+func (ext *policyExtension) Options(target string) []string { return []string{"rateLimit"} }
+func (ext *policyExtension) Statements() []string           { return []string{"auth", "quota"} }
+
+func (ext *policyExtension) ParseOption(p *sadl.Parser, target string, name string) (interface{}, error) {
+	tok := p.GetToken()
+	if tok == nil || tok.Text != "=" {
+		return nil, p.SyntaxError()
+	}
+	return p.ExpectString()
+}
+
+func (ext *policyExtension) ParseStatement(p *sadl.Parser, op *sadl.HttpDef, keyword string) (interface{}, error) {
+	if keyword == "quota" {
+		return p.ExpectIdentifier()
+	}
+	return p.ExpectString()
+}
+
+func (ext *policyExtension) UnparseStatement(keyword string, value interface{}) string {
+	if keyword == "quota" {
+		return value.(string)
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+func (ext *policyExtension) Transform(model *sadl.Model) error {
+	ext.transformed = true
+	return nil
+}
+
+func TestExtensionHooks(test *testing.T) {
+	src := `name test
+annotation x_owner String
+type Item Struct (rateLimit="10/s") {
+   id String (rateLimit="5/s", required)
+}
+http GET "/items/{id}" (action=getItem) {
+   id String
+   auth "oauth2"
+   quota perUser
+   expect 200 {
+      body Item
+   }
+}
+`
+	ext := &policyExtension{}
+	model, err := sadl.ParseSadlString(src, sadl.NewData(), ext)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	td := model.FindType("Item")
+	if sadl.GetAnnotation(td.Annotations, "rateLimit") != "10/s" || sadl.GetAnnotation(td.Fields[0].Annotations, "rateLimit") != "5/s" || !td.Fields[0].Required {
+		test.Errorf("Extension options not parsed: %s", sadl.Pretty(td))
+	}
+	hd := model.FindHttp("getItem")
+	if sadl.GetAnnotation(hd.Statements, "auth") != "oauth2" || len(hd.Inputs) != 1 || hd.Auth != nil {
+		test.Errorf("Extension http statement not parsed: %s", sadl.Pretty(hd))
+	}
+	if !ext.transformed {
+		test.Errorf("Extension transform not run")
+	}
+	_, err = parseString(src)
+	if err == nil {
+		test.Errorf("Expected an error for options with no extension to accept them")
+	}
+	//the statement's keyword is also a built-in http option, so it must be decompiled as a statement
+	src2 := sadl.DecompileSadl(model, ext)
+	model2, err := sadl.ParseSadlString(src2, sadl.NewData(), &policyExtension{})
+	if err != nil {
+		test.Fatalf("Cannot reparse the decompiled model: %v\n%s", err, src2)
+	}
+	if hd2 := model2.FindHttp("getItem"); sadl.GetAnnotation(hd2.Statements, "auth") != "oauth2" || sadl.GetAnnotation(hd2.Statements, "quota") != "perUser" {
+		test.Errorf("Extension http statements did not round trip:\n%s", src2)
+	}
+}
+
+func TestLoadModelValidates(test *testing.T) {
+	fsys := fstest.MapFS{
+		"good.json": &fstest.MapFile{Data: []byte(`{"sadl": "v1.2.0", "name": "good", "types": [
+  {"name": "Items", "type": "List", "items": "String"},
+  {"name": "Page", "type": "Struct", "fields": [{"name": "items", "type": "Items"}, {"name": "limit", "type": "Int32", "default": 10}]}
+], "constants": [{"name": "MaxLimit", "type": "Int32", "value": 100}],
+"examples": [{"target": "Page", "example": {"items": ["a"], "limit": 5}}]}`)},
+		"undefined.json": &fstest.MapFile{Data: []byte(`{"sadl": "v1.2.0", "name": "bad", "types": [
+  {"name": "Page", "type": "Struct", "fields": [{"name": "items", "type": "Items"}]}
+]}`)},
+		"newer.json":  &fstest.MapFile{Data: []byte(`{"sadl": "v99.0.0", "name": "newer"}`)},
+		"nosadl.json": &fstest.MapFile{Data: []byte(`{"name": "nosadl"}`)},
+	}
+	model, err := sadl.LoadModelFS(fsys, "good.json")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if td := model.FindType("Items"); td == nil || td.Type != "Array" {
+		test.Errorf("Expected the List alias to be migrated to Array")
+	}
+	_, err = sadl.LoadModelFS(fsys, "undefined.json")
+	if err == nil || !strings.Contains(err.Error(), "Items") {
+		test.Errorf("Expected an undefined type error, got: %v", err)
+	}
+	_, err = sadl.LoadModelFS(fsys, "nosadl.json")
+	if err == nil {
+		test.Errorf("Expected an error loading a model with no sadl version")
+	}
+	saved := sadl.Version
+	sadl.Version = "v1.9.0"
+	defer func() { sadl.Version = saved }()
+	_, err = sadl.LoadModelFS(fsys, "newer.json")
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		test.Errorf("Expected an error loading a model from a newer version, got: %v", err)
+	}
+}
+
+func TestInlineConstraints(test *testing.T) {
+	src := `name test
+type Id String (pattern="^[a-z]+$")
+type Count Int32 (min=0)
+type Money UnitValue
+type Currency Enum {
+   USD
+   EUR
+}
+type Item Struct {
+   id Id (maxsize=5, required)
+   count Count (max=100)
+   price Money (unit=Currency)
+   cost UnitValue<Decimal,String> (unit=Currency)
+}
+type Choice Union {
+   small Count (max=10) // a small one
+   name String (minsize=2)
+}
+http GET "/items/{id}" {
+   id Id (maxsize=5)
+   expect 200 {
+      item Item
+   }
+}
+`
+	model, err := parseString(src)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	item := model.FindType("Item")
+	if item.Fields[0].MaxSize == nil || *item.Fields[0].MaxSize != 5 {
+		test.Errorf("Expected an inline maxsize on a field of a named type")
+	}
+	choice := model.FindType("Choice")
+	if v := choice.Variants[0]; v.Max == nil || v.Comment != "a small one" {
+		test.Errorf("Expected the constraints and comment of a union variant to be kept: %s", sadl.Pretty(v))
+	}
+	if err := model.Validate("", "Item", map[string]interface{}{"id": "abcdef"}); err == nil {
+		test.Errorf("Expected the inline maxsize to be checked")
+	}
+	if err := model.Validate("", "Item", map[string]interface{}{"id": "AB"}); err == nil {
+		test.Errorf("Expected the pattern of the named type to still be checked")
+	}
+	if err := model.Validate("", "Choice", map[string]interface{}{"small": 11}); err == nil {
+		test.Errorf("Expected the inline max of a union variant to be checked")
+	}
+	if item.Fields[3].Unit != "Currency" {
+		test.Errorf("Expected the unit option to set the unit of an inline UnitValue: %s", sadl.Pretty(item.Fields[3]))
+	}
+	if err := model.Validate("", "Item", map[string]interface{}{"id": "ab", "price": "10.00 GBP"}); err == nil {
+		test.Errorf("Expected the inline unit of a named UnitValue to be checked")
+	}
+	if err := model.Validate("", "Item", map[string]interface{}{"id": "ab", "price": "10.00 USD"}); err != nil {
+		test.Errorf("Expected a value in the inline unit to be valid: %v", err)
+	}
+	if ts := model.ConstrainedTypeSpec(&item.Fields[0].TypeSpec); ts.Type != "String" || ts.Pattern == "" || ts.MaxSize == nil {
+		test.Errorf("Expected the type and inline constraints to be merged: %s", sadl.Pretty(ts))
+	}
+	again, err := parseString(sadl.DecompileSadl(model))
+	if err != nil || *again.FindType("Item").Fields[0].MaxSize != 5 || again.FindType("Item").Fields[2].Unit != "Currency" {
+		test.Errorf("Inline constraints did not round trip: %v", err)
+	}
+	testParse(test, false, `type Flag Bool
+type Foo Struct {
+   f Flag (min=1)
+}
+`)
+	testParse(test, false, `type Id String
+type Foo Struct {
+   id Id (values=["a"], pattern="[a-z]")
+}
+`)
+	testParse(test, false, `type Count Int32
+type Foo Struct {
+   n Count (max=3)
+}
+example Foo {"n": 4}
+`)
+	testParse(test, false, `type Count Int32
+type Foo Struct {
+   n Count (unit=String)
+}
+`)
+	testParse(test, false, `type Foo Struct {
+   price UnitValue (unit=Int32)
+}
+`)
+}
+
+func TestLoadModelMigrates(test *testing.T) {
+	ast := `{"sadl": %q, "name": "files", "http": [
+  {"method": "GET", "path": "/files/{key+}", "inputs": [{"name": "key", "type": "String", "path": true}],
+   "expected": {"status": 200}}
+]}`
+	fsys := fstest.MapFS{
+		"old.json": &fstest.MapFile{Data: []byte(fmt.Sprintf(ast, "v0.9.2"))},
+	}
+	model, err := sadl.LoadModelFS(fsys, "old.json")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	hd := model.Http[0]
+	if hd.Name != "getFiles" || !hd.Inputs[0].Greedy {
+		test.Errorf("Expected the http action of an old version to be migrated: %s", sadl.Pretty(hd))
+	}
+	if model.Sadl != "v1.9.0" {
+		test.Errorf("Expected a development build to stamp a migrated model with a release, got %q", model.Sadl)
+	}
+	//a migrated model loads again as it was
+	fsys["migrated.json"] = &fstest.MapFile{Data: []byte(sadl.Pretty(model.Schema))}
+	model, err = sadl.LoadModelFS(fsys, "migrated.json")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if model.Sadl != "v1.9.0" || model.Http[0].Name != "getFiles" || !model.Http[0].Inputs[0].Greedy {
+		test.Errorf("Expected a migrated model to load unchanged: %s", sadl.Pretty(model.Schema))
+	}
+	//a model written by a release with greedy labels is not migrated
+	fsys["newer.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(ast, "v1.9.0-3-g1234567"))}
+	model, err = sadl.LoadModelFS(fsys, "newer.json")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if model.Http[0].Inputs[0].Greedy {
+		test.Errorf("Expected a model of a release with greedy labels not to be migrated: %s", sadl.Pretty(model.Schema))
+	}
+	saved := sadl.Version
+	sadl.Version = "v1.9.2-3-g1234567"
+	defer func() { sadl.Version = saved }()
+	model, err = sadl.LoadModelFS(fsys, "old.json")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if model.Sadl != sadl.Version {
+		test.Errorf("Expected a migrated model to be stamped with the current version, got %q", model.Sadl)
+	}
+}
+
+func TestWireNames(test *testing.T) {
+	model, err := parseString(`name test
+type Status Enum {
+   IN_PROGRESS (json="in-progress")
+   DONE
+}
+type Doc Struct {
+   contentType String (json="content-type", required)
+   status Status
+}
+example Doc {"content-type": "text/plain", "status": "in-progress"}
+`)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	fd := model.FindType("Doc").Fields[0]
+	if fd.WireName() != "content-type" || sadl.GetAnnotation(fd.Annotations, "x_wire_name") != "content-type" {
+		test.Errorf("Expected a wire name for the field: %s", sadl.Pretty(fd))
+	}
+	if err := model.Validate("", "Doc", map[string]interface{}{"contentType": "text/plain"}); err == nil {
+		test.Errorf("Expected the field name to be rejected as a JSON key")
+	}
+	if err := model.Validate("", "Status", "IN_PROGRESS"); err == nil {
+		test.Errorf("Expected the enum symbol to be rejected as a JSON value")
+	}
+	testParse(test, false, `type Doc Struct {
+   a String (json="b")
+   b String
+}
+`)
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/boynton/sadl"
@@ -26,3 +27,129 @@ func TestRefactorStruct(test *testing.T) {
 		}
 	}
 }
+
+const refactorSource = `name test
+type ItemId String (pattern="^[a-z]+$")
+type Item Struct {
+   id ItemId (required)
+   street String
+   city String (required)
+   tags Array<ItemId>
+}
+type Items Array<Item>
+type ItemListing Struct {
+   items Items
+   next String
+}
+auth Key apiKey (header="X-Api-Key")
+http GET "/items/{id}" (action=getItem) {
+   id ItemId
+   expect 200 {
+      body Item
+   }
+   except 404 {
+      error Item
+      reason String (header="X-Reason")
+   }
+}
+http GET "/items?after={after}" (action=listItems, auth="Key", paginated={"inputToken": "after", "outputToken": "listing.next", "items": "listing.items"}) {
+   after String
+   expect 200 {
+      listing ItemListing
+   }
+}
+resource Item (identifiers=["id"], read=getItem, list=listItems)
+example Item {"id": "abc", "street": "Main", "city": "Springfield"}
+example GetItemExceptItem {"error": {"id": "xyz", "city": "Nowhere"}, "reason": "gone"}
+`
+
+func refactored(test *testing.T, refactor func(model *sadl.Model) error) *sadl.Model {
+	model, err := parseString(refactorSource)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	err = refactor(model)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	//the result must be valid, and survive a round trip through SADL source
+	reparsed, err := parseString(sadl.DecompileSadl(model))
+	if err != nil {
+		test.Fatalf("Refactored model is invalid: %v\n%s", err, sadl.DecompileSadl(model))
+	}
+	return reparsed
+}
+
+func TestRefactorRename(test *testing.T) {
+	model := refactored(test, func(model *sadl.Model) error {
+		return model.RenameType("Item", "Product")
+	})
+	hd := model.FindHttp("getItem")
+	if model.FindType("Item") != nil || model.FindType("Items").Items != "Product" || hd.Expected.Outputs[0].Type != "Product" || hd.Exceptions[0].Type != "Product" || model.Examples[0].Target != "Product" {
+		test.Errorf("Type not renamed everywhere:\n%s", sadl.DecompileSadl(model))
+	}
+	if model.Examples[1].Target != "GetItemExceptProduct" {
+		test.Errorf("Exception example not renamed: %s", model.Examples[1].Target)
+	}
+	model = refactored(test, func(model *sadl.Model) error {
+		return model.RenameField("Item", "street", "address", true)
+	})
+	fd := model.FindType("Item").Fields[1]
+	if fd.Name != "address" || sadl.GetAnnotation(fd.Annotations, "x_wire_name") != "street" || sadl.AsString(sadl.AsMap(model.Examples[0].Example)["street"]) != "Main" {
+		test.Errorf("Field not renamed, keeping its wire name:\n%s", sadl.DecompileSadl(model))
+	}
+	model = refactored(test, func(model *sadl.Model) error {
+		return model.RenameField("Item", "street", "address", false)
+	})
+	if sadl.AsString(sadl.AsMap(model.Examples[0].Example)["address"]) != "Main" {
+		test.Errorf("Example key not renamed with the field:\n%s", sadl.DecompileSadl(model))
+	}
+	model = refactored(test, func(model *sadl.Model) error {
+		return model.RenameField("ItemListing", "next", "cursor", false)
+	})
+	if pg := model.FindHttp("listItems").Paginated; pg.OutputToken != "listing.cursor" || pg.Items != "listing.items" {
+		test.Errorf("Pagination path not renamed with the field: %s", sadl.Pretty(pg))
+	}
+	if hd := model.FindHttp("listItems"); hd.Auth[0] != "Key" || hd.Resource != "Item" {
+		test.Errorf("Auth and resource references lost in the rename: %s", sadl.Pretty(hd))
+	}
+	model, _ = parseString(refactorSource)
+	if err := model.RenameType("Item", "ItemId"); err == nil {
+		test.Errorf("Expected an error renaming a type to an existing name")
+	}
+}
+
+func TestRefactorInline(test *testing.T) {
+	model := refactored(test, func(model *sadl.Model) error {
+		return model.InlineType("Items")
+	})
+	if model.FindType("Items") != nil {
+		test.Errorf("Type not removed after inlining")
+	}
+	model, _ = parseString(refactorSource)
+	err := model.InlineType("ItemId")
+	if err == nil || !strings.Contains(err.Error(), "type parameter") {
+		test.Errorf("Expected an error inlining a constrained type used in Array<ItemId>, got: %v", err)
+	}
+	model, _ = parseString(strings.Replace(refactorSource, "tags Array<ItemId>", "", 1))
+	err = model.InlineType("ItemId")
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if id := model.FindType("Item").Fields[0]; id.Type != "String" || id.Pattern != "^[a-z]+$" || !id.Required {
+		test.Errorf("Type not inlined into the field: %s", sadl.Pretty(id))
+	}
+}
+
+func TestRefactorExtract(test *testing.T) {
+	model := refactored(test, func(model *sadl.Model) error {
+		return model.ExtractType("Item", "Address", "address", "street", "city")
+	})
+	td := model.FindType("Item")
+	if len(td.Fields) != 3 || td.Fields[1].Name != "address" || !td.Fields[1].Required || len(model.FindType("Address").Fields) != 2 {
+		test.Errorf("Fields not extracted:\n%s", sadl.DecompileSadl(model))
+	}
+	if address := sadl.AsMap(sadl.AsMap(model.Examples[0].Example)["address"]); sadl.AsString(address["city"]) != "Springfield" {
+		test.Errorf("Example not restructured: %s", sadl.Pretty(model.Examples[0]))
+	}
+}
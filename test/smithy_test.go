@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/boynton/sadl/smithy"
+)
+
+func TestSmithyAuth(test *testing.T) {
+	model, err := parseString(`auth Token bearer
+auth OAuth oauth2 (tokenUrl="https://example.com/token", scopes=["read"])
+http GET "/items" (action=listItems, auth="OAuth") {
+  expect 204
+}`)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if _, err = smithy.FromSADL(model, "example"); err == nil {
+		test.Errorf("An action whose auth schemes have no Smithy trait should not export")
+	}
+	model, err = parseString(`auth OAuth oauth2 (tokenUrl="https://example.com/token", scopes=["read"])
+http GET "/items" (action=listItems) {
+  expect 204
+}`)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if _, err = smithy.FromSADL(model, "example"); err == nil {
+		test.Errorf("A service whose auth schemes have no Smithy trait should not export")
+	}
+	model, err = parseString(`auth Token bearer
+auth OAuth oauth2 (tokenUrl="https://example.com/token", scopes=["read"])
+http GET "/items" (action=listItems, auth=["OAuth", "Token"]) {
+  expect 204
+}`)
+	if err != nil {
+		test.Fatalf("%v", err)
+	}
+	if _, err = smithy.FromSADL(model, "example"); err != nil {
+		test.Errorf("An action with a scheme Smithy can express should export: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package sadl
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -11,7 +12,8 @@ const indentAmount = "    "
 
 type SadlGenerator struct {
 	Generator
-	Model *Model
+	Model      *Model
+	Extensions []Extension
 }
 
 func NewGenerator(model *Model, outdir string) *SadlGenerator {
@@ -21,8 +23,11 @@ func NewGenerator(model *Model, outdir string) *SadlGenerator {
 	return gen
 }
 
-func DecompileSadl(model *Model) string {
+// DecompileSadl returns the SADL source for the model. The extensions it was parsed with write back the statements
+// they parsed.
+func DecompileSadl(model *Model, extensions ...Extension) string {
 	g := NewGenerator(model, "")
+	g.Extensions = extensions
 	sadlSource := g.Generate()
 	if g.Err != nil {
 		panic(g.Err.Error())
@@ -41,30 +46,51 @@ func (g *SadlGenerator) Generate() string {
 		"literal": func(s string) string {
 			return fmt.Sprintf("%q", s)
 		},
-		"annotations": func(annos map[string]string) string {
+		"annotations": func(annos map[string]interface{}) string {
 			s := ""
 			if len(annos) > 0 {
 				s = "\n"
 				for k, v := range annos {
-					if v == "" {
-						s += fmt.Sprintf("%s\n", k)
-					} else {
-						s += fmt.Sprintf("%s %q\n", k, v)
-					}
+					s += strings.Replace(AnnotationOption(k, v), "=", " ", 1) + "\n"
 				}
 			}
 			return s
 		},
 		"typedef": func(td *TypeDef) string {
-			return fmt.Sprintf("type %s %s\n", td.Name, g.sadlTypeSpec(&td.TypeSpec, nil, ""))
+			return g.sadlTypeDef(td)
+		},
+		"constant": func(cd *ConstantDef) string {
+			return fmt.Sprintf("const %s %s = %s\n", cd.Name, cd.Type, ToString(cd.Value))
+		},
+		"annotationDef": func(ad *AnnotationDef) string {
+			var opts []string
+			if len(ad.Targets) > 0 {
+				opts = append(opts, "targets="+stringList(ad.Targets))
+			}
+			return fmt.Sprintf("annotation %s %s\n", ad.Name, g.sadlTypeSpec(&ad.TypeSpec, opts, ""))
 		},
 		"operation": func(op *OperationDef) string {
 			return g.sadlOperationSpec(op)
 		},
+		"auth": func(ad *AuthDef) string {
+			return g.sadlAuthDef(ad)
+		},
+		"service": func(sd *ServiceDef) string {
+			return g.sadlServiceDef(sd)
+		},
+		"resource": func(rd *ResourceDef) string {
+			return g.sadlResourceDef(rd)
+		},
+		"exception": func(exc *HttpExceptionSpec) string {
+			return g.sadlExceptionSpec(exc, "")
+		},
 		"http": func(hact *HttpDef) string {
 			return g.sadlHttpSpec(hact)
 		},
 		"example": func(ed *ExampleDef) string {
+			if ed.Name == "" {
+				return fmt.Sprintf("example %s %s\n", ed.Target, Pretty(ed.Example))
+			}
 			return fmt.Sprintf("example %s (name=%s) %s\n", ed.Target, ed.Name, Pretty(ed.Example))
 		},
 	}
@@ -86,6 +112,25 @@ func (g *SadlGenerator) CreateSadlSource() {
 	}
 }
 
+// DecompileTypeDef returns the SADL source for a single type definition, without its comment.
+func DecompileTypeDef(model *Model, td *TypeDef) string {
+	return NewGenerator(model, "").sadlTypeDef(td)
+}
+
+func (g *SadlGenerator) sadlTypeDef(td *TypeDef) string {
+	switch td.Type {
+	case "Struct", "Enum":
+		//the options precede the opening brace
+		spec := g.sadlTypeSpec(&td.TypeSpec, nil, "")
+		return fmt.Sprintf("type %s %s%s%s\n", td.Name, td.Type, AnnotationsAsString(td.Annotations), spec[len(td.Type):])
+	}
+	var opts []string
+	for k, v := range td.Annotations {
+		opts = append(opts, AnnotationOption(k, v))
+	}
+	return fmt.Sprintf("type %s %s\n", td.Name, g.sadlTypeSpec(&td.TypeSpec, opts, ""))
+}
+
 func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string) string {
 	switch ts.Type {
 	case "Enum":
@@ -99,7 +144,12 @@ func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string)
 			annos := AnnotationsAsString(el.Annotations)
 			s = s + indent + indentAmount + el.Symbol + annos + com + "\n"
 		}
-		return s + indent + "}"
+		//the options of a field with an inline type follow the closing brace
+		sopts := ""
+		if len(opts) > 0 {
+			sopts = " (" + strings.Join(opts, ", ") + ")"
+		}
+		return s + indent + "}" + sopts
 	case "String":
 		if ts.Pattern != "" {
 			opts = append(opts, fmt.Sprintf("pattern=%q", ts.Pattern))
@@ -113,6 +163,9 @@ func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string)
 		if ts.Values != nil {
 			opts = append(opts, fmt.Sprintf("values=%s", stringList(ts.Values)))
 		}
+		if ts.Reference != "" {
+			opts = append(opts, "reference="+ts.Reference)
+		}
 		sopts := ""
 		if len(opts) > 0 {
 			sopts = " (" + strings.Join(opts, ", ") + ")"
@@ -154,6 +207,12 @@ func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string)
 			sopts = " (" + strings.Join(opts, ", ") + ")"
 		}
 		return fmt.Sprintf("Map<%s,%s>%s", ts.Keys, ts.Items, sopts)
+	case "UnitValue":
+		sopts := ""
+		if len(opts) > 0 {
+			sopts = " (" + strings.Join(opts, ", ") + ")"
+		}
+		return fmt.Sprintf("UnitValue<%s,%s>%s", ts.Value, ts.Unit, sopts)
 	case "Struct":
 		sopt := ""
 		if len(ts.Fields) > 0 {
@@ -179,12 +238,22 @@ func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string)
 				if fd.Required {
 					fopts = append(fopts, "required")
 				}
+				if fd.Nullable {
+					fopts = append(fopts, "nullable")
+				}
+				if fd.Default != nil {
+					fopts = append(fopts, "default="+ToString(fd.Default))
+				}
 				for aname, aval := range fd.Annotations {
-					fopts = append(fopts, fmt.Sprintf("%s=%q", aname, aval))
+					fopts = append(fopts, AnnotationOption(aname, aval))
 				}
 				s += fmt.Sprintf("%s%s%s%s %s%s\n", blockLine, bcom, indent+indentAmount, fd.Name, g.sadlTypeSpec(&fd.TypeSpec, fopts, indent+indentAmount), com)
 			}
-			return s + indent + "}"
+			sopts := ""
+			if len(opts) > 0 {
+				sopts = " (" + strings.Join(opts, ", ") + ")"
+			}
+			return s + indent + "}" + sopts
 		}
 		return fmt.Sprintf("Struct\n")
 	case "Union":
@@ -202,7 +271,7 @@ func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string)
 				}
 				fopts := []string{}
 				for aname, aval := range fd.Annotations {
-					fopts = append(fopts, fmt.Sprintf("%s=%q", aname, aval))
+					fopts = append(fopts, AnnotationOption(aname, aval))
 				}
 				s += fmt.Sprintf("%s%s%s %s%s\n", bcom, indentAmount, fd.Name, g.sadlTypeSpec(&fd.TypeSpec, fopts, indent+indentAmount), com)
 			}
@@ -218,6 +287,31 @@ func (g *SadlGenerator) sadlTypeSpec(ts *TypeSpec, opts []string, indent string)
 			return s + ">"
 		}
 	default:
+		//a named type, with any constraints given inline
+		if ts.Pattern != "" {
+			opts = append(opts, fmt.Sprintf("pattern=%q", ts.Pattern))
+		}
+		if ts.Values != nil {
+			opts = append(opts, fmt.Sprintf("values=%s", stringList(ts.Values)))
+		}
+		if ts.MinSize != nil {
+			opts = append(opts, fmt.Sprintf("minsize=%d", *ts.MinSize))
+		}
+		if ts.MaxSize != nil {
+			opts = append(opts, fmt.Sprintf("maxsize=%d", *ts.MaxSize))
+		}
+		if ts.Min != nil {
+			opts = append(opts, fmt.Sprintf("min=%v", ts.Min.String()))
+		}
+		if ts.Max != nil {
+			opts = append(opts, fmt.Sprintf("max=%v", ts.Max.String()))
+		}
+		if ts.Reference != "" {
+			opts = append(opts, "reference="+ts.Reference)
+		}
+		if ts.Unit != "" {
+			opts = append(opts, "unit="+ts.Unit)
+		}
 		sopts := ""
 		if len(opts) > 0 {
 			sopts = " (" + strings.Join(opts, ", ") + ")"
@@ -230,7 +324,7 @@ func (g *SadlGenerator) sadlOperationSpec(op *OperationDef) string {
 	var opts []string
 	if len(op.Annotations) > 0 {
 		for k, v := range op.Annotations {
-			opts = append(opts, fmt.Sprintf("%s=%q", k, v))
+			opts = append(opts, AnnotationOption(k, v))
 		}
 	}
 	opt := ""
@@ -293,16 +387,123 @@ func (g *SadlGenerator) sadlOperationSpec(op *OperationDef) string {
 	return s
 }
 
+func (g *SadlGenerator) sadlAuthDef(ad *AuthDef) string {
+	var opts []string
+	for _, opt := range []struct{ name, val string }{
+		{"header", ad.Header},
+		{"query", ad.Query},
+		{"cookie", ad.Cookie},
+		{"format", ad.Format},
+		{"authorizationUrl", ad.AuthorizationUrl},
+		{"tokenUrl", ad.TokenUrl},
+	} {
+		if opt.val != "" {
+			opts = append(opts, fmt.Sprintf("%s=%q", opt.name, opt.val))
+		}
+	}
+	if len(ad.Scopes) > 0 {
+		opts = append(opts, "scopes="+stringList(ad.Scopes))
+	}
+	for k, v := range ad.Annotations {
+		opts = append(opts, AnnotationOption(k, v))
+	}
+	opt := ""
+	if len(opts) > 0 {
+		opt = " (" + strings.Join(opts, ", ") + ")"
+	}
+	bcom := ""
+	if ad.Comment != "" {
+		bcom = g.FormatComment("", ad.Comment, 100, false)
+	}
+	return fmt.Sprintf("%sauth %s %s%s\n", bcom, ad.Name, ad.Scheme, opt)
+}
+
+func (g *SadlGenerator) sadlServiceDef(sd *ServiceDef) string {
+	var opts []string
+	if sd.Version != "" {
+		opts = append(opts, fmt.Sprintf("version=%q", sd.Version))
+	}
+	if sd.Base != "" {
+		opts = append(opts, fmt.Sprintf("base=%q", sd.Base))
+	}
+	for k, v := range sd.Annotations {
+		opts = append(opts, AnnotationOption(k, v))
+	}
+	opt := ""
+	if len(opts) > 0 {
+		opt = " (" + strings.Join(opts, ", ") + ")"
+	}
+	bcom := ""
+	if sd.Comment != "" {
+		bcom = g.FormatComment("", sd.Comment, 100, false)
+	}
+	return fmt.Sprintf("%sservice %s%s\n", bcom, sd.Name, opt)
+}
+
+func (g *SadlGenerator) sadlResourceDef(rd *ResourceDef) string {
+	var opts []string
+	if len(rd.Identifiers) > 0 {
+		opts = append(opts, "identifiers="+stringList(rd.Identifiers))
+	}
+	for _, op := range ResourceLifecycle {
+		if name := rd.LifecycleAction(op); name != "" {
+			opts = append(opts, op+"="+name)
+		}
+	}
+	for k, v := range rd.Annotations {
+		opts = append(opts, AnnotationOption(k, v))
+	}
+	opt := ""
+	if len(opts) > 0 {
+		opt = " (" + strings.Join(opts, ", ") + ")"
+	}
+	bcom := ""
+	if rd.Comment != "" {
+		bcom = g.FormatComment("", rd.Comment, 100, false)
+	}
+	return fmt.Sprintf("%sresource %s%s\n", bcom, rd.Name, opt)
+}
+
 func (g *SadlGenerator) sadlHttpSpec(hact *HttpDef) string {
 	var opts []string
+	//the name and resource are written unless the parser would derive them from the path, or the resource binding
+	derived := &HttpDef{Method: hact.Method, Resource: resourceName(hact)}
 	if hact.Name != "" {
-		if hact.Name != actionName(hact) {
+		if hact.Name != actionName(derived) || hact.Resource != derived.Resource {
 			opts = append(opts, "operation="+hact.Name)
 		}
 	}
+	if hact.Resource != "" && hact.Resource != derived.Resource {
+		if rd, _ := g.Model.HttpLifecycle(hact); rd == nil || rd.Name != hact.Resource {
+			opts = append(opts, "resource="+hact.Resource)
+		}
+	}
+	if hact.Service != "" {
+		opts = append(opts, "service="+hact.Service)
+	}
+	if hact.Anonymous {
+		opts = append(opts, "auth=[]")
+	} else if len(hact.Auth) == 1 {
+		opts = append(opts, fmt.Sprintf("auth=%q", hact.Auth[0]))
+	} else if len(hact.Auth) > 1 {
+		opts = append(opts, "auth="+stringList(hact.Auth))
+	}
+	if len(hact.Scopes) > 0 {
+		opts = append(opts, "scopes="+stringList(hact.Scopes))
+	}
+	if pg := hact.Paginated; pg != nil {
+		fields := fmt.Sprintf("%q: %q, %q: %q", "inputToken", pg.InputToken, "outputToken", pg.OutputToken)
+		if pg.PageSize != "" {
+			fields += fmt.Sprintf(", %q: %q", "pageSize", pg.PageSize)
+		}
+		if pg.Items != "" {
+			fields += fmt.Sprintf(", %q: %q", "items", pg.Items)
+		}
+		opts = append(opts, "paginated={"+fields+"}")
+	}
 	if len(hact.Annotations) > 0 {
 		for k, v := range hact.Annotations {
-			opts = append(opts, fmt.Sprintf("%s=%q", k, v))
+			opts = append(opts, AnnotationOption(k, v))
 		}
 	}
 	opt := ""
@@ -315,6 +516,17 @@ func (g *SadlGenerator) sadlHttpSpec(hact *HttpDef) string {
 	for _, in := range hact.Inputs {
 		s += indentAmount + g.sadlParamSpec(in)
 	}
+	if len(hact.Statements) > 0 {
+		//extension statements are written back as statements, since their keywords may also be option names
+		var keywords []string
+		for k := range hact.Statements {
+			keywords = append(keywords, k)
+		}
+		sort.Strings(keywords)
+		for _, k := range keywords {
+			s += indentAmount + g.sadlStatement(k, hact.Statements[k]) + "\n"
+		}
+	}
 	bcom := ""
 	if hact.Expected == nil {
 		hact.Expected = &HttpExpectedSpec{
@@ -332,22 +544,44 @@ func (g *SadlGenerator) sadlHttpSpec(hact *HttpDef) string {
 	if len(hact.Exceptions) > 0 {
 		s += "\n"
 		for _, exc := range hact.Exceptions {
-			bcom := ""
-			if exc.Comment != "" {
-				bcom = g.FormatComment(indentAmount, exc.Comment, 100, false)
-			}
-			if exc.Status == 0 {
-				s += fmt.Sprintf("%s%sexcept %s\n", bcom, indentAmount, exc.Type)
-			} else {
-				//todo: header outputs
-				s += fmt.Sprintf("%s%sexcept %d %s\n", bcom, indentAmount, exc.Status, exc.Type)
-			}
+			s += g.sadlExceptionSpec(exc, indentAmount)
 		}
 	}
 	s += "}\n"
 	return s
 }
 
+// sadlStatement writes back an extension statement of an http action. Without the extension that parsed it, the value
+// is written as that of an option would be.
+func (g *SadlGenerator) sadlStatement(keyword string, val interface{}) string {
+	for _, ext := range g.Extensions {
+		if hext, ok := ext.(HttpExtension); ok && containsOption(hext.Statements(), keyword) {
+			return keyword + " " + hext.UnparseStatement(keyword, val)
+		}
+	}
+	return strings.Replace(AnnotationOption(keyword, val), "=", " ", 1)
+}
+
+// sadlExceptionSpec returns an except statement, of an http action or, with no indent, common to all of them.
+func (g *SadlGenerator) sadlExceptionSpec(exc *HttpExceptionSpec, indent string) string {
+	bcom := ""
+	if exc.Comment != "" {
+		bcom = g.FormatComment(indent, exc.Comment, 100, false)
+	}
+	status := ""
+	if exc.Status != 0 {
+		status = fmt.Sprintf("%d ", exc.Status)
+	}
+	if len(exc.Outputs) > 0 {
+		s := fmt.Sprintf("%s%sexcept %s{\n", bcom, indent, status)
+		for _, out := range exc.Outputs {
+			s += indent + indentAmount + g.sadlParamSpec(out)
+		}
+		return s + indent + "}\n"
+	}
+	return fmt.Sprintf("%s%sexcept %s%s\n", bcom, indent, status, exc.Type)
+}
+
 func (g *SadlGenerator) sadlParamSpec(ps *HttpParamSpec) string {
 	var opts []string
 	if ps.Required {
@@ -359,21 +593,30 @@ func (g *SadlGenerator) sadlParamSpec(ps *HttpParamSpec) string {
 	if ps.Header != "" {
 		opts = append(opts, fmt.Sprintf("header=%q", ps.Header))
 	}
-	opt := ""
-	if len(opts) > 0 {
-		opt = " (" + strings.Join(opts, ", ") + ")"
+	if ps.Cookie != "" {
+		opts = append(opts, fmt.Sprintf("cookie=%q", ps.Cookie))
+	}
+	if ps.Csv {
+		opts = append(opts, "csv")
+	}
+	if ps.MediaType != "" {
+		opts = append(opts, fmt.Sprintf("mediaType=%q", ps.MediaType))
+	}
+	for aname, aval := range ps.Annotations {
+		opts = append(opts, AnnotationOption(aname, aval))
 	}
 	com := ""
 	bcom := ""
-	ts := g.sadlTypeSpec(&ps.TypeSpec, nil, indentAmount)
+	//the options are emitted with those of the type spec, so they are in one list
+	ts := g.sadlTypeSpec(&ps.TypeSpec, opts, indentAmount)
 	if ps.Comment != "" {
-		if (len(ps.Comment) + len(ps.Name) + len(ts) + len(opt)) > 100 {
+		if (len(ps.Comment) + len(ps.Name) + len(ts)) > 100 {
 			bcom = g.FormatComment("   ", ps.Comment, 100, false)[3:] + "   "
 		} else {
 			com = " // " + ps.Comment
 		}
 	}
-	return bcom + ps.Name + " " + ts + opt + com + "\n"
+	return bcom + ps.Name + " " + ts + com + "\n"
 }
 
 func stringList(lst []string) string {
@@ -397,8 +640,14 @@ const sadlTemplate = `{{if .Comment}}{{blockComment .Comment}}{{end}}{{if .Names
 {{end}}{{if .Name}}name {{.Name}}
 {{end}}{{if .Base}}base {{literal .Base}}
 {{end}}{{if .Version}}version "{{.Version}}"
-{{end}}{{annotations .Annotations}}{{if .Types}}{{range .Types}}
+{{end}}{{annotations .Annotations}}{{if .Constants}}{{range .Constants}}
+{{blockComment .Comment}}{{constant .}}{{end}}{{end}}{{if .AnnotationDefs}}{{range .AnnotationDefs}}
+{{blockComment .Comment}}{{annotationDef .}}{{end}}{{end}}{{if .Types}}{{range .Types}}
 {{blockComment .Comment}}{{typedef .}}{{end}}{{end}}{{if .Operations}}{{range .Operations}}
-{{blockComment .Comment}}{{operation .}}{{end}}{{end}}{{if .Http}}{{range .Http}}
+{{blockComment .Comment}}{{operation .}}{{end}}{{end}}{{if .Services}}
+{{range .Services}}{{service .}}{{end}}{{end}}{{if .Resources}}
+{{range .Resources}}{{resource .}}{{end}}{{end}}{{if .Auth}}
+{{range .Auth}}{{auth .}}{{end}}{{end}}{{if .Exceptions}}
+{{range .Exceptions}}{{exception .}}{{end}}{{end}}{{if .Http}}{{range .Http}}
 {{blockComment .Comment}}{{http .}}{{end}}{{end}}{{if .Examples}}{{range .Examples}}
 {{blockComment .Comment}}{{example .}}{{end}}{{end}}`